@@ -0,0 +1,140 @@
+// Package buildcontext inspects Docker build context directories.
+package buildcontext
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/moby/patternmatcher"
+	"github.com/moby/patternmatcher/ignorefile"
+)
+
+// HashResult is the result of hashing a build context.
+type HashResult struct {
+	// SHA256 is the hex encoded hash of the build context.
+	SHA256 string
+	// FileCount is the number of files included in the hash.
+	FileCount int
+}
+
+// ReadIgnorePatterns returns the exclude patterns for a build context.
+// As BuildKit does, <dockerfile>.dockerignore next to the Dockerfile takes precedence
+// over .dockerignore in the context directory. dockerfile may be empty.
+func ReadIgnorePatterns(contextDir, dockerfile string) ([]string, error) {
+	candidates := []string{}
+	if dockerfile != "" {
+		if !filepath.IsAbs(dockerfile) {
+			dockerfile = filepath.Join(contextDir, dockerfile)
+		}
+		candidates = append(candidates, dockerfile+".dockerignore")
+	}
+	candidates = append(candidates, filepath.Join(contextDir, ".dockerignore"))
+
+	for _, candidate := range candidates {
+		f, err := os.Open(candidate)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", candidate, err)
+		}
+		defer f.Close()
+		patterns, err := ignorefile.ReadAll(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", candidate, err)
+		}
+		return patterns, nil
+	}
+	return nil, nil
+}
+
+// Hash computes a hash of the files in contextDir that are sent to the builder,
+// excluding the files matched by excludes (in .dockerignore syntax).
+// The hash covers relative paths, file modes, symlink targets and file contents,
+// but not timestamps, so it is stable across checkouts.
+func Hash(contextDir string, excludes []string) (*HashResult, error) {
+	pm, err := patternmatcher.New(excludes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exclude patterns: %w", err)
+	}
+
+	h := sha256.New()
+	result := &HashResult{}
+	parentMatchInfo := map[string]patternmatcher.MatchInfo{}
+	// WalkDir visits entries in lexical order, which makes the hash deterministic.
+	err = filepath.WalkDir(contextDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(contextDir, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		parentPath := filepath.ToSlash(filepath.Dir(rel))
+		excluded, matchInfo, err := pm.MatchesUsingParentResults(rel, parentMatchInfo[parentPath])
+		if err != nil {
+			return fmt.Errorf("failed to match %s: %w", rel, err)
+		}
+		if d.IsDir() {
+			parentMatchInfo[rel] = matchInfo
+		}
+		if excluded {
+			// Files under an excluded directory can only be re-included by exclusion patterns ("!path").
+			if d.IsDir() && !pm.Exclusions() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		return hashEntry(h, path, rel, d, result)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash build context %s: %w", contextDir, err)
+	}
+	result.SHA256 = hex.EncodeToString(h.Sum(nil))
+	return result, nil
+}
+
+// hashEntry writes a single directory entry to h.
+func hashEntry(h hash.Hash, path, rel string, d fs.DirEntry, result *HashResult) error {
+	info, err := d.Info()
+	if err != nil {
+		return err
+	}
+	switch {
+	case d.IsDir():
+		fmt.Fprintf(h, "dir %s %o\n", rel, info.Mode().Perm())
+	case info.Mode()&fs.ModeSymlink != 0:
+		target, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "symlink %s %s\n", rel, target)
+		result.FileCount++
+	case info.Mode().IsRegular():
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		content := sha256.New()
+		if _, err := io.Copy(content, f); err != nil {
+			return fmt.Errorf("failed to read %s: %w", rel, err)
+		}
+		fmt.Fprintf(h, "file %s %o %x\n", rel, info.Mode().Perm(), content.Sum(nil))
+		result.FileCount++
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package buildx
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/providerconfig"
+)
+
+var (
+	// builderMu serializes EnsureRemoteBuilder so that concurrent resources do not create the builder in parallel.
+	builderMu sync.Mutex
+	// configuredBuilders records the builders already configured by this process, keyed by name.
+	configuredBuilders = map[string]providerconfig.RemoteBuilder{}
+	// builderEnv records the values of the environment variables set from the env of the configured builders.
+	builderEnv = map[string]string{}
+)
+
+// EnsureRemoteBuilder registers the remote builder described by b as a buildx builder instance named b.Name,
+// running the buildx plugin binary at buildxPath.
+// tcp:// and unix:// endpoints use the remote driver and connect to an existing BuildKit daemon;
+// ssh:// endpoints use the docker-container driver on the Docker engine reachable over SSH.
+// An existing builder with the same name is updated in place so that its build cache is kept.
+// Without an endpoint, the existing builder b.Name is only checked to exist and is left as configured.
+// b.Env is set in the environment of the process, which buildx inherits here and when Compose runs it for the builds.
+func EnsureRemoteBuilder(ctx context.Context, buildxPath string, b providerconfig.RemoteBuilder) error {
+	builderMu.Lock()
+	defer builderMu.Unlock()
+
+	if configured, ok := configuredBuilders[b.Name]; ok && reflect.DeepEqual(configured, b) {
+		return nil
+	}
+	if err := setBuilderEnv(b.Env); err != nil {
+		return err
+	}
+
+	if b.Endpoint == "" {
+		if err := runBuildx(ctx, buildxPath, "inspect", b.Name); err != nil {
+			return fmt.Errorf("builder %q not found: register it with `docker buildx create` or the CLI of the build service: %w", b.Name, err)
+		}
+		configuredBuilders[b.Name] = b
+		return nil
+	}
+
+	driver, err := remoteBuilderDriver(b.Endpoint)
+	if err != nil {
+		return err
+	}
+
+	args := []string{"create", "--name", b.Name, "--node", b.Name + "0", "--driver", driver}
+	if opts := remoteBuilderDriverOpts(b); opts != "" {
+		args = append(args, "--driver-opt", opts)
+	}
+	if err := runBuildx(ctx, buildxPath, "inspect", b.Name); err == nil {
+		args = append(args, "--append")
+	}
+	args = append(args, b.Endpoint)
+
+	tflog.Info(ctx, "Configuring remote buildx builder", map[string]interface{}{
+		"name":     b.Name,
+		"driver":   driver,
+		"endpoint": b.Endpoint,
+	})
+	if err := runBuildx(ctx, buildxPath, args...); err != nil {
+		return fmt.Errorf("failed to configure builder %q: %w", b.Name, err)
+	}
+
+	configuredBuilders[b.Name] = b
+	return nil
+}
+
+// setBuilderEnv sets env in the environment of the process.
+// Builders configured with different values for the same variable cannot be used by the same process.
+func setBuilderEnv(env map[string]string) error {
+	for name, value := range env {
+		if previous, ok := builderEnv[name]; ok && previous != value {
+			return fmt.Errorf("remote_builder env %s conflicts with the value configured for another builder", name)
+		}
+	}
+	for name, value := range env {
+		if err := os.Setenv(name, value); err != nil {
+			return fmt.Errorf("failed to set remote_builder env %s: %w", name, err)
+		}
+		builderEnv[name] = value
+	}
+	return nil
+}
+
+// ValidateRemoteBuilderEndpoint returns an error when endpoint cannot be used for a remote builder.
+func ValidateRemoteBuilderEndpoint(endpoint string) error {
+	_, err := remoteBuilderDriver(endpoint)
+	return err
+}
+
+// remoteBuilderDriver returns the buildx driver to use for endpoint.
+func remoteBuilderDriver(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid builder endpoint %q: %w", endpoint, err)
+	}
+	switch u.Scheme {
+	case "tcp", "unix":
+		return "remote", nil
+	case "ssh":
+		return "docker-container", nil
+	default:
+		return "", fmt.Errorf("unsupported builder endpoint %q: scheme must be tcp, unix or ssh", endpoint)
+	}
+}
+
+// remoteBuilderDriverOpts returns the --driver-opt value carrying the TLS material of b.
+func remoteBuilderDriverOpts(b providerconfig.RemoteBuilder) string {
+	var opts []string
+	if b.CACert != "" {
+		opts = append(opts, "cacert="+b.CACert)
+	}
+	if b.Cert != "" {
+		opts = append(opts, "cert="+b.Cert)
+	}
+	if b.Key != "" {
+		opts = append(opts, "key="+b.Key)
+	}
+	if b.ServerName != "" {
+		opts = append(opts, "servername="+b.ServerName)
+	}
+	return strings.Join(opts, ",")
+}
+
+// CurrentBuilderDriver returns the driver of the builder buildx uses when none is specified
+// (the one named by BUILDX_BUILDER, or the current builder selected with `docker buildx use`).
+func CurrentBuilderDriver(ctx context.Context, buildxPath string, env []string) (string, error) {
+	out, err := outputBuildx(ctx, buildxPath, env, "inspect")
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect the current builder: %w", err)
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if key, value, ok := strings.Cut(line, ":"); ok && strings.TrimSpace(key) == "Driver" {
+			return strings.TrimSpace(value), nil
+		}
+	}
+	return "", fmt.Errorf("failed to inspect the current builder: no driver in output: %s", strings.TrimSpace(out))
+}
+
+// CreateEphemeralBuilder creates and starts a docker-container builder with a random name on the current Docker engine,
+// allowing the builds to request entitlements (e.g. network.host).
+// The returned function removes the builder together with its container and build cache.
+func CreateEphemeralBuilder(ctx context.Context, buildxPath string, env []string, entitlements []string) (string, func(), error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", nil, fmt.Errorf("failed to generate builder name: %w", err)
+	}
+	name := "containerregistry-" + hex.EncodeToString(suffix)
+
+	tflog.Info(ctx, "Creating ephemeral buildx builder", map[string]interface{}{
+		"name":   name,
+		"driver": "docker-container",
+	})
+	args := []string{"create", "--name", name, "--driver", "docker-container", "--bootstrap"}
+	if len(entitlements) > 0 {
+		flags := make([]string, 0, len(entitlements))
+		for _, entitlement := range entitlements {
+			flags = append(flags, "--allow-insecure-entitlement="+entitlement)
+		}
+		args = append(args, "--buildkitd-flags", strings.Join(flags, " "))
+	}
+	if _, err := outputBuildx(ctx, buildxPath, env, args...); err != nil {
+		// A failed bootstrap may leave the builder registered
+		_, _ = outputBuildx(context.WithoutCancel(ctx), buildxPath, env, "rm", "--force", name)
+		return "", nil, fmt.Errorf("failed to create builder %q: %w", name, err)
+	}
+
+	remove := func() {
+		// Remove the builder even when the build was canceled
+		if _, err := outputBuildx(context.WithoutCancel(ctx), buildxPath, env, "rm", "--force", name); err != nil {
+			tflog.Warn(ctx, "Failed to remove ephemeral buildx builder", map[string]interface{}{
+				"name":  name,
+				"error": err.Error(),
+			})
+		}
+	}
+	return name, remove, nil
+}
+
+// runBuildx runs the buildx plugin binary with args, returning its output in the error on failure.
+func runBuildx(ctx context.Context, buildxPath string, args ...string) error {
+	_, err := outputBuildx(ctx, buildxPath, nil, args...)
+	return err
+}
+
+// outputBuildx runs the buildx plugin binary with args and env added to the environment, and returns its combined output.
+func outputBuildx(ctx context.Context, buildxPath string, env []string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, buildxPath, args...)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(out.String()))
+	}
+	return out.String(), nil
+}
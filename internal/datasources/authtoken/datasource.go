@@ -0,0 +1,174 @@
+package authtoken
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/logging"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/providerconfig"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ datasource.DataSource = &AuthTokenDataSource{}
+var _ datasource.DataSourceWithConfigure = &AuthTokenDataSource{}
+
+// NewAuthTokenDataSource returns a new data source implementing the containerregistry_auth_token data source type.
+func NewAuthTokenDataSource() datasource.DataSource {
+	return &AuthTokenDataSource{}
+}
+
+// AuthTokenDataSource defines the data source implementation.
+type AuthTokenDataSource struct {
+	providerConfig *providerconfig.Config
+}
+
+// Metadata returns the data source type name.
+func (d *AuthTokenDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_auth_token"
+}
+
+// Schema defines the schema for the data source.
+func (d *AuthTokenDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Returns the credentials for a registry and performs the registry token exchange with them, " +
+			"so that other providers (e.g. helm, kubernetes) can authenticate to the same registry. " +
+			"The credentials are those configured in `registry_auth`, or without them, an authorization token requested with the AWS credentials " +
+			"of the environment for AWS ECR, and a Google access token for Google Artifact Registry. " +
+			"Note that the values are stored in the Terraform state.",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Registry hostname",
+			},
+			"registry": schema.StringAttribute{
+				MarkdownDescription: "Registry hostname as used in `registry_auth` (e.g. `asia-northeast1-docker.pkg.dev`)",
+				Required:            true,
+			},
+			"repository": schema.StringAttribute{
+				MarkdownDescription: "Repository path in the registry (e.g. `project/repo/app`) to request a pull token for. " +
+					"Omit to use the scope requested by the registry.",
+				Optional: true,
+			},
+			"username": schema.StringAttribute{
+				MarkdownDescription: "Username for the registry: the one configured in `registry_auth`, `AWS` for ECR or `oauth2accesstoken` for Artifact Registry. " +
+					"Null for other registries without `registry_auth`.",
+				Computed: true,
+			},
+			"password": schema.StringAttribute{
+				MarkdownDescription: "Password for the registry: the one configured in `registry_auth`, the ECR authorization token or the Google access token. " +
+					"Null for other registries without `registry_auth`.",
+				Computed:  true,
+				Sensitive: true,
+			},
+			"token": schema.StringAttribute{
+				MarkdownDescription: "Bearer token issued by the registry token service. " +
+					"Null when the registry does not use bearer token authentication (e.g. AWS ECR).",
+				Computed:  true,
+				Sensitive: true,
+			},
+			"expires_at": schema.StringAttribute{
+				MarkdownDescription: "Expiry in RFC 3339 format of `token`, or of `password` when it is an ECR authorization token or a Google access token, " +
+					"whichever comes first. Null when neither is known.",
+				Computed: true,
+			},
+		},
+	}
+}
+
+// Configure adds the provider configuration to the data source.
+func (d *AuthTokenDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	if cfg, ok := req.ProviderData.(*providerconfig.Config); ok {
+		d.providerConfig = cfg
+	}
+}
+
+// Read obtains the credentials for the registry and performs the token exchange with them.
+func (d *AuthTokenDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	// Initialize the HTTP logging subsystem and header masking for this request.
+	ctx = logging.WithHTTPLoggingSubsystem(ctx)
+
+	var data AuthTokenDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	host := data.Registry.ValueString()
+	if host == "" {
+		resp.Diagnostics.AddAttributeError(path.Root("registry"), "Invalid registry", "registry must not be empty")
+		return
+	}
+	scope := ""
+	if !data.Repository.IsNull() && data.Repository.ValueString() != "" {
+		scope = fmt.Sprintf("repository:%s:pull", data.Repository.ValueString())
+	}
+
+	tflog.Info(ctx, "Requesting registry token", map[string]interface{}{
+		"registry": host,
+		"scope":    scope,
+	})
+
+	creds, err := exchangeCredentials(ctx, d.providerConfig, host)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error obtaining registry credentials",
+			fmt.Sprintf("Could not obtain credentials for %s: %s", host, err),
+		)
+		return
+	}
+	// The token exchange uses the credentials obtained above
+	clientConfig := &providerconfig.Config{}
+	if creds != nil {
+		clientConfig.RegistryAuth = map[string]providerconfig.RegistryAuthCredentials{
+			host: {Username: creds.Username, Password: creds.Password},
+		}
+	}
+	client, err := registryclient.New(clientConfig, host)
+	if err != nil {
+		resp.Diagnostics.AddError("Error configuring registry client", err.Error())
+		return
+	}
+	token, err := client.RequestToken(ctx, scope)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error requesting registry token",
+			fmt.Sprintf("Could not request a token for %s: %s", host, err),
+		)
+		return
+	}
+
+	data.ID = types.StringValue(host)
+	data.Username = types.StringNull()
+	data.Password = types.StringNull()
+	var expiresAt time.Time
+	if creds != nil {
+		data.Username = types.StringValue(creds.Username)
+		data.Password = types.StringValue(creds.Password)
+		expiresAt = creds.ExpiresAt
+	}
+	data.Token = types.StringNull()
+	if token != nil {
+		data.Token = types.StringValue(token.Token)
+		if !token.ExpiresAt.IsZero() && (expiresAt.IsZero() || token.ExpiresAt.Before(expiresAt)) {
+			expiresAt = token.ExpiresAt
+		}
+	}
+	data.ExpiresAt = types.StringNull()
+	if !expiresAt.IsZero() {
+		data.ExpiresAt = types.StringValue(expiresAt.UTC().Format(time.RFC3339))
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
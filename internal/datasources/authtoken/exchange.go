@@ -0,0 +1,144 @@
+package authtoken
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/providerconfig"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// garHostPattern matches Google Artifact Registry Docker hosts: <location>-docker.pkg.dev
+var garHostPattern = regexp.MustCompile(`^[a-z0-9-]+-docker\.pkg\.dev$`)
+
+// garUsername is the username Artifact Registry accepts with a Google OAuth 2.0 access token as the password.
+const garUsername = "oauth2accesstoken"
+
+// metadataTokenTimeout bounds the request to the Google Cloud metadata server, which does not answer outside Google Cloud.
+const metadataTokenTimeout = 5 * time.Second
+
+// registryCredentials are the credentials for a registry, with their expiry when known.
+type registryCredentials struct {
+	Username  string
+	Password  string
+	ExpiresAt time.Time
+}
+
+// exchangeCredentials returns the credentials for host: those configured in registry_auth,
+// an ECR authorization token for ECR registries, or a Google access token for Artifact Registry.
+// It returns nil for other registries without registry_auth.
+func exchangeCredentials(ctx context.Context, cfg *providerconfig.Config, host string) (*registryCredentials, error) {
+	if cfg != nil {
+		if creds, ok := cfg.RegistryAuth[host]; ok {
+			return &registryCredentials{Username: creds.Username, Password: creds.Password}, nil
+		}
+	}
+	if registry, ok := registryclient.ParseECRHost(host); ok {
+		return ecrCredentials(ctx, registry)
+	}
+	if garHostPattern.MatchString(host) {
+		token, expiresAt, err := googleAccessToken(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &registryCredentials{Username: garUsername, Password: token, ExpiresAt: expiresAt}, nil
+	}
+	return nil, nil
+}
+
+// ecrCredentials requests an authorization token for the ECR registry with the AWS credentials of the environment,
+// and returns the username and password it encodes.
+func ecrCredentials(ctx context.Context, registry registryclient.ECRRegistry) (*registryCredentials, error) {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(registry.Region))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	tflog.Debug(ctx, "Requesting ECR authorization token", map[string]interface{}{
+		"region": registry.Region,
+	})
+	out, err := ecr.NewFromConfig(cfg).GetAuthorizationToken(ctx, &ecr.GetAuthorizationTokenInput{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ECR authorization token: %w", err)
+	}
+	if len(out.AuthorizationData) == 0 {
+		return nil, errors.New("ECR returned no authorization token")
+	}
+	data := out.AuthorizationData[0]
+	decoded, err := base64.StdEncoding.DecodeString(aws.ToString(data.AuthorizationToken))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode ECR authorization token: %w", err)
+	}
+	username, password, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return nil, errors.New("invalid ECR authorization token")
+	}
+	return &registryCredentials{Username: username, Password: password, ExpiresAt: aws.ToTime(data.ExpiresAt)}, nil
+}
+
+// googleAccessToken returns a Google OAuth 2.0 access token and its expiry, when known: GOOGLE_OAUTH_ACCESS_TOKEN,
+// the token of the active gcloud account, or the token of the service account of the Google Cloud metadata server.
+func googleAccessToken(ctx context.Context) (string, time.Time, error) {
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		return token, time.Time{}, nil
+	}
+	if gcloud, err := exec.LookPath("gcloud"); err == nil {
+		out, err := exec.CommandContext(ctx, gcloud, "auth", "print-access-token").Output()
+		if err == nil {
+			return strings.TrimSpace(string(out)), time.Time{}, nil
+		}
+		tflog.Warn(ctx, "Failed to get an access token from gcloud", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+	token, expiresAt, err := metadataAccessToken(ctx)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("no Google credentials found: set GOOGLE_OAUTH_ACCESS_TOKEN, log in with gcloud, "+
+			"run on Google Cloud or configure registry_auth (%w)", err)
+	}
+	return token, expiresAt, nil
+}
+
+// metadataAccessToken requests an access token of the default service account from the Google Cloud metadata server.
+func metadataAccessToken(ctx context.Context) (string, time.Time, error) {
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = "metadata.google.internal"
+	}
+	ctx, cancel := context.WithTimeout(ctx, metadataTokenTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+host+"/computeMetadata/v1/instance/service-accounts/default/token", nil)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to reach the metadata server: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", time.Time{}, fmt.Errorf("the metadata server returned status %d", resp.StatusCode)
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to decode the metadata server token: %w", err)
+	}
+	return token.AccessToken, time.Now().Add(time.Duration(token.ExpiresIn) * time.Second), nil
+}
@@ -0,0 +1,16 @@
+package authtoken
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// AuthTokenDataSourceModel describes the containerregistry_auth_token data source data model.
+type AuthTokenDataSourceModel struct {
+	ID         types.String `tfsdk:"id"`
+	Registry   types.String `tfsdk:"registry"`
+	Repository types.String `tfsdk:"repository"`
+	Username   types.String `tfsdk:"username"`
+	Password   types.String `tfsdk:"password"`
+	Token      types.String `tfsdk:"token"`
+	ExpiresAt  types.String `tfsdk:"expires_at"`
+}
@@ -0,0 +1,112 @@
+package contexthash
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/buildcontext"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ datasource.DataSource = &ContextHashDataSource{}
+
+// NewContextHashDataSource returns a new data source implementing the containerregistry_context_hash data source type.
+func NewContextHashDataSource() datasource.DataSource {
+	return &ContextHashDataSource{}
+}
+
+// ContextHashDataSource defines the data source implementation.
+type ContextHashDataSource struct{}
+
+// Metadata returns the data source type name.
+func (d *ContextHashDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_context_hash"
+}
+
+// Schema defines the schema for the data source.
+func (d *ContextHashDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Computes a hash of a build context directory honoring `.dockerignore`, " +
+			"to be used in `triggers` so that images are rebuilt when the sources change",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Hash of the build context",
+			},
+			"path": schema.StringAttribute{
+				MarkdownDescription: "Build context directory",
+				Required:            true,
+			},
+			"dockerfile": schema.StringAttribute{
+				MarkdownDescription: "Dockerfile path relative to `path`. When `<dockerfile>.dockerignore` exists, " +
+					"it is used instead of `.dockerignore` as BuildKit does.",
+				Optional: true,
+			},
+			"excludes": schema.ListAttribute{
+				MarkdownDescription: "Additional exclude patterns in `.dockerignore` syntax",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"sha256": schema.StringAttribute{
+				MarkdownDescription: "Hex encoded SHA-256 hash of the paths, modes and contents of the files in the build context. " +
+					"Timestamps are not included.",
+				Computed: true,
+			},
+			"file_count": schema.Int64Attribute{
+				MarkdownDescription: "Number of files included in the hash",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+// Read computes the hash of the build context.
+func (d *ContextHashDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data ContextHashDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	contextDir := data.Path.ValueString()
+	excludes, err := buildcontext.ReadIgnorePatterns(contextDir, data.Dockerfile.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Error reading .dockerignore", err.Error())
+		return
+	}
+	if !data.Excludes.IsNull() {
+		var extra []string
+		resp.Diagnostics.Append(data.Excludes.ElementsAs(ctx, &extra, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		excludes = append(excludes, extra...)
+	}
+
+	result, err := buildcontext.Hash(contextDir, excludes)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error hashing build context",
+			fmt.Sprintf("Could not hash %s: %s", contextDir, err),
+		)
+		return
+	}
+
+	tflog.Debug(ctx, "Computed build context hash", map[string]interface{}{
+		"path":       contextDir,
+		"sha256":     result.SHA256,
+		"file_count": result.FileCount,
+	})
+
+	data.ID = types.StringValue(result.SHA256)
+	data.SHA256 = types.StringValue(result.SHA256)
+	data.FileCount = types.Int64Value(int64(result.FileCount))
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
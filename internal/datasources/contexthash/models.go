@@ -0,0 +1,15 @@
+package contexthash
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// ContextHashDataSourceModel describes the containerregistry_context_hash data source data model.
+type ContextHashDataSourceModel struct {
+	ID         types.String `tfsdk:"id"`
+	Path       types.String `tfsdk:"path"`
+	Dockerfile types.String `tfsdk:"dockerfile"`
+	Excludes   types.List   `tfsdk:"excludes"`
+	SHA256     types.String `tfsdk:"sha256"`
+	FileCount  types.Int64  `tfsdk:"file_count"`
+}
@@ -0,0 +1,182 @@
+package imageconfig
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/logging"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/providerconfig"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ datasource.DataSource = &ImageConfigDataSource{}
+var _ datasource.DataSourceWithConfigure = &ImageConfigDataSource{}
+
+// NewImageConfigDataSource returns a new data source implementing the containerregistry_image_config data source type.
+func NewImageConfigDataSource() datasource.DataSource {
+	return &ImageConfigDataSource{}
+}
+
+// ImageConfigDataSource defines the data source implementation.
+type ImageConfigDataSource struct {
+	providerConfig *providerconfig.Config
+}
+
+// Metadata returns the data source type name.
+func (d *ImageConfigDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_image_config"
+}
+
+// Schema defines the schema for the data source.
+func (d *ImageConfigDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Reads the configuration (entrypoint, command, environment, ports) of an image in a container registry",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Image URI",
+			},
+			"image_uri": schema.StringAttribute{
+				MarkdownDescription: "Image reference with a tag or digest (e.g. `ghcr.io/owner/app:v1`). `latest` is used when neither is given.",
+				Required:            true,
+			},
+			"platform": schema.StringAttribute{
+				MarkdownDescription: "Platform (`os/arch[/variant]`, e.g. `linux/arm64`) to read for a multi-platform image. " +
+					"Defaults to the first platform in the image index. Ignored for single-platform images.",
+				Optional: true,
+			},
+			"entrypoint": schema.ListAttribute{
+				MarkdownDescription: "Entrypoint of the image",
+				Computed:            true,
+				ElementType:         types.StringType,
+			},
+			"cmd": schema.ListAttribute{
+				MarkdownDescription: "Default command of the image",
+				Computed:            true,
+				ElementType:         types.StringType,
+			},
+			"env": schema.MapAttribute{
+				MarkdownDescription: "Environment variables of the image",
+				Computed:            true,
+				ElementType:         types.StringType,
+			},
+			"exposed_ports": schema.ListAttribute{
+				MarkdownDescription: "Ports exposed by the image in `port/protocol` form (e.g. `8080/tcp`), sorted",
+				Computed:            true,
+				ElementType:         types.StringType,
+			},
+			"working_dir": schema.StringAttribute{
+				MarkdownDescription: "Working directory of the image",
+				Computed:            true,
+			},
+			"user": schema.StringAttribute{
+				MarkdownDescription: "User the image runs as",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+// Configure adds the provider configuration to the data source.
+func (d *ImageConfigDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	if cfg, ok := req.ProviderData.(*providerconfig.Config); ok {
+		d.providerConfig = cfg
+	}
+}
+
+// Read fetches the image configuration from the registry.
+func (d *ImageConfigDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	// Initialize the HTTP logging subsystem and header masking for this request.
+	ctx = logging.WithHTTPLoggingSubsystem(ctx)
+
+	var data ImageConfigDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	host, repository, ref, err := registryclient.ParseImageReference(data.ImageURI.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("image_uri"), "Invalid image URI", err.Error())
+		return
+	}
+	platform := data.Platform.ValueString()
+	if platform != "" {
+		if _, err := registryclient.ParsePlatform(platform); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("platform"), "Invalid platform", err.Error())
+			return
+		}
+	}
+
+	tflog.Info(ctx, "Fetching image config", map[string]interface{}{
+		"registry":   host,
+		"repository": repository,
+		"reference":  ref,
+		"platform":   platform,
+	})
+
+	client, err := registryclient.New(d.providerConfig, host)
+	if err != nil {
+		resp.Diagnostics.AddError("Error configuring registry client", err.Error())
+		return
+	}
+	config, err := client.GetImageConfig(ctx, repository, ref, platform)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error fetching image config",
+			fmt.Sprintf("Could not fetch the image config of %s: %s", data.ImageURI.ValueString(), err),
+		)
+		return
+	}
+
+	env := make(map[string]string, len(config.Config.Env))
+	for _, e := range config.Config.Env {
+		k, v, _ := strings.Cut(e, "=")
+		env[k] = v
+	}
+	ports := make([]string, 0, len(config.Config.ExposedPorts))
+	for port := range config.Config.ExposedPorts {
+		ports = append(ports, port)
+	}
+	sort.Strings(ports)
+
+	var diags diag.Diagnostics
+	data.Entrypoint, diags = types.ListValueFrom(ctx, types.StringType, nonNil(config.Config.Entrypoint))
+	resp.Diagnostics.Append(diags...)
+	data.Cmd, diags = types.ListValueFrom(ctx, types.StringType, nonNil(config.Config.Cmd))
+	resp.Diagnostics.Append(diags...)
+	data.Env, diags = types.MapValueFrom(ctx, types.StringType, env)
+	resp.Diagnostics.Append(diags...)
+	data.ExposedPorts, diags = types.ListValueFrom(ctx, types.StringType, ports)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	data.ID = types.StringValue(data.ImageURI.ValueString())
+	data.WorkingDir = types.StringValue(config.Config.WorkingDir)
+	data.User = types.StringValue(config.Config.User)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// nonNil returns an empty slice for nil so that unset values become empty lists rather than null.
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,18 @@
+package imageconfig
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// ImageConfigDataSourceModel describes the containerregistry_image_config data source data model.
+type ImageConfigDataSourceModel struct {
+	ID           types.String `tfsdk:"id"`
+	ImageURI     types.String `tfsdk:"image_uri"`
+	Platform     types.String `tfsdk:"platform"`
+	Entrypoint   types.List   `tfsdk:"entrypoint"`
+	Cmd          types.List   `tfsdk:"cmd"`
+	Env          types.Map    `tfsdk:"env"`
+	ExposedPorts types.List   `tfsdk:"exposed_ports"`
+	WorkingDir   types.String `tfsdk:"working_dir"`
+	User         types.String `tfsdk:"user"`
+}
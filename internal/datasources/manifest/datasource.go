@@ -0,0 +1,120 @@
+package manifest
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/logging"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/providerconfig"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ datasource.DataSource = &ManifestDataSource{}
+var _ datasource.DataSourceWithConfigure = &ManifestDataSource{}
+
+// NewManifestDataSource returns a new data source implementing the containerregistry_manifest data source type.
+func NewManifestDataSource() datasource.DataSource {
+	return &ManifestDataSource{}
+}
+
+// ManifestDataSource defines the data source implementation.
+type ManifestDataSource struct {
+	providerConfig *providerconfig.Config
+}
+
+// Metadata returns the data source type name.
+func (d *ManifestDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_manifest"
+}
+
+// Schema defines the schema for the data source.
+func (d *ManifestDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Fetches the raw manifest of an image in a container registry",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Digest of the manifest",
+			},
+			"image_uri": schema.StringAttribute{
+				MarkdownDescription: "Image reference with a tag or digest (e.g. `ghcr.io/owner/app:v1`). `latest` is used when neither is given.",
+				Required:            true,
+			},
+			"manifest": schema.StringAttribute{
+				MarkdownDescription: "Raw manifest JSON as returned by the registry. For multi-platform images, this is the image index.",
+				Computed:            true,
+			},
+			"media_type": schema.StringAttribute{
+				MarkdownDescription: "Media type of the manifest",
+				Computed:            true,
+			},
+			"digest": schema.StringAttribute{
+				MarkdownDescription: "Digest of the manifest",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+// Configure adds the provider configuration to the data source.
+func (d *ManifestDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	if cfg, ok := req.ProviderData.(*providerconfig.Config); ok {
+		d.providerConfig = cfg
+	}
+}
+
+// Read fetches the manifest from the registry.
+func (d *ManifestDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	// Initialize the HTTP logging subsystem and header masking for this request.
+	ctx = logging.WithHTTPLoggingSubsystem(ctx)
+
+	var data ManifestDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	host, repository, ref, err := registryclient.ParseImageReference(data.ImageURI.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("image_uri"), "Invalid image URI", err.Error())
+		return
+	}
+
+	tflog.Info(ctx, "Fetching manifest", map[string]interface{}{
+		"registry":   host,
+		"repository": repository,
+		"reference":  ref,
+	})
+
+	client, err := registryclient.New(d.providerConfig, host)
+	if err != nil {
+		resp.Diagnostics.AddError("Error configuring registry client", err.Error())
+		return
+	}
+	manifest, err := client.GetManifest(ctx, repository, ref)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error fetching manifest",
+			fmt.Sprintf("Could not fetch manifest of %s: %s", data.ImageURI.ValueString(), err),
+		)
+		return
+	}
+
+	data.ID = types.StringValue(manifest.Digest)
+	data.Manifest = types.StringValue(string(manifest.Body))
+	data.MediaType = types.StringValue(manifest.MediaType)
+	data.Digest = types.StringValue(manifest.Digest)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,14 @@
+package manifest
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// ManifestDataSourceModel describes the containerregistry_manifest data source data model.
+type ManifestDataSourceModel struct {
+	ID        types.String `tfsdk:"id"`
+	ImageURI  types.String `tfsdk:"image_uri"`
+	Manifest  types.String `tfsdk:"manifest"`
+	MediaType types.String `tfsdk:"media_type"`
+	Digest    types.String `tfsdk:"digest"`
+}
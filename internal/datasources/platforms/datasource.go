@@ -0,0 +1,154 @@
+package platforms
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/logging"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/providerconfig"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ datasource.DataSource = &PlatformsDataSource{}
+var _ datasource.DataSourceWithConfigure = &PlatformsDataSource{}
+
+// NewPlatformsDataSource returns a new data source implementing the containerregistry_platforms data source type.
+func NewPlatformsDataSource() datasource.DataSource {
+	return &PlatformsDataSource{}
+}
+
+// PlatformsDataSource defines the data source implementation.
+type PlatformsDataSource struct {
+	providerConfig *providerconfig.Config
+}
+
+// Metadata returns the data source type name.
+func (d *PlatformsDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_platforms"
+}
+
+// Schema defines the schema for the data source.
+func (d *PlatformsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Lists the platforms of an image in a container registry",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Image URI",
+			},
+			"image_uri": schema.StringAttribute{
+				MarkdownDescription: "Image reference with a tag or digest (e.g. `ghcr.io/owner/app:v1`). `latest` is used when neither is given.",
+				Required:            true,
+			},
+			"digest": schema.StringAttribute{
+				MarkdownDescription: "Digest of the image index, or of the manifest for a single-platform image",
+				Computed:            true,
+			},
+			"platforms": schema.ListNestedAttribute{
+				MarkdownDescription: "Platforms of the image in the order of the image index. Attestation manifests are excluded.",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"platform": schema.StringAttribute{
+							MarkdownDescription: "Platform in `os/arch[/variant]` form (e.g. `linux/arm64/v8`)",
+							Computed:            true,
+						},
+						"os": schema.StringAttribute{
+							MarkdownDescription: "Operating system",
+							Computed:            true,
+						},
+						"architecture": schema.StringAttribute{
+							MarkdownDescription: "CPU architecture",
+							Computed:            true,
+						},
+						"variant": schema.StringAttribute{
+							MarkdownDescription: "CPU variant. Empty when not specified.",
+							Computed:            true,
+						},
+						"digest": schema.StringAttribute{
+							MarkdownDescription: "Digest of the image manifest for the platform",
+							Computed:            true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Configure adds the provider configuration to the data source.
+func (d *PlatformsDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	if cfg, ok := req.ProviderData.(*providerconfig.Config); ok {
+		d.providerConfig = cfg
+	}
+}
+
+// Read lists the platforms of the image.
+func (d *PlatformsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	// Initialize the HTTP logging subsystem and header masking for this request.
+	ctx = logging.WithHTTPLoggingSubsystem(ctx)
+
+	var data PlatformsDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	host, repository, ref, err := registryclient.ParseImageReference(data.ImageURI.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("image_uri"), "Invalid image URI", err.Error())
+		return
+	}
+
+	tflog.Info(ctx, "Listing image platforms", map[string]interface{}{
+		"registry":   host,
+		"repository": repository,
+		"reference":  ref,
+	})
+
+	client, err := registryclient.New(d.providerConfig, host)
+	if err != nil {
+		resp.Diagnostics.AddError("Error configuring registry client", err.Error())
+		return
+	}
+	manifest, platforms, err := client.ListPlatforms(ctx, repository, ref)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error listing image platforms",
+			fmt.Sprintf("Could not list the platforms of %s: %s", data.ImageURI.ValueString(), err),
+		)
+		return
+	}
+
+	entries := make([]PlatformModel, 0, len(platforms))
+	for _, p := range platforms {
+		entries = append(entries, PlatformModel{
+			Platform:     types.StringValue(p.Platform.String()),
+			OS:           types.StringValue(p.Platform.OS),
+			Architecture: types.StringValue(p.Platform.Architecture),
+			Variant:      types.StringValue(p.Platform.Variant),
+			Digest:       types.StringValue(p.Digest),
+		})
+	}
+	platformsList, diags := types.ListValueFrom(ctx, types.ObjectType{AttrTypes: platformAttrTypes}, entries)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	data.ID = types.StringValue(data.ImageURI.ValueString())
+	data.Digest = types.StringValue(manifest.Digest)
+	data.Platforms = platformsList
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
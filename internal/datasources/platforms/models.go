@@ -0,0 +1,32 @@
+package platforms
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// PlatformsDataSourceModel describes the containerregistry_platforms data source data model.
+type PlatformsDataSourceModel struct {
+	ID        types.String `tfsdk:"id"`
+	ImageURI  types.String `tfsdk:"image_uri"`
+	Digest    types.String `tfsdk:"digest"`
+	Platforms types.List   `tfsdk:"platforms"`
+}
+
+// PlatformModel describes an entry of platforms.
+type PlatformModel struct {
+	Platform     types.String `tfsdk:"platform"`
+	OS           types.String `tfsdk:"os"`
+	Architecture types.String `tfsdk:"architecture"`
+	Variant      types.String `tfsdk:"variant"`
+	Digest       types.String `tfsdk:"digest"`
+}
+
+// platformAttrTypes are the attribute types of PlatformModel.
+var platformAttrTypes = map[string]attr.Type{
+	"platform":     types.StringType,
+	"os":           types.StringType,
+	"architecture": types.StringType,
+	"variant":      types.StringType,
+	"digest":       types.StringType,
+}
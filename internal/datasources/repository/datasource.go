@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/logging"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/providerconfig"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ datasource.DataSource = &RepositoryDataSource{}
+var _ datasource.DataSourceWithConfigure = &RepositoryDataSource{}
+
+// NewRepositoryDataSource returns a new data source implementing the containerregistry_repository data source type.
+func NewRepositoryDataSource() datasource.DataSource {
+	return &RepositoryDataSource{}
+}
+
+// RepositoryDataSource defines the data source implementation.
+type RepositoryDataSource struct {
+	providerConfig *providerconfig.Config
+}
+
+// Metadata returns the data source type name.
+func (d *RepositoryDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_repository"
+}
+
+// Schema defines the schema for the data source.
+func (d *RepositoryDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Reports whether a repository exists in a container registry",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Normalized repository reference",
+			},
+			"repository": schema.StringAttribute{
+				MarkdownDescription: "Repository to look up (e.g. `asia-northeast1-docker.pkg.dev/project/repo/app`). " +
+					"A tag or digest in the value is ignored.",
+				Required: true,
+			},
+			"exists": schema.BoolAttribute{
+				MarkdownDescription: "Whether the repository exists",
+				Computed:            true,
+			},
+			"tag_count": schema.Int64Attribute{
+				MarkdownDescription: "Number of tags in the repository. 0 when the repository does not exist.",
+				Computed:            true,
+			},
+			"ecr_repository_arn": schema.StringAttribute{
+				MarkdownDescription: "ARN of the repository when the registry is AWS ECR. Null otherwise.",
+				Computed:            true,
+			},
+			"gar_repository_name": schema.StringAttribute{
+				MarkdownDescription: "Resource name of the repository (`projects/<project>/locations/<location>/repositories/<repository>`) " +
+					"when the registry is Google Artifact Registry. Null otherwise.",
+				Computed: true,
+			},
+		},
+	}
+}
+
+// Configure adds the provider configuration to the data source.
+func (d *RepositoryDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	if cfg, ok := req.ProviderData.(*providerconfig.Config); ok {
+		d.providerConfig = cfg
+	}
+}
+
+// Read looks up the repository.
+func (d *RepositoryDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	// Initialize the HTTP logging subsystem and header masking for this request.
+	ctx = logging.WithHTTPLoggingSubsystem(ctx)
+
+	var data RepositoryDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	host, repository, err := registryclient.ParseRepository(data.Repository.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("repository"), "Invalid repository", err.Error())
+		return
+	}
+
+	tflog.Info(ctx, "Looking up repository", map[string]interface{}{
+		"registry":   host,
+		"repository": repository,
+	})
+
+	client, err := registryclient.New(d.providerConfig, host)
+	if err != nil {
+		resp.Diagnostics.AddError("Error configuring registry client", err.Error())
+		return
+	}
+	tags, err := client.ListTags(ctx, repository)
+	switch {
+	case err == nil:
+		data.Exists = types.BoolValue(true)
+		data.TagCount = types.Int64Value(int64(len(tags)))
+	case registryclient.IsNotFound(err):
+		tflog.Debug(ctx, "Repository not found", map[string]interface{}{
+			"repository": repository,
+			"error":      err.Error(),
+		})
+		data.Exists = types.BoolValue(false)
+		data.TagCount = types.Int64Value(0)
+	default:
+		resp.Diagnostics.AddError(
+			"Error looking up repository",
+			fmt.Sprintf("Could not look up %s: %s", data.Repository.ValueString(), err),
+		)
+		return
+	}
+
+	data.ID = types.StringValue(host + "/" + repository)
+	data.ECRRepositoryARN = types.StringNull()
+	if arn := ecrRepositoryARN(host, repository); arn != "" {
+		data.ECRRepositoryARN = types.StringValue(arn)
+	}
+	data.GARRepositoryName = types.StringNull()
+	if name := garRepositoryName(host, repository); name != "" {
+		data.GARRepositoryName = types.StringValue(name)
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// RepositoryDataSourceModel describes the containerregistry_repository data source data model.
+type RepositoryDataSourceModel struct {
+	ID                types.String `tfsdk:"id"`
+	Repository        types.String `tfsdk:"repository"`
+	Exists            types.Bool   `tfsdk:"exists"`
+	TagCount          types.Int64  `tfsdk:"tag_count"`
+	ECRRepositoryARN  types.String `tfsdk:"ecr_repository_arn"`
+	GARRepositoryName types.String `tfsdk:"gar_repository_name"`
+}
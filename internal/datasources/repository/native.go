@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// garHostPattern matches Google Artifact Registry Docker hosts: <location>-docker.pkg.dev
+var garHostPattern = regexp.MustCompile(`^([a-z0-9-]+)-docker\.pkg\.dev$`)
+
+// ecrRepositoryARN returns the ARN of the ECR repository, or an empty string when host is not an ECR registry.
+func ecrRepositoryARN(host, repository string) string {
+	registry, ok := registryclient.ParseECRHost(host)
+	if !ok {
+		return ""
+	}
+	partition := "aws"
+	if registry.China {
+		partition = "aws-cn"
+	}
+	return fmt.Sprintf("arn:%s:ecr:%s:%s:repository/%s", partition, registry.Region, registry.Account, repository)
+}
+
+// garRepositoryName returns the resource name of the Artifact Registry repository containing the image
+// (projects/<project>/locations/<location>/repositories/<repository>),
+// or an empty string when host is not an Artifact Registry host.
+func garRepositoryName(host, repository string) string {
+	m := garHostPattern.FindStringSubmatch(host)
+	if m == nil {
+		return ""
+	}
+	// Artifact Registry image paths are <project>/<repository>/<image...>.
+	parts := strings.SplitN(repository, "/", 3)
+	if len(parts) < 3 {
+		return ""
+	}
+	return fmt.Sprintf("projects/%s/locations/%s/repositories/%s", parts[0], m[1], parts[1])
+}
@@ -0,0 +1,116 @@
+package resolvedref
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/logging"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/providerconfig"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ datasource.DataSource = &ResolvedRefDataSource{}
+var _ datasource.DataSourceWithConfigure = &ResolvedRefDataSource{}
+
+// NewResolvedRefDataSource returns a new data source implementing the containerregistry_resolved_ref data source type.
+func NewResolvedRefDataSource() datasource.DataSource {
+	return &ResolvedRefDataSource{}
+}
+
+// ResolvedRefDataSource defines the data source implementation.
+type ResolvedRefDataSource struct {
+	providerConfig *providerconfig.Config
+}
+
+// Metadata returns the data source type name.
+func (d *ResolvedRefDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_resolved_ref"
+}
+
+// Schema defines the schema for the data source.
+func (d *ResolvedRefDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Resolves an image tag to its current digest and returns the digest-pinned reference",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Digest-pinned reference",
+			},
+			"image_uri": schema.StringAttribute{
+				MarkdownDescription: "Image reference to resolve (e.g. `nginx:1.27`). `latest` is used when no tag is given.",
+				Required:            true,
+			},
+			"digest": schema.StringAttribute{
+				MarkdownDescription: "Current digest of the manifest (or image index) the tag points to",
+				Computed:            true,
+			},
+			"pinned_ref": schema.StringAttribute{
+				MarkdownDescription: "Fully qualified digest-pinned reference (e.g. `docker.io/library/nginx@sha256:...`)",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+// Configure adds the provider configuration to the data source.
+func (d *ResolvedRefDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	if cfg, ok := req.ProviderData.(*providerconfig.Config); ok {
+		d.providerConfig = cfg
+	}
+}
+
+// Read resolves the tag to a digest.
+func (d *ResolvedRefDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	// Initialize the HTTP logging subsystem and header masking for this request.
+	ctx = logging.WithHTTPLoggingSubsystem(ctx)
+
+	var data ResolvedRefDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	host, repository, ref, err := registryclient.ParseImageReference(data.ImageURI.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("image_uri"), "Invalid image URI", err.Error())
+		return
+	}
+
+	tflog.Info(ctx, "Resolving image reference", map[string]interface{}{
+		"registry":   host,
+		"repository": repository,
+		"reference":  ref,
+	})
+
+	client, err := registryclient.New(d.providerConfig, host)
+	if err != nil {
+		resp.Diagnostics.AddError("Error configuring registry client", err.Error())
+		return
+	}
+	digest, err := client.ResolveDigest(ctx, repository, ref)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error resolving image reference",
+			fmt.Sprintf("Could not resolve %s: %s", data.ImageURI.ValueString(), err),
+		)
+		return
+	}
+
+	pinned := fmt.Sprintf("%s/%s@%s", host, repository, digest)
+	data.ID = types.StringValue(pinned)
+	data.Digest = types.StringValue(digest)
+	data.PinnedRef = types.StringValue(pinned)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,13 @@
+package resolvedref
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// ResolvedRefDataSourceModel describes the containerregistry_resolved_ref data source data model.
+type ResolvedRefDataSourceModel struct {
+	ID        types.String `tfsdk:"id"`
+	ImageURI  types.String `tfsdk:"image_uri"`
+	Digest    types.String `tfsdk:"digest"`
+	PinnedRef types.String `tfsdk:"pinned_ref"`
+}
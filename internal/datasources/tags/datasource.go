@@ -0,0 +1,147 @@
+package tags
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/logging"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/providerconfig"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ datasource.DataSource = &TagsDataSource{}
+var _ datasource.DataSourceWithConfigure = &TagsDataSource{}
+
+// NewTagsDataSource returns a new data source implementing the containerregistry_tags data source type.
+func NewTagsDataSource() datasource.DataSource {
+	return &TagsDataSource{}
+}
+
+// TagsDataSource defines the data source implementation.
+type TagsDataSource struct {
+	providerConfig *providerconfig.Config
+}
+
+// Metadata returns the data source type name.
+func (d *TagsDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_tags"
+}
+
+// Schema defines the schema for the data source.
+func (d *TagsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Lists the tags of a repository in a container registry",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Repository the tags were listed for",
+			},
+			"repository": schema.StringAttribute{
+				MarkdownDescription: "Repository to list tags of (e.g. `asia-northeast1-docker.pkg.dev/project/repo/app`). " +
+					"A tag or digest in the value is ignored.",
+				Required: true,
+			},
+			"prefix": schema.StringAttribute{
+				MarkdownDescription: "Only return tags starting with this prefix",
+				Optional:            true,
+			},
+			"regex": schema.StringAttribute{
+				MarkdownDescription: "Only return tags matching this regular expression (RE2 syntax)",
+				Optional:            true,
+			},
+			"tags": schema.ListAttribute{
+				MarkdownDescription: "Tags of the repository, in the order returned by the registry",
+				Computed:            true,
+				ElementType:         types.StringType,
+			},
+		},
+	}
+}
+
+// Configure adds the provider configuration to the data source.
+func (d *TagsDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	if cfg, ok := req.ProviderData.(*providerconfig.Config); ok {
+		d.providerConfig = cfg
+	}
+}
+
+// Read lists the tags of the repository.
+func (d *TagsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	// Initialize the HTTP logging subsystem and header masking for this request.
+	ctx = logging.WithHTTPLoggingSubsystem(ctx)
+
+	var data TagsDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var re *regexp.Regexp
+	if !data.Regex.IsNull() {
+		var err error
+		re, err = regexp.Compile(data.Regex.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("regex"), "Invalid regular expression", err.Error())
+			return
+		}
+	}
+
+	host, repository, err := registryclient.ParseRepository(data.Repository.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("repository"), "Invalid repository", err.Error())
+		return
+	}
+
+	tflog.Info(ctx, "Listing tags", map[string]interface{}{
+		"registry":   host,
+		"repository": repository,
+	})
+
+	client, err := registryclient.New(d.providerConfig, host)
+	if err != nil {
+		resp.Diagnostics.AddError("Error configuring registry client", err.Error())
+		return
+	}
+	allTags, err := client.ListTags(ctx, repository)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error listing tags",
+			fmt.Sprintf("Could not list tags of %s: %s", data.Repository.ValueString(), err),
+		)
+		return
+	}
+
+	tags := make([]string, 0, len(allTags))
+	for _, tag := range allTags {
+		if !data.Prefix.IsNull() && !strings.HasPrefix(tag, data.Prefix.ValueString()) {
+			continue
+		}
+		if re != nil && !re.MatchString(tag) {
+			continue
+		}
+		tags = append(tags, tag)
+	}
+
+	tagsList, diags := types.ListValueFrom(ctx, types.StringType, tags)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	data.ID = types.StringValue(host + "/" + repository)
+	data.Tags = tagsList
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
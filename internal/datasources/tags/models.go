@@ -0,0 +1,14 @@
+package tags
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// TagsDataSourceModel describes the containerregistry_tags data source data model.
+type TagsDataSourceModel struct {
+	ID         types.String `tfsdk:"id"`
+	Repository types.String `tfsdk:"repository"`
+	Prefix     types.String `tfsdk:"prefix"`
+	Regex      types.String `tfsdk:"regex"`
+	Tags       types.List   `tfsdk:"tags"`
+}
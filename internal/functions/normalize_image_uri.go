@@ -0,0 +1,62 @@
+package functions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/distribution/reference"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ function.Function = &NormalizeImageURIFunction{}
+
+// NewNormalizeImageURIFunction returns a new function implementing normalize_image_uri.
+func NewNormalizeImageURIFunction() function.Function {
+	return &NormalizeImageURIFunction{}
+}
+
+// NormalizeImageURIFunction defines the normalize_image_uri function.
+type NormalizeImageURIFunction struct{}
+
+// Metadata returns the function name.
+func (f *NormalizeImageURIFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "normalize_image_uri"
+}
+
+// Definition defines the parameters and return type of the function.
+func (f *NormalizeImageURIFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Normalizes an image reference",
+		MarkdownDescription: "Returns the fully qualified form of an image reference: Docker Hub short names are expanded " +
+			"(`nginx` becomes `docker.io/library/nginx:latest`), the registry hostname is lowercased " +
+			"and `latest` is added when neither tag nor digest is given.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "uri",
+				MarkdownDescription: "Image reference to normalize",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+// Run normalizes the image reference.
+func (f *NormalizeImageURIFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var uri string
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &uri))
+	if resp.Error != nil {
+		return
+	}
+
+	named, err := reference.ParseNormalizedNamed(uri)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("invalid image reference %q: %s", uri, err))
+		return
+	}
+
+	// Hostnames are case-insensitive, but reference keeps them as written.
+	normalized := strings.ToLower(reference.Domain(named)) + strings.TrimPrefix(reference.TagNameOnly(named).String(), reference.Domain(named))
+	resp.Error = resp.Result.Set(ctx, normalized)
+}
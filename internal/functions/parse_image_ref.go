@@ -0,0 +1,91 @@
+// Package functions implements the provider-defined functions of the provider.
+package functions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/distribution/reference"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ function.Function = &ParseImageRefFunction{}
+
+// imageRefAttrTypes are the attribute types of the object returned by parse_image_ref.
+var imageRefAttrTypes = map[string]attr.Type{
+	"registry":   types.StringType,
+	"repository": types.StringType,
+	"tag":        types.StringType,
+	"digest":     types.StringType,
+}
+
+// NewParseImageRefFunction returns a new function implementing parse_image_ref.
+func NewParseImageRefFunction() function.Function {
+	return &ParseImageRefFunction{}
+}
+
+// ParseImageRefFunction defines the parse_image_ref function.
+type ParseImageRefFunction struct{}
+
+// Metadata returns the function name.
+func (f *ParseImageRefFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "parse_image_ref"
+}
+
+// Definition defines the parameters and return type of the function.
+func (f *ParseImageRefFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Parses an image reference into its components",
+		MarkdownDescription: "Parses an image reference such as `ghcr.io/owner/app:v1` into `registry`, `repository`, `tag` and `digest`. " +
+			"Docker Hub short names are normalized (e.g. `nginx` has registry `docker.io` and repository `library/nginx`). " +
+			"`tag` and `digest` are null when not present.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "uri",
+				MarkdownDescription: "Image reference to parse",
+			},
+		},
+		Return: function.ObjectReturn{
+			AttributeTypes: imageRefAttrTypes,
+		},
+	}
+}
+
+// Run parses the image reference.
+func (f *ParseImageRefFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var uri string
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &uri))
+	if resp.Error != nil {
+		return
+	}
+
+	named, err := reference.ParseNormalizedNamed(uri)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("invalid image reference %q: %s", uri, err))
+		return
+	}
+
+	tag := types.StringNull()
+	if tagged, ok := named.(reference.Tagged); ok {
+		tag = types.StringValue(tagged.Tag())
+	}
+	digest := types.StringNull()
+	if digested, ok := named.(reference.Digested); ok {
+		digest = types.StringValue(digested.Digest().String())
+	}
+
+	result, diags := types.ObjectValue(imageRefAttrTypes, map[string]attr.Value{
+		"registry":   types.StringValue(reference.Domain(named)),
+		"repository": types.StringValue(reference.Path(named)),
+		"tag":        tag,
+		"digest":     digest,
+	})
+	resp.Error = function.FuncErrorFromDiags(ctx, diags)
+	if resp.Error != nil {
+		return
+	}
+	resp.Error = resp.Result.Set(ctx, result)
+}
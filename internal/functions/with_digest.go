@@ -0,0 +1,69 @@
+package functions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/distribution/reference"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	ocidigest "github.com/opencontainers/go-digest"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ function.Function = &WithDigestFunction{}
+
+// NewWithDigestFunction returns a new function implementing with_digest.
+func NewWithDigestFunction() function.Function {
+	return &WithDigestFunction{}
+}
+
+// WithDigestFunction defines the with_digest function.
+type WithDigestFunction struct{}
+
+// Metadata returns the function name.
+func (f *WithDigestFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "with_digest"
+}
+
+// Definition defines the parameters and return type of the function.
+func (f *WithDigestFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Pins an image reference to a digest",
+		MarkdownDescription: "Returns `uri` with `digest` appended (e.g. `ghcr.io/owner/app:v1@sha256:...`). " +
+			"A digest already in `uri` is replaced. The tag is kept as is.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "uri",
+				MarkdownDescription: "Image reference",
+			},
+			function.StringParameter{
+				Name:                "digest",
+				MarkdownDescription: "Digest to pin to (e.g. `sha256:...`)",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+// Run appends the digest to the image reference.
+func (f *WithDigestFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var uri, digest string
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &uri, &digest))
+	if resp.Error != nil {
+		return
+	}
+
+	if _, err := ocidigest.Parse(digest); err != nil {
+		resp.Error = function.NewArgumentFuncError(1, fmt.Sprintf("invalid digest %q: %s", digest, err))
+		return
+	}
+	if _, err := reference.ParseNormalizedNamed(uri); err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("invalid image reference %q: %s", uri, err))
+		return
+	}
+
+	// Keep the reference as written (short names stay short) and only replace the digest part.
+	name, _, _ := strings.Cut(uri, "@")
+	resp.Error = resp.Result.Set(ctx, name+"@"+digest)
+}
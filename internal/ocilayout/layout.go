@@ -0,0 +1,237 @@
+// Package ocilayout reads images stored on disk as an OCI image layout or a docker-archive tarball
+// (as written by `docker save`, ko, bazel or buildah) and pushes them with the Registry HTTP API,
+// without a Docker daemon. It also writes images saved from the Docker daemon back to disk.
+package ocilayout
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	ocidigest "github.com/opencontainers/go-digest"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/tarball"
+)
+
+// Media types of image content written by docker-archive tarballs.
+const (
+	mediaTypeOCIConfig        = "application/vnd.oci.image.config.v1+json"
+	mediaTypeOCILayer         = "application/vnd.oci.image.layer.v1.tar"
+	mediaTypeOCILayerGzip     = "application/vnd.oci.image.layer.v1.tar+gzip"
+	ociLayoutFile             = "oci-layout"
+	ociIndexFile              = "index.json"
+	dockerArchiveManifestFile = "manifest.json"
+)
+
+// Image is an image read from disk, ready to be pushed.
+type Image struct {
+	// Root is the descriptor of the top-level image manifest or image index.
+	Root registryclient.Descriptor
+
+	// dir is the root of the OCI image layout, or empty for docker-archive images.
+	dir string
+	// files maps blob digests to the files holding them for docker-archive images.
+	files map[string]string
+	// manifests holds manifests generated for docker-archive images, keyed by digest.
+	manifests map[string][]byte
+}
+
+// Open reads the image stored in dir, which is either an OCI image layout
+// (with oci-layout and index.json) or an extracted docker-archive (with manifest.json).
+func Open(dir string) (*Image, error) {
+	if _, err := os.Stat(filepath.Join(dir, ociLayoutFile)); err == nil {
+		return openLayout(dir)
+	}
+	if _, err := os.Stat(filepath.Join(dir, dockerArchiveManifestFile)); err == nil {
+		return openDockerArchive(dir)
+	}
+	return nil, fmt.Errorf("%s is neither an OCI image layout nor a docker-archive: %s or %s not found", dir, ociLayoutFile, dockerArchiveManifestFile)
+}
+
+// OpenTarball extracts the tarball at path (optionally gzip-compressed) into a temporary directory
+// and reads the image in it with Open. The returned cleanup function removes the temporary directory.
+func OpenTarball(path string) (*Image, func(), error) {
+	dir, err := os.MkdirTemp("", "containerregistry-tarball-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	cleanup := func() { _ = os.RemoveAll(dir) }
+
+	if err := tarball.Extract(path, dir); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	img, err := Open(dir)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return img, cleanup, nil
+}
+
+// blobPath returns the file holding the blob digest.
+func (img *Image) blobPath(digest string) (string, error) {
+	if img.dir == "" {
+		path, ok := img.files[digest]
+		if !ok {
+			return "", fmt.Errorf("blob %s not found in docker-archive", digest)
+		}
+		return path, nil
+	}
+	d, err := ocidigest.Parse(digest)
+	if err != nil {
+		return "", fmt.Errorf("invalid digest %q: %w", digest, err)
+	}
+	return filepath.Join(img.dir, "blobs", d.Algorithm().String(), d.Encoded()), nil
+}
+
+// readManifest returns the body of the manifest or image index digest.
+func (img *Image) readManifest(digest string) ([]byte, error) {
+	if body, ok := img.manifests[digest]; ok {
+		return body, nil
+	}
+	path, err := img.blobPath(digest)
+	if err != nil {
+		return nil, err
+	}
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest %s: %w", digest, err)
+	}
+	return body, nil
+}
+
+// openLayout reads an OCI image layout. index.json must reference exactly one image.
+func openLayout(dir string) (*Image, error) {
+	body, err := os.ReadFile(filepath.Join(dir, ociIndexFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", ociIndexFile, err)
+	}
+	var index struct {
+		Manifests []registryclient.Descriptor `json:"manifests"`
+	}
+	if err := json.Unmarshal(body, &index); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", ociIndexFile, err)
+	}
+	if len(index.Manifests) != 1 {
+		return nil, fmt.Errorf("%s in %s must reference exactly one image, but references %d", ociIndexFile, dir, len(index.Manifests))
+	}
+	root := index.Manifests[0]
+	// The ref name annotation names the image in the layout; it is not part of the pushed descriptor
+	root.Annotations = nil
+	return &Image{Root: root, dir: dir}, nil
+}
+
+// openDockerArchive reads an extracted docker-archive with a single image.
+// The layers are uncompressed tarballs, so an OCI image manifest referencing them as is is generated.
+func openDockerArchive(dir string) (*Image, error) {
+	entry, err := readDockerArchiveManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	img := &Image{
+		files:     map[string]string{},
+		manifests: map[string][]byte{},
+	}
+	config, err := img.addFile(dir, entry.Config, mediaTypeOCIConfig)
+	if err != nil {
+		return nil, err
+	}
+	manifest := registryclient.ImageManifest{
+		SchemaVersion: 2,
+		MediaType:     registryclient.MediaTypeOCIManifest,
+		Config:        config,
+		Layers:        make([]registryclient.Descriptor, 0, len(entry.Layers)),
+	}
+	for _, name := range entry.Layers {
+		layer, err := img.addFile(dir, name, mediaTypeOCILayer)
+		if err != nil {
+			return nil, err
+		}
+		if gzipped, err := isGzip(img.files[layer.Digest]); err != nil {
+			return nil, err
+		} else if gzipped {
+			layer.MediaType = mediaTypeOCILayerGzip
+		}
+		manifest.Layers = append(manifest.Layers, layer)
+	}
+
+	manifestBody, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	digest := ocidigest.FromBytes(manifestBody).String()
+	img.manifests[digest] = manifestBody
+	img.Root = registryclient.Descriptor{
+		MediaType: registryclient.MediaTypeOCIManifest,
+		Digest:    digest,
+		Size:      int64(len(manifestBody)),
+	}
+	return img, nil
+}
+
+// dockerArchiveEntry is an image in manifest.json of a docker-archive.
+type dockerArchiveEntry struct {
+	Config   string   `json:"Config"`
+	RepoTags []string `json:"RepoTags,omitempty"`
+	Layers   []string `json:"Layers"`
+}
+
+// readDockerArchiveManifest reads manifest.json of the extracted docker-archive in dir, which must hold a single image.
+func readDockerArchiveManifest(dir string) (dockerArchiveEntry, error) {
+	body, err := os.ReadFile(filepath.Join(dir, dockerArchiveManifestFile))
+	if err != nil {
+		return dockerArchiveEntry{}, fmt.Errorf("failed to read %s: %w", dockerArchiveManifestFile, err)
+	}
+	var entries []dockerArchiveEntry
+	if err := json.Unmarshal(body, &entries); err != nil {
+		return dockerArchiveEntry{}, fmt.Errorf("failed to decode %s: %w", dockerArchiveManifestFile, err)
+	}
+	if len(entries) != 1 {
+		return dockerArchiveEntry{}, fmt.Errorf("docker-archive must contain exactly one image, but contains %d", len(entries))
+	}
+	return entries[0], nil
+}
+
+// addFile registers the file name (relative to dir) as a blob and returns its descriptor.
+func (img *Image) addFile(dir, name, mediaType string) (registryclient.Descriptor, error) {
+	path, err := tarball.SecurePath(dir, name)
+	if err != nil {
+		return registryclient.Descriptor{}, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return registryclient.Descriptor{}, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer f.Close()
+	digester := ocidigest.Canonical.Digester()
+	size, err := io.Copy(digester.Hash(), f)
+	if err != nil {
+		return registryclient.Descriptor{}, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	digest := digester.Digest().String()
+	img.files[digest] = path
+	return registryclient.Descriptor{MediaType: mediaType, Digest: digest, Size: size}, nil
+}
+
+// isGzip reports whether the file at path starts with the gzip magic number.
+func isGzip(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	magic := make([]byte, 2)
+	if _, err := io.ReadFull(f, magic); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return false, nil
+		}
+		return false, err
+	}
+	return magic[0] == 0x1f && magic[1] == 0x8b, nil
+}
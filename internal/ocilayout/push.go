@@ -0,0 +1,105 @@
+package ocilayout
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// PushStats summarizes the blobs handled by Push.
+type PushStats struct {
+	// BlobsPushed is the number of blobs (configs and layers) uploaded to the registry.
+	BlobsPushed int
+	// BlobsExisting is the number of blobs the registry already had.
+	BlobsExisting int
+	// PushedBytes is the total size of the uploaded blobs.
+	PushedBytes int64
+}
+
+// Push uploads the blobs and manifests of img to repository and tags the top-level manifest with tag.
+// Image indexes are pushed with all the manifests they reference. It returns the pushed manifest digest.
+func Push(ctx context.Context, c *registryclient.Client, repository, tag string, img *Image) (string, *PushStats, error) {
+	p := &pusher{client: c, repository: repository, img: img, stats: &PushStats{}}
+	digest, err := p.pushManifest(ctx, img.Root, tag)
+	if err != nil {
+		return "", nil, err
+	}
+	return digest, p.stats, nil
+}
+
+type pusher struct {
+	client     *registryclient.Client
+	repository string
+	img        *Image
+	stats      *PushStats
+}
+
+// pushManifest pushes the manifest or image index desc and everything it references,
+// with ref (a tag, or empty to push by digest).
+func (p *pusher) pushManifest(ctx context.Context, desc registryclient.Descriptor, ref string) (string, error) {
+	body, err := p.img.readManifest(desc.Digest)
+	if err != nil {
+		return "", err
+	}
+	manifest := &registryclient.Manifest{Body: body, MediaType: desc.MediaType, Digest: desc.Digest}
+	content, err := manifest.Content()
+	if err != nil {
+		return "", err
+	}
+	// Some tools omit the media type in index.json; the manifest itself carries it
+	if manifest.MediaType == "" {
+		manifest.MediaType = content.MediaType
+	}
+
+	switch manifest.MediaType {
+	case registryclient.MediaTypeOCIIndex, registryclient.MediaTypeDockerManifestList:
+		for _, child := range content.Manifests {
+			if _, err := p.pushManifest(ctx, child, ""); err != nil {
+				return "", fmt.Errorf("failed to push manifest %s: %w", child.Digest, err)
+			}
+		}
+	case registryclient.MediaTypeOCIManifest, registryclient.MediaTypeDockerManifest:
+		blobs := append([]registryclient.Descriptor{content.Config}, content.Layers...)
+		for _, blob := range blobs {
+			if err := p.pushBlob(ctx, blob); err != nil {
+				return "", err
+			}
+		}
+	default:
+		return "", fmt.Errorf("unsupported manifest media type %q", manifest.MediaType)
+	}
+
+	if ref == "" {
+		ref = desc.Digest
+	}
+	return p.client.PutManifest(ctx, p.repository, ref, manifest.MediaType, body)
+}
+
+// pushBlob uploads the blob desc unless the registry already has it.
+func (p *pusher) pushBlob(ctx context.Context, desc registryclient.Descriptor) error {
+	path, err := p.img.blobPath(desc.Digest)
+	if err != nil {
+		return err
+	}
+	uploaded, err := p.client.UploadBlobFile(ctx, p.repository, desc, path)
+	if err != nil {
+		return fmt.Errorf("failed to upload blob %s: %w", desc.Digest, err)
+	}
+	if uploaded {
+		p.stats.BlobsPushed++
+		p.stats.PushedBytes += desc.Size
+		tflog.Info(ctx, "Pushed blob", map[string]interface{}{
+			"digest": desc.Digest,
+			"bytes":  desc.Size,
+		})
+	} else {
+		p.stats.BlobsExisting++
+		tflog.Info(ctx, "Blob already exists in the registry", map[string]interface{}{
+			"digest": desc.Digest,
+		})
+	}
+	return nil
+}
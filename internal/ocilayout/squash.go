@@ -0,0 +1,313 @@
+package ocilayout
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	ocidigest "github.com/opencontainers/go-digest"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/tarball"
+)
+
+// Whiteout markers of image layers: a file deleted by the layer, and a directory whose lower contents are hidden.
+const (
+	whiteoutPrefix = ".wh."
+	whiteoutOpaque = ".wh..wh..opq"
+)
+
+// SquashArchive reads the image tarball r (as returned by the Docker Engine image save API) and writes to w
+// a docker-archive of the same image whose layers are merged into a single layer, tagged as refName.
+// Files deleted or replaced in upper layers are dropped, so the layer holds the filesystem the container sees.
+// The history of the image is kept, with the original steps marked as empty layers.
+// It returns the number of layers of the original image.
+func SquashArchive(r io.Reader, w io.Writer, refName string) (int, error) {
+	tmp, err := os.CreateTemp("", "containerregistry-squash-*.tar")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to save image: %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", "containerregistry-squash-")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := tarball.Extract(tmp.Name(), dir); err != nil {
+		return 0, err
+	}
+	entry, err := readDockerArchiveManifest(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	// Merge the layers into a single uncompressed layer, whose digest is also its diff ID
+	layer, err := os.CreateTemp("", "containerregistry-squash-*.tar")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(layer.Name())
+	digester := ocidigest.Canonical.Digester()
+	err = squashLayers(dir, entry.Layers, io.MultiWriter(layer, digester.Hash()))
+	if closeErr := layer.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	diffID := digester.Digest()
+
+	configPath, err := tarball.SecurePath(dir, entry.Config)
+	if err != nil {
+		return 0, err
+	}
+	configBody, err := os.ReadFile(configPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read image config: %w", err)
+	}
+	configBody, err = squashConfig(configBody, diffID.String(), len(entry.Layers))
+	if err != nil {
+		return 0, err
+	}
+	configDigest := ocidigest.FromBytes(configBody)
+
+	configName := path.Join("blobs", configDigest.Algorithm().String(), configDigest.Encoded())
+	layerName := path.Join("blobs", diffID.Algorithm().String(), diffID.Encoded())
+	manifestBody, err := json.Marshal([]dockerArchiveEntry{{
+		Config:   configName,
+		RepoTags: []string{refName},
+		Layers:   []string{layerName},
+	}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode %s: %w", dockerArchiveManifestFile, err)
+	}
+
+	tw := tar.NewWriter(w)
+	if err := writeTarBytes(tw, dockerArchiveManifestFile, manifestBody); err != nil {
+		return 0, err
+	}
+	if err := writeTarBytes(tw, configName, configBody); err != nil {
+		return 0, err
+	}
+	if err := writeTarFile(tw, layerName, layer.Name()); err != nil {
+		return 0, err
+	}
+	if err := tw.Close(); err != nil {
+		return 0, fmt.Errorf("failed to write squashed image: %w", err)
+	}
+	return len(entry.Layers), nil
+}
+
+// squashLayers writes to w a tarball of the filesystem built by applying the layers (relative to dir, bottom first) in order.
+// The layers are read from the top, and an entry is kept unless an upper layer already has it,
+// deletes it with a whiteout, or replaces a parent directory with a file or an opaque directory.
+func squashLayers(dir string, layers []string, w io.Writer) error {
+	s := &squasher{
+		tw:      tar.NewWriter(w),
+		entries: map[string]bool{},
+		deleted: map[string]bool{},
+		opaque:  map[string]bool{},
+	}
+	for i := len(layers) - 1; i >= 0; i-- {
+		layerPath, err := tarball.SecurePath(dir, layers[i])
+		if err != nil {
+			return err
+		}
+		if err := s.addLayer(layerPath); err != nil {
+			return fmt.Errorf("failed to squash layer %s: %w", layers[i], err)
+		}
+	}
+	// Hard links are written last, as their targets may come from lower layers read after them
+	for _, hdr := range s.links {
+		if err := s.tw.WriteHeader(hdr); err != nil {
+			return fmt.Errorf("failed to write squashed layer: %w", err)
+		}
+	}
+	if err := s.tw.Close(); err != nil {
+		return fmt.Errorf("failed to write squashed layer: %w", err)
+	}
+	return nil
+}
+
+type squasher struct {
+	tw *tar.Writer
+	// entries maps the paths written so far to whether they are directories.
+	entries map[string]bool
+	// deleted holds the paths deleted by whiteouts of upper layers.
+	deleted map[string]bool
+	// opaque holds the directories whose contents in lower layers are hidden.
+	opaque map[string]bool
+	// links holds the hard links to write after the other entries.
+	links []*tar.Header
+}
+
+// addLayer writes the entries of the layer at layerPath that are visible from the layers above.
+func (s *squasher) addLayer(layerPath string) error {
+	f, err := os.Open(layerPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if gzipped, err := isGzip(layerPath); err != nil {
+		return err
+	} else if gzipped {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	// Whiteouts only apply to the layers below, not to the entries of the same layer
+	deleted := map[string]bool{}
+	opaque := map[string]bool{}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "/"))
+		if name == "." {
+			continue
+		}
+		dirName, base := path.Split(name)
+		dirName = path.Clean(dirName)
+		if base == whiteoutOpaque {
+			opaque[dirName] = true
+			continue
+		}
+		if target, ok := strings.CutPrefix(base, whiteoutPrefix); ok {
+			deleted[path.Join(dirName, target)] = true
+			continue
+		}
+		if _, ok := s.entries[name]; ok || s.hidden(name) {
+			continue
+		}
+		s.entries[name] = hdr.Typeflag == tar.TypeDir
+		if hdr.Typeflag == tar.TypeLink {
+			s.links = append(s.links, hdr)
+			continue
+		}
+		if err := s.tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := io.Copy(s.tw, tr); err != nil {
+			return err
+		}
+	}
+	for name := range deleted {
+		s.deleted[name] = true
+	}
+	for name := range opaque {
+		s.opaque[name] = true
+	}
+	return nil
+}
+
+// hidden reports whether name in a lower layer is hidden by the layers above.
+func (s *squasher) hidden(name string) bool {
+	if s.deleted[name] {
+		return true
+	}
+	for parent := path.Dir(name); parent != "."; parent = path.Dir(parent) {
+		if s.deleted[parent] || s.opaque[parent] {
+			return true
+		}
+		if isDir, ok := s.entries[parent]; ok && !isDir {
+			return true
+		}
+	}
+	return false
+}
+
+// squashConfig returns the image config configBody with the single layer diffID, marking the original steps
+// of the history as empty layers and appending a step for the squashed layer.
+func squashConfig(configBody []byte, diffID string, layers int) ([]byte, error) {
+	var config map[string]json.RawMessage
+	if err := json.Unmarshal(configBody, &config); err != nil {
+		return nil, fmt.Errorf("failed to decode image config: %w", err)
+	}
+	rootfs, err := json.Marshal(map[string]interface{}{
+		"type":     "layers",
+		"diff_ids": []string{diffID},
+	})
+	if err != nil {
+		return nil, err
+	}
+	config["rootfs"] = rootfs
+
+	var history []map[string]json.RawMessage
+	if body, ok := config["history"]; ok {
+		if err := json.Unmarshal(body, &history); err != nil {
+			return nil, fmt.Errorf("failed to decode image history: %w", err)
+		}
+	}
+	for _, step := range history {
+		step["empty_layer"] = json.RawMessage("true")
+	}
+	step := map[string]json.RawMessage{}
+	if created, ok := config["created"]; ok {
+		step["created"] = created
+	}
+	comment, err := json.Marshal(fmt.Sprintf("squashed %d layers", layers))
+	if err != nil {
+		return nil, err
+	}
+	step["created_by"] = json.RawMessage(`"containerregistry squash"`)
+	step["comment"] = comment
+	history = append(history, step)
+	if config["history"], err = json.Marshal(history); err != nil {
+		return nil, err
+	}
+	return json.Marshal(config)
+}
+
+// writeTarBytes writes body as the regular file name to tw.
+func writeTarBytes(tw *tar.Writer, name string, body []byte) error {
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(body)), Typeflag: tar.TypeReg}); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if _, err := tw.Write(body); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// writeTarFile writes the file at filePath as the regular file name to tw.
+func writeTarFile(tw *tar.Writer, name, filePath string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", filepath.Base(filePath), err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", filepath.Base(filePath), err)
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: info.Size(), Typeflag: tar.TypeReg}); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if _, err := io.Copy(tw, f); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
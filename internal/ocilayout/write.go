@@ -0,0 +1,191 @@
+package ocilayout
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	ocidigest "github.com/opencontainers/go-digest"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/tarball"
+)
+
+// Formats accepted by ExportArchive.
+const (
+	// FormatDocker writes the docker-archive tarball as is.
+	FormatDocker = "docker"
+	// FormatOCI writes an OCI image layout directory.
+	FormatOCI = "oci"
+)
+
+// annotationRefName is the index.json annotation naming an image in an OCI image layout.
+const annotationRefName = "org.opencontainers.image.ref.name"
+
+// ExportArchive writes the image tarball read from r (as returned by the Docker Engine image save API)
+// to path in format: FormatDocker writes the tarball to the file path, and FormatOCI writes an OCI image layout
+// into the directory path, naming the image refName.
+func ExportArchive(r io.Reader, format, path, refName string) error {
+	switch format {
+	case FormatDocker:
+		return writeStream(path, r)
+	case FormatOCI:
+		tmp, err := os.CreateTemp("", "containerregistry-export-*.tar")
+		if err != nil {
+			return fmt.Errorf("failed to create temporary file: %w", err)
+		}
+		defer os.Remove(tmp.Name())
+		_, err = io.Copy(tmp, r)
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("failed to save image: %w", err)
+		}
+
+		img, cleanup, err := OpenTarball(tmp.Name())
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		if err := os.MkdirAll(path, 0755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+		return WriteLayout(img, path, refName)
+	default:
+		return fmt.Errorf("unsupported export format %q: must be %s or %s", format, FormatDocker, FormatOCI)
+	}
+}
+
+// WriteLayout writes img as an OCI image layout into dir, naming it refName in index.json.
+// Manifests of an image index that are not available in img (e.g. platforms that were not pulled) are skipped.
+func WriteLayout(img *Image, dir, refName string) error {
+	w := &layoutWriter{img: img, dir: dir}
+	if err := w.writeManifest(img.Root); err != nil {
+		return err
+	}
+
+	root := img.Root
+	if refName != "" {
+		root.Annotations = map[string]string{annotationRefName: refName}
+	}
+	index, err := json.Marshal(struct {
+		SchemaVersion int                         `json:"schemaVersion"`
+		MediaType     string                      `json:"mediaType"`
+		Manifests     []registryclient.Descriptor `json:"manifests"`
+	}{
+		SchemaVersion: 2,
+		MediaType:     registryclient.MediaTypeOCIIndex,
+		Manifests:     []registryclient.Descriptor{root},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", ociIndexFile, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ociIndexFile), index, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", ociIndexFile, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ociLayoutFile), []byte(`{"imageLayoutVersion":"1.0.0"}`), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", ociLayoutFile, err)
+	}
+	return nil
+}
+
+type layoutWriter struct {
+	img *Image
+	dir string
+}
+
+// writeManifest writes the manifest or image index desc and everything it references.
+func (w *layoutWriter) writeManifest(desc registryclient.Descriptor) error {
+	body, err := w.img.readManifest(desc.Digest)
+	if err != nil {
+		return err
+	}
+	manifest := &registryclient.Manifest{Body: body, MediaType: desc.MediaType, Digest: desc.Digest}
+	content, err := manifest.Content()
+	if err != nil {
+		return err
+	}
+
+	if len(content.Manifests) > 0 {
+		for _, child := range content.Manifests {
+			if err := w.writeManifest(child); err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					continue
+				}
+				return fmt.Errorf("failed to write manifest %s: %w", child.Digest, err)
+			}
+		}
+	} else {
+		for _, blob := range append([]registryclient.Descriptor{content.Config}, content.Layers...) {
+			if err := w.copyBlob(blob.Digest); err != nil {
+				return err
+			}
+		}
+	}
+
+	target, err := w.targetPath(desc.Digest)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(target, body, 0644)
+}
+
+// copyBlob copies the blob digest into the layout unless it is already there.
+func (w *layoutWriter) copyBlob(digest string) error {
+	target, err := w.targetPath(digest)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(target); err == nil {
+		return nil
+	}
+	source, err := w.img.blobPath(digest)
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(source)
+	if err != nil {
+		return fmt.Errorf("failed to open blob %s: %w", digest, err)
+	}
+	defer in.Close()
+	return tarball.WriteFile(target, in, 0644)
+}
+
+// targetPath returns the path of the blob digest in the layout being written.
+func (w *layoutWriter) targetPath(digest string) (string, error) {
+	d, err := ocidigest.Parse(digest)
+	if err != nil {
+		return "", fmt.Errorf("invalid digest %q: %w", digest, err)
+	}
+	return filepath.Join(w.dir, "blobs", d.Algorithm().String(), d.Encoded()), nil
+}
+
+// writeStream writes r to path through a temporary file, so that path is not left half-written on failure.
+func writeStream(path string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-")
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -2,18 +2,39 @@ package provider
 
 import (
 	"context"
+	"fmt"
+	"net/url"
+	"strings"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/buildx"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/datasources/authtoken"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/datasources/contexthash"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/datasources/imageconfig"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/datasources/manifest"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/datasources/platforms"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/datasources/repository"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/datasources/resolvedref"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/datasources/tags"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/functions"
 	"github.com/ikedam/terraform-provider-containerregistry/internal/providerconfig"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/resources/artifact"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/resources/attestation"
 	"github.com/ikedam/terraform-provider-containerregistry/internal/resources/compose"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/resources/helmchart"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/resources/prune"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/resources/signature"
 )
 
 // Ensure the implementation satisfies the provider.Provider interface.
 var _ provider.Provider = &ContainerRegistryProvider{}
+var _ provider.ProviderWithFunctions = &ContainerRegistryProvider{}
 
 // ContainerRegistryProvider defines the provider implementation.
 type ContainerRegistryProvider struct {
@@ -25,9 +46,18 @@ type ContainerRegistryProvider struct {
 
 // ContainerRegistryProviderModel describes the provider data model.
 type ContainerRegistryProviderModel struct {
-	BuildxInstallIfMissing types.Bool   `tfsdk:"buildx_install_if_missing"`
-	BuildxVersion          types.String `tfsdk:"buildx_version"`
-	RegistryAuth           types.Map    `tfsdk:"registry_auth"`
+	BuildxInstallIfMissing types.Bool             `tfsdk:"buildx_install_if_missing"`
+	BuildxVersion          types.String           `tfsdk:"buildx_version"`
+	RegistryAuth           types.Map              `tfsdk:"registry_auth"`
+	ApplySummaryFile       types.String           `tfsdk:"apply_summary_file"`
+	DockerHost             types.String           `tfsdk:"docker_host"`
+	DockerCertPath         types.String           `tfsdk:"docker_cert_path"`
+	DockerContext          types.String           `tfsdk:"docker_context"`
+	CheckDockerDaemon      types.Bool             `tfsdk:"check_docker_daemon"`
+	RemoteBuilder          *RemoteBuilderModel    `tfsdk:"remote_builder"`
+	CacheStorageAuth       *CacheStorageAuthModel `tfsdk:"cache_storage_auth"`
+	MaxParallelBuilds      types.Int64            `tfsdk:"max_parallel_builds"`
+	PushRetries            types.Int64            `tfsdk:"push_retries"`
 }
 
 type RegistryAuthEntryModel struct {
@@ -35,6 +65,31 @@ type RegistryAuthEntryModel struct {
 	Password types.String `tfsdk:"password"`
 }
 
+type RemoteBuilderModel struct {
+	Name          types.String            `tfsdk:"name"`
+	Endpoint      types.String            `tfsdk:"endpoint"`
+	TLSCACert     types.String            `tfsdk:"tls_ca_cert"`
+	TLSCert       types.String            `tfsdk:"tls_cert"`
+	TLSKey        types.String            `tfsdk:"tls_key"`
+	TLSServerName types.String            `tfsdk:"tls_server_name"`
+	Env           map[string]types.String `tfsdk:"env"`
+}
+
+type CacheStorageAuthModel struct {
+	AWSAccessKeyID     types.String `tfsdk:"aws_access_key_id"`
+	AWSSecretAccessKey types.String `tfsdk:"aws_secret_access_key"`
+	AWSSessionToken    types.String `tfsdk:"aws_session_token"`
+	AWSRegion          types.String `tfsdk:"aws_region"`
+	GCSHMACAccessID    types.String `tfsdk:"gcs_hmac_access_id"`
+	GCSHMACSecret      types.String `tfsdk:"gcs_hmac_secret"`
+}
+
+// defaultPushRetries is the number of times a failed push is retried when push_retries is omitted.
+const defaultPushRetries = 2
+
+// defaultRemoteBuilderName is the buildx builder instance name used when remote_builder.name is omitted.
+const defaultRemoteBuilderName = "containerregistry-remote"
+
 // New returns a function that initializes a provider.Provider.
 func New(version string) func() provider.Provider {
 	return func() provider.Provider {
@@ -83,6 +138,124 @@ func (p *ContainerRegistryProvider) Schema(ctx context.Context, req provider.Sch
 					},
 				},
 			},
+			"apply_summary_file": schema.StringAttribute{
+				MarkdownDescription: "Path of a file to which a JSON line is appended for each image built and pushed during apply " +
+					"(image URI, digest, durations, pushed bytes and layer cache statistics). Omit to disable.",
+				Optional: true,
+			},
+			"max_parallel_builds": schema.Int64Attribute{
+				MarkdownDescription: "Maximum number of images built and pushed at the same time by the resources of this provider configuration, " +
+					"independently of `terraform apply -parallelism`, so that many image resources do not overload the Docker daemon. " +
+					"Omit for no limit.",
+				Optional: true,
+			},
+			"push_retries": schema.Int64Attribute{
+				MarkdownDescription: "Number of times a push failing with a transient error (e.g. a connection reset, a 502 from the registry " +
+					"or a registry token expiring during the push) is retried, with backoff. Each retry authenticates again, " +
+					"and layers already in the registry are not uploaded again, so the push resumes instead of the whole apply failing after a long build. " +
+					"Set 0 to disable retries. Default is 2.",
+				Optional: true,
+			},
+			"docker_host": schema.StringAttribute{
+				MarkdownDescription: "Address of the Docker daemon used to build and push images (`tcp://`, `ssh://` or `unix://`). " +
+					"Omit to use `DOCKER_HOST` and the current Docker context.",
+				Optional: true,
+			},
+			"docker_cert_path": schema.StringAttribute{
+				MarkdownDescription: "Directory holding `ca.pem`, `cert.pem` and `key.pem` to connect to a `tcp://` docker_host with TLS, " +
+					"as `DOCKER_CERT_PATH` does. Omit to connect without TLS.",
+				Optional: true,
+			},
+			"docker_context": schema.StringAttribute{
+				MarkdownDescription: "Name of the Docker CLI context (as `docker --context`) whose daemon is used to build and push images, " +
+					"read from the context store of the user (`docker context ls`). Conflicts with docker_host.",
+				Optional: true,
+			},
+			"check_docker_daemon": schema.BoolAttribute{
+				MarkdownDescription: "When true, the Docker daemon is probed the first time a resource plans a build using it, " +
+					"so that planning the build fails with the address tried and how to fix it, rather than the apply failing deep in the build. " +
+					"Set false when the daemon is only started during the apply. Default is true.",
+				Optional: true,
+			},
+			"remote_builder": schema.SingleNestedAttribute{
+				MarkdownDescription: "Delegate image builds to a remote BuildKit daemon. " +
+					"The provider registers it as a buildx builder and passes it to Compose builds, so the buildx plugin is required. " +
+					"Built images are loaded back into the local Docker daemon and pushed from there.",
+				Optional: true,
+				Attributes: map[string]schema.Attribute{
+					"name": schema.StringAttribute{
+						MarkdownDescription: "Name of the buildx builder instance. Default is `" + defaultRemoteBuilderName + "`. " +
+							"Required when endpoint is omitted.",
+						Optional: true,
+					},
+					"endpoint": schema.StringAttribute{
+						MarkdownDescription: "Builder endpoint. `tcp://` and `unix://` connect to a BuildKit daemon (buildx remote driver); " +
+							"`ssh://` runs BuildKit in a container on the Docker engine reachable over SSH (buildx docker-container driver). " +
+							"Omit to use the existing buildx builder `name` as is, such as a builder registered by the CLI of a hosted build service " +
+							"(e.g. `depot configure-docker`), which authenticates with the token in its environment variables (see `env`).",
+						Optional: true,
+					},
+					"tls_ca_cert": schema.StringAttribute{
+						MarkdownDescription: "Path to the CA certificate (PEM) used to verify a `tcp://` builder.",
+						Optional:            true,
+					},
+					"tls_cert": schema.StringAttribute{
+						MarkdownDescription: "Path to the client certificate (PEM) for a `tcp://` builder.",
+						Optional:            true,
+					},
+					"tls_key": schema.StringAttribute{
+						MarkdownDescription: "Path to the client key (PEM) for a `tcp://` builder.",
+						Optional:            true,
+					},
+					"tls_server_name": schema.StringAttribute{
+						MarkdownDescription: "Server name used to verify the builder certificate, when it differs from the endpoint host.",
+						Optional:            true,
+					},
+					"env": schema.MapAttribute{
+						MarkdownDescription: "Environment variables for buildx when it configures and uses the builder, " +
+							"such as the token of a hosted build service (e.g. `DEPOT_TOKEN`). " +
+							"They are set in the environment of the provider process, which the builds run buildx from.",
+						ElementType: types.StringType,
+						Optional:    true,
+						Sensitive:   true,
+					},
+				},
+			},
+			"cache_storage_auth": schema.SingleNestedAttribute{
+				MarkdownDescription: "Credentials for the `type=s3` and `type=gcs` build cache backends in `cache_from` / `cache_to`. " +
+					"Credentials written in a cache entry take precedence.",
+				Optional: true,
+				Attributes: map[string]schema.Attribute{
+					"aws_access_key_id": schema.StringAttribute{
+						MarkdownDescription: "AWS access key ID for `type=s3` caches. Omit to use the AWS credential chain of the environment (e.g. `AWS_PROFILE`).",
+						Optional:            true,
+					},
+					"aws_secret_access_key": schema.StringAttribute{
+						MarkdownDescription: "AWS secret access key for `type=s3` caches.",
+						Optional:            true,
+						Sensitive:           true,
+					},
+					"aws_session_token": schema.StringAttribute{
+						MarkdownDescription: "AWS session token for `type=s3` caches, when using temporary credentials.",
+						Optional:            true,
+						Sensitive:           true,
+					},
+					"aws_region": schema.StringAttribute{
+						MarkdownDescription: "Region of `type=s3` cache buckets, used when the cache entry has no `region`. " +
+							"Defaults to `AWS_REGION` or `AWS_DEFAULT_REGION`.",
+						Optional: true,
+					},
+					"gcs_hmac_access_id": schema.StringAttribute{
+						MarkdownDescription: "Access ID of the Cloud Storage HMAC key for `type=gcs` caches.",
+						Optional:            true,
+					},
+					"gcs_hmac_secret": schema.StringAttribute{
+						MarkdownDescription: "Secret of the Cloud Storage HMAC key for `type=gcs` caches.",
+						Optional:            true,
+						Sensitive:           true,
+					},
+				},
+			},
 		},
 	}
 }
@@ -129,23 +302,237 @@ func (p *ContainerRegistryProvider) Configure(ctx context.Context, req provider.
 		}
 	}
 
-	resp.ResourceData = &providerconfig.Config{
+	applySummaryFile := ""
+	if !data.ApplySummaryFile.IsNull() {
+		applySummaryFile = data.ApplySummaryFile.ValueString()
+	}
+
+	var buildSlots chan struct{}
+	if !data.MaxParallelBuilds.IsNull() && !data.MaxParallelBuilds.IsUnknown() {
+		maxParallelBuilds := data.MaxParallelBuilds.ValueInt64()
+		if maxParallelBuilds < 1 {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("max_parallel_builds"),
+				"Invalid max_parallel_builds",
+				fmt.Sprintf("max_parallel_builds must be at least 1, got %d.", maxParallelBuilds),
+			)
+			return
+		}
+		buildSlots = make(chan struct{}, maxParallelBuilds)
+	}
+
+	pushRetries := defaultPushRetries
+	if !data.PushRetries.IsNull() && !data.PushRetries.IsUnknown() {
+		if data.PushRetries.ValueInt64() < 0 {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("push_retries"),
+				"Invalid push_retries",
+				fmt.Sprintf("push_retries must not be negative, got %d.", data.PushRetries.ValueInt64()),
+			)
+			return
+		}
+		pushRetries = int(data.PushRetries.ValueInt64())
+	}
+
+	dockerHost := data.DockerHost.ValueString()
+	dockerCertPath := data.DockerCertPath.ValueString()
+	dockerContext := data.DockerContext.ValueString()
+	if dockerContext != "" && dockerHost != "" {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("docker_context"),
+			"Conflicting Docker daemon configuration",
+			"docker_context and docker_host cannot be set together.",
+		)
+		return
+	}
+	if dockerHost != "" {
+		u, err := url.Parse(dockerHost)
+		if err != nil || (u.Scheme != "tcp" && u.Scheme != "ssh" && u.Scheme != "unix") {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("docker_host"),
+				"Invalid docker_host",
+				fmt.Sprintf("docker_host must be a tcp://, ssh:// or unix:// address, got %q.", dockerHost),
+			)
+			return
+		}
+		if dockerCertPath != "" && u.Scheme != "tcp" {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("docker_cert_path"),
+				"Invalid docker_cert_path",
+				"docker_cert_path applies only to a tcp:// docker_host.",
+			)
+			return
+		}
+	} else if dockerCertPath != "" {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("docker_cert_path"),
+			"Invalid docker_cert_path",
+			"docker_cert_path requires docker_host.",
+		)
+		return
+	}
+
+	var remoteBuilder *providerconfig.RemoteBuilder
+	if data.RemoteBuilder != nil && (data.RemoteBuilder.Endpoint.IsUnknown() || remoteBuilderEnvUnknown(data.RemoteBuilder.Env)) {
+		// Keep the block so that builds do not silently fall back to the local daemon
+		remoteBuilder = &providerconfig.RemoteBuilder{
+			Name:       data.RemoteBuilder.Name.ValueString(),
+			Unresolved: true,
+		}
+	} else if data.RemoteBuilder != nil {
+		remoteBuilder = &providerconfig.RemoteBuilder{
+			Name:       data.RemoteBuilder.Name.ValueString(),
+			Endpoint:   data.RemoteBuilder.Endpoint.ValueString(),
+			CACert:     data.RemoteBuilder.TLSCACert.ValueString(),
+			Cert:       data.RemoteBuilder.TLSCert.ValueString(),
+			Key:        data.RemoteBuilder.TLSKey.ValueString(),
+			ServerName: data.RemoteBuilder.TLSServerName.ValueString(),
+		}
+		if len(data.RemoteBuilder.Env) > 0 {
+			remoteBuilder.Env = make(map[string]string, len(data.RemoteBuilder.Env))
+			for name, value := range data.RemoteBuilder.Env {
+				if name == "" || strings.Contains(name, "=") {
+					resp.Diagnostics.AddAttributeError(
+						path.Root("remote_builder").AtName("env"),
+						"Invalid remote_builder env",
+						fmt.Sprintf("%q is not a valid environment variable name.", name),
+					)
+					return
+				}
+				remoteBuilder.Env[name] = value.ValueString()
+			}
+		}
+		if remoteBuilder.Endpoint == "" {
+			if remoteBuilder.Name == "" {
+				resp.Diagnostics.AddAttributeError(
+					path.Root("remote_builder").AtName("name"),
+					"Missing remote_builder name",
+					"name is required to use an existing builder when endpoint is omitted.",
+				)
+				return
+			}
+			if remoteBuilder.CACert != "" || remoteBuilder.Cert != "" || remoteBuilder.Key != "" || remoteBuilder.ServerName != "" {
+				resp.Diagnostics.AddAttributeError(
+					path.Root("remote_builder"),
+					"Invalid remote_builder TLS configuration",
+					"TLS settings require endpoint: an existing builder is used with its own configuration.",
+				)
+				return
+			}
+		} else if err := buildx.ValidateRemoteBuilderEndpoint(remoteBuilder.Endpoint); err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("remote_builder").AtName("endpoint"),
+				"Invalid remote_builder endpoint",
+				err.Error(),
+			)
+			return
+		}
+		if remoteBuilder.Name == "" {
+			remoteBuilder.Name = defaultRemoteBuilderName
+		}
+		if (remoteBuilder.Cert == "") != (remoteBuilder.Key == "") {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("remote_builder"),
+				"Invalid remote_builder TLS configuration",
+				"tls_cert and tls_key must be set together.",
+			)
+			return
+		}
+	}
+
+	var cacheStorageAuth *providerconfig.CacheStorageAuth
+	if data.CacheStorageAuth != nil {
+		cacheStorageAuth = &providerconfig.CacheStorageAuth{
+			AWSAccessKeyID:     data.CacheStorageAuth.AWSAccessKeyID.ValueString(),
+			AWSSecretAccessKey: data.CacheStorageAuth.AWSSecretAccessKey.ValueString(),
+			AWSSessionToken:    data.CacheStorageAuth.AWSSessionToken.ValueString(),
+			AWSRegion:          data.CacheStorageAuth.AWSRegion.ValueString(),
+			GCSHMACAccessID:    data.CacheStorageAuth.GCSHMACAccessID.ValueString(),
+			GCSHMACSecret:      data.CacheStorageAuth.GCSHMACSecret.ValueString(),
+		}
+		if (cacheStorageAuth.AWSAccessKeyID == "") != (cacheStorageAuth.AWSSecretAccessKey == "") {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("cache_storage_auth"),
+				"Invalid cache_storage_auth configuration",
+				"aws_access_key_id and aws_secret_access_key must be set together.",
+			)
+			return
+		}
+		if (cacheStorageAuth.GCSHMACAccessID == "") != (cacheStorageAuth.GCSHMACSecret == "") {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("cache_storage_auth"),
+				"Invalid cache_storage_auth configuration",
+				"gcs_hmac_access_id and gcs_hmac_secret must be set together.",
+			)
+			return
+		}
+	}
+
+	config := &providerconfig.Config{
 		BuildxInstallIfMissing: installIfMissing,
 		BuildxVersion:          version,
 		RegistryAuth:           registryAuth,
+		ApplySummaryFile:       applySummaryFile,
+		DockerHost:             dockerHost,
+		DockerCertPath:         dockerCertPath,
+		DockerContext:          dockerContext,
+		RemoteBuilder:          remoteBuilder,
+		CacheStorageAuth:       cacheStorageAuth,
+		BuildSlots:             buildSlots,
+		PushRetries:            pushRetries,
 	}
+	// The daemon is probed by the first resource planning a build, and not while its address is not known yet
+	dockerKnown := !data.DockerHost.IsUnknown() && !data.DockerCertPath.IsUnknown() && !data.DockerContext.IsUnknown()
+	if dockerKnown && (data.CheckDockerDaemon.IsNull() || data.CheckDockerDaemon.ValueBool()) {
+		config.DockerDaemonCheck = &providerconfig.DockerDaemonCheck{}
+	}
+	resp.ResourceData = config
+	resp.DataSourceData = config
 }
 
 // Resources defines the resources implemented in the provider.
 func (p *ContainerRegistryProvider) Resources(ctx context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		compose.NewComposeResource,
+		compose.NewDockerfileImageResource,
+		compose.NewComposeProjectResource,
+		artifact.NewArtifactResource,
+		helmchart.NewHelmChartResource,
+		signature.NewSignatureResource,
+		attestation.NewAttestationResource,
+		prune.NewPruneResource,
 	}
 }
 
 // DataSources defines the data sources implemented in the provider.
 func (p *ContainerRegistryProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
 	return []func() datasource.DataSource{
-		// もしデータソースがあれば登録します
+		tags.NewTagsDataSource,
+		manifest.NewManifestDataSource,
+		authtoken.NewAuthTokenDataSource,
+		repository.NewRepositoryDataSource,
+		imageconfig.NewImageConfigDataSource,
+		platforms.NewPlatformsDataSource,
+		contexthash.NewContextHashDataSource,
+		resolvedref.NewResolvedRefDataSource,
+	}
+}
+
+// Functions defines the provider-defined functions implemented in the provider.
+func (p *ContainerRegistryProvider) Functions(ctx context.Context) []func() function.Function {
+	return []func() function.Function{
+		functions.NewParseImageRefFunction,
+		functions.NewWithDigestFunction,
+		functions.NewNormalizeImageURIFunction,
+	}
+}
+
+// remoteBuilderEnvUnknown reports whether a value of remote_builder.env is not known yet.
+func remoteBuilderEnvUnknown(env map[string]types.String) bool {
+	for _, value := range env {
+		if value.IsUnknown() {
+			return true
+		}
 	}
+	return false
 }
@@ -1,5 +1,7 @@
 package providerconfig
 
+import "sync"
+
 // Config holds provider-level configuration passed to resources via ConfigureResponse.ResourceData.
 type Config struct {
 	// BuildxInstallIfMissing when true, installs the buildx plugin when not found.
@@ -9,6 +11,30 @@ type Config struct {
 	// RegistryAuth maps registry hostname (e.g. asia-northeast1-docker.pkg.dev) to credentials.
 	// Used by resources when pushing/pulling or calling the Registry HTTP API for that host.
 	RegistryAuth map[string]RegistryAuthCredentials
+	// ApplySummaryFile is the path of a file to which resources append a JSON line
+	// describing each published image. Empty means disabled.
+	ApplySummaryFile string
+	// DockerHost is the Docker daemon address (tcp://, ssh:// or unix://) used to build and push images.
+	// Empty means DOCKER_HOST and the current Docker context.
+	DockerHost string
+	// DockerContext is the name of the Docker CLI context whose endpoint is used to build and push images.
+	// It is exclusive with DockerHost. Empty means DOCKER_HOST and the current Docker context.
+	DockerContext string
+	// DockerCertPath is the directory holding ca.pem, cert.pem and key.pem for TLS with a tcp:// DockerHost.
+	// Empty means no TLS.
+	DockerCertPath string
+	// DockerDaemonCheck probes the Docker daemon once, the first time a resource plans a build using it.
+	// Nil disables the probe.
+	DockerDaemonCheck *DockerDaemonCheck
+	// RemoteBuilder, when non-nil, delegates builds to a remote BuildKit daemon through a buildx builder.
+	RemoteBuilder *RemoteBuilder
+	// CacheStorageAuth holds credentials for the s3 and gcs build cache backends. Nil means none are configured.
+	CacheStorageAuth *CacheStorageAuth
+	// BuildSlots limits the number of images built and pushed at the same time: each build holds a slot
+	// (an element in the channel) until its push completes. Nil means unlimited.
+	BuildSlots chan struct{}
+	// PushRetries is the number of times a failed push is retried.
+	PushRetries int
 }
 
 // RegistryAuthCredentials is username/password for a single registry host.
@@ -16,3 +42,52 @@ type RegistryAuthCredentials struct {
 	Username string
 	Password string
 }
+
+// RemoteBuilder describes a buildx builder backed by a BuildKit daemon outside the local Docker engine.
+type RemoteBuilder struct {
+	// Name is the buildx builder instance name.
+	Name string
+	// Endpoint is the builder address (tcp://, unix:// or ssh://).
+	// Empty means the existing builder Name is used as is, e.g. one registered by a hosted build service.
+	Endpoint string
+	// CACert, Cert and Key are paths to the PEM files used for TLS with tcp:// endpoints.
+	CACert string
+	Cert   string
+	Key    string
+	// ServerName overrides the server name used to verify the builder certificate.
+	ServerName string
+	// Env holds environment variables for buildx, e.g. the token of a hosted build service.
+	Env map[string]string
+	// Unresolved is set when the endpoint or env was not known when the provider was configured,
+	// e.g. when it refers to a resource not created yet. Builds fail rather than run on the local daemon.
+	Unresolved bool
+}
+
+// CacheStorageAuth is the credentials used by the s3 and gcs build cache backends.
+type CacheStorageAuth struct {
+	// AWSAccessKeyID, AWSSecretAccessKey and AWSSessionToken are used for type=s3 caches.
+	// When empty, buildx falls back to the AWS credential chain of its environment.
+	AWSAccessKeyID     string
+	AWSSecretAccessKey string
+	AWSSessionToken    string
+	// AWSRegion is the region of type=s3 cache buckets, used when the cache entry has no region.
+	AWSRegion string
+	// GCSHMACAccessID and GCSHMACSecret are the HMAC key used for type=gcs caches through the
+	// Cloud Storage XML API.
+	GCSHMACAccessID string
+	GCSHMACSecret   string
+}
+
+// DockerDaemonCheck is the result of probing the Docker daemon, shared by the resources of a provider configuration.
+type DockerDaemonCheck struct {
+	once sync.Once
+	err  error
+}
+
+// Do runs probe the first time it is called and returns the error of that probe, nil when the daemon answered.
+func (c *DockerDaemonCheck) Do(probe func() error) error {
+	c.once.Do(func() {
+		c.err = probe()
+	})
+	return c.err
+}
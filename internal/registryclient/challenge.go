@@ -0,0 +1,131 @@
+package registryclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Challenge is a parsed WWW-Authenticate header of a registry response.
+type Challenge struct {
+	// Scheme is the authentication scheme in lower case (e.g. "basic", "bearer").
+	Scheme string
+	// Params holds the challenge parameters (e.g. realm, service, scope).
+	Params map[string]string
+}
+
+// ParseChallenge parses a WWW-Authenticate header value such as
+// `Bearer realm="https://auth.docker.io/token",service="registry.docker.io"`.
+// It returns nil when the header is empty.
+func ParseChallenge(header string) *Challenge {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return nil
+	}
+	scheme, rest, _ := strings.Cut(header, " ")
+	challenge := &Challenge{
+		Scheme: strings.ToLower(scheme),
+		Params: map[string]string{},
+	}
+	for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimSpace(rest) {
+		key, after, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		var value string
+		if strings.HasPrefix(after, `"`) {
+			end := strings.Index(after[1:], `"`)
+			if end < 0 {
+				value, rest = after[1:], ""
+			} else {
+				value, rest = after[1:end+1], after[end+2:]
+			}
+		} else {
+			value, rest, _ = strings.Cut(after, ",")
+		}
+		challenge.Params[key] = strings.TrimSpace(value)
+		rest = strings.TrimPrefix(strings.TrimSpace(rest), ",")
+	}
+	return challenge
+}
+
+// Token is a bearer token issued by a registry token service.
+type Token struct {
+	Token string
+	// ExpiresAt is when the token expires. Zero when the token service did not report it.
+	ExpiresAt time.Time
+}
+
+// FetchToken performs the Docker Registry token authentication flow for a Bearer challenge:
+// it requests a token from the challenge realm, sending authHeader (Basic credentials) when not empty.
+// scope overrides the scope in the challenge when not empty.
+func FetchToken(ctx context.Context, client *http.Client, challenge *Challenge, scope, authHeader string) (*Token, error) {
+	if challenge == nil || challenge.Scheme != "bearer" {
+		return nil, fmt.Errorf("registry did not request bearer token authentication")
+	}
+	realm := challenge.Params["realm"]
+	if realm == "" {
+		return nil, fmt.Errorf("bearer challenge has no realm")
+	}
+	tokenURL, err := url.Parse(realm)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token realm %q: %w", realm, err)
+	}
+	query := tokenURL.Query()
+	if service := challenge.Params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	if scope == "" {
+		scope = challenge.Params["scope"]
+	}
+	if scope != "" {
+		query.Set("scope", scope)
+	}
+	tokenURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+	if authHeader != "" {
+		req.Header.Add("Authorization", authHeader)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request token: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := CheckResponse(resp, "request token"); err != nil {
+		return nil, err
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+		IssuedAt    string `json:"issued_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	token := &Token{Token: body.Token}
+	if token.Token == "" {
+		token.Token = body.AccessToken
+	}
+	if token.Token == "" {
+		return nil, fmt.Errorf("token response contains no token")
+	}
+	if body.ExpiresIn > 0 {
+		issuedAt := time.Now()
+		if t, err := time.Parse(time.RFC3339, body.IssuedAt); err == nil {
+			issuedAt = t
+		}
+		token.ExpiresAt = issuedAt.Add(time.Duration(body.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
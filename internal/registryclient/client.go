@@ -0,0 +1,136 @@
+// Package registryclient provides the HTTP client shared by resources and data sources
+// to call the Docker Registry HTTP API v2.
+package registryclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/logging"
+)
+
+const (
+	// defaultMaxRetries is the number of retries for rate-limited requests.
+	defaultMaxRetries = 5
+	// initialBackoff is the wait before the first retry when Retry-After is not provided.
+	initialBackoff = 1 * time.Second
+	// maxBackoff caps the wait between retries, including the one requested by Retry-After.
+	maxBackoff = 60 * time.Second
+	// maxRedirects is the number of redirects to follow, the same as the net/http default.
+	maxRedirects = 10
+)
+
+// NewHTTPClient returns an *http.Client for Registry API requests.
+// Requests are logged via tflog (see logging.InjectLoggingToTransport) and retried
+// when the registry responds with 429 Too Many Requests, honoring Retry-After.
+// The Authorization header is not forwarded when a redirect leaves the registry host.
+func NewHTTPClient() *http.Client {
+	return &http.Client{
+		Transport: &retryTransport{
+			base:       logging.InjectLoggingToTransport(http.DefaultTransport),
+			maxRetries: defaultMaxRetries,
+		},
+		CheckRedirect: checkRedirect,
+	}
+}
+
+// checkRedirect drops the Authorization header when a redirect points to another host.
+// Blob downloads from ECR and Artifact Registry are redirected to pre-signed S3/GCS URLs:
+// those reject requests carrying registry credentials, and the credentials must not leak there.
+// net/http only strips the header for hosts outside the original domain, so it is not enough
+// (e.g. it keeps the header for a redirect from example.com to storage.example.com).
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	if req.URL.Host != via[0].URL.Host {
+		req.Header.Del("Authorization")
+	}
+	return nil
+}
+
+// retryTransport retries requests rejected with 429 Too Many Requests.
+// Docker Hub and GHCR rate-limit manifest reads, and a single 429 should not fail the apply.
+type retryTransport struct {
+	base       http.RoundTripper
+	maxRetries int
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests || attempt >= t.maxRetries {
+			return resp, err
+		}
+		// Requests with a body can be retried only when the body can be re-created.
+		if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+			return resp, nil
+		}
+
+		wait := retryAfter(resp.Header.Get("Retry-After"), time.Now())
+		if wait <= 0 {
+			wait = initialBackoff << attempt
+		}
+		if wait > maxBackoff {
+			wait = maxBackoff
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+
+		tflog.Warn(ctx, "Registry rate limit exceeded, retrying", map[string]interface{}{
+			"url":     req.URL.String(),
+			"attempt": attempt + 1,
+			"wait":    wait.String(),
+		})
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil, err
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+	}
+}
+
+// retryAfter parses the Retry-After header value, which is either a number of seconds
+// or an HTTP date. It returns 0 when the value is absent or invalid.
+func retryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,25 @@
+package registryclient
+
+import "regexp"
+
+// ecrHostPattern matches AWS ECR private registry hosts: <account>.dkr.ecr[-fips].<region>.amazonaws.com[.cn]
+var ecrHostPattern = regexp.MustCompile(`^(\d{12})\.dkr\.ecr(?:-fips)?\.([a-z0-9-]+)\.amazonaws\.com(\.cn)?$`)
+
+// ECRRegistry is an AWS ECR private registry, identified from its host.
+type ECRRegistry struct {
+	// Account is the AWS account ID owning the registry.
+	Account string
+	// Region is the AWS region of the registry.
+	Region string
+	// China is set for registries in the AWS China partition.
+	China bool
+}
+
+// ParseECRHost returns the ECR registry of host, and false when host is not an ECR private registry.
+func ParseECRHost(host string) (ECRRegistry, bool) {
+	m := ecrHostPattern.FindStringSubmatch(host)
+	if m == nil {
+		return ECRRegistry{}, false
+	}
+	return ECRRegistry{Account: m[1], Region: m[2], China: m[3] != ""}, true
+}
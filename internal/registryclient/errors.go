@@ -0,0 +1,117 @@
+package registryclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxErrorBodySize limits how much of an error response body is read.
+const maxErrorBodySize = 64 * 1024
+
+// ErrorDetail is an entry of the "errors" array in a Registry API error response,
+// e.g. {"code":"DENIED","message":"requested access to the resource is denied"}.
+type ErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Detail  any    `json:"detail,omitempty"`
+}
+
+// ResponseError is returned for unsuccessful Registry API responses.
+type ResponseError struct {
+	// Operation describes the failed request (e.g. "get manifest").
+	Operation string
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Errors holds the errors decoded from the response body, if any.
+	Errors []ErrorDetail
+}
+
+// Error implements error.
+func (e *ResponseError) Error() string {
+	msg := fmt.Sprintf("failed to %s, status: %d", e.Operation, e.StatusCode)
+	if len(e.Errors) == 0 {
+		return msg
+	}
+	details := make([]string, 0, len(e.Errors))
+	for _, d := range e.Errors {
+		detail := d.Code
+		if d.Message != "" {
+			detail += ": " + d.Message
+		}
+		if d.Detail != nil {
+			if b, err := json.Marshal(d.Detail); err == nil && string(b) != "null" && string(b) != "{}" {
+				detail += " (" + string(b) + ")"
+			}
+		}
+		details = append(details, detail)
+	}
+	return msg + ": " + strings.Join(details, "; ")
+}
+
+// HasCode reports whether the registry returned the given error code (e.g. "MANIFEST_UNKNOWN").
+func (e *ResponseError) HasCode(code string) bool {
+	for _, d := range e.Errors {
+		if d.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckResponse returns nil for 2xx responses. Otherwise it returns a *ResponseError
+// with the structured errors decoded from the response body.
+// The body is consumed but not closed.
+func CheckResponse(resp *http.Response, operation string) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	respErr := &ResponseError{
+		Operation:  operation,
+		StatusCode: resp.StatusCode,
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if err == nil && len(body) > 0 {
+		var payload struct {
+			Errors []ErrorDetail `json:"errors"`
+		}
+		if json.Unmarshal(body, &payload) == nil {
+			respErr.Errors = payload.Errors
+		}
+	}
+	return respErr
+}
+
+// AsResponseError returns the *ResponseError in err's chain, if any.
+func AsResponseError(err error) (*ResponseError, bool) {
+	var respErr *ResponseError
+	if errors.As(err, &respErr) {
+		return respErr, true
+	}
+	return nil, false
+}
+
+// IsNotFound reports whether err is a registry response telling that the repository
+// or manifest does not exist (404, NAME_UNKNOWN or MANIFEST_UNKNOWN).
+func IsNotFound(err error) bool {
+	respErr, ok := AsResponseError(err)
+	if !ok {
+		return false
+	}
+	return respErr.StatusCode == http.StatusNotFound ||
+		respErr.HasCode("NAME_UNKNOWN") ||
+		respErr.HasCode("MANIFEST_UNKNOWN")
+}
+
+// IsUnsupported reports whether err is a registry response telling that the operation
+// is not supported (405 Method Not Allowed or UNSUPPORTED), e.g. when manifest deletion is disabled.
+func IsUnsupported(err error) bool {
+	respErr, ok := AsResponseError(err)
+	if !ok {
+		return false
+	}
+	return respErr.StatusCode == http.StatusMethodNotAllowed || respErr.HasCode("UNSUPPORTED")
+}
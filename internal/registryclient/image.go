@@ -0,0 +1,264 @@
+package registryclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// attestationReferenceType is the annotation value BuildKit sets on attestation manifests in an image index.
+const attestationReferenceType = "attestation-manifest"
+
+// Platform is the platform of an image manifest in an image index.
+type Platform struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// String returns the platform in os/arch[/variant] form.
+func (p Platform) String() string {
+	s := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		s += "/" + p.Variant
+	}
+	return s
+}
+
+// ParsePlatform parses a platform in os/arch[/variant] form (e.g. "linux/arm64/v8").
+func ParsePlatform(s string) (Platform, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return Platform{}, fmt.Errorf("invalid platform %q: expected os/arch[/variant]", s)
+	}
+	p := Platform{OS: parts[0], Architecture: parts[1]}
+	if len(parts) == 3 {
+		p.Variant = parts[2]
+	}
+	return p, nil
+}
+
+// Descriptor describes content referenced from a manifest or an image index.
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Platform    *Platform         `json:"platform,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// IsAttestation reports whether d is an attestation manifest (provenance, SBOM) in an image index.
+func (d Descriptor) IsAttestation() bool {
+	return d.Annotations["vnd.docker.reference.type"] == attestationReferenceType
+}
+
+// ManifestContent is the decoded content of an image manifest or image index.
+type ManifestContent struct {
+	MediaType string       `json:"mediaType"`
+	Config    Descriptor   `json:"config"`
+	Layers    []Descriptor `json:"layers"`
+	// Manifests is set for image indexes (multi-platform images).
+	Manifests []Descriptor `json:"manifests"`
+}
+
+// Content decodes the manifest body.
+func (m *Manifest) Content() (*ManifestContent, error) {
+	var content ManifestContent
+	if err := json.Unmarshal(m.Body, &content); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest: %w", err)
+	}
+	return &content, nil
+}
+
+// IsIndex reports whether the manifest is an image index (manifest list).
+func (m *Manifest) IsIndex() bool {
+	return m.MediaType == MediaTypeOCIIndex || m.MediaType == MediaTypeDockerManifestList
+}
+
+// ImageConfig is the image configuration blob of an image manifest.
+type ImageConfig struct {
+	// Created is when the image was created, in RFC 3339 format. Empty when not recorded.
+	Created      string `json:"created,omitempty"`
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant,omitempty"`
+	Config       struct {
+		User         string              `json:"User"`
+		ExposedPorts map[string]struct{} `json:"ExposedPorts"`
+		Env          []string            `json:"Env"`
+		Entrypoint   []string            `json:"Entrypoint"`
+		Cmd          []string            `json:"Cmd"`
+		WorkingDir   string              `json:"WorkingDir"`
+		Labels       map[string]string   `json:"Labels"`
+	} `json:"config"`
+}
+
+// GetBlob fetches the blob identified by digest from repository.
+func (c *Client) GetBlob(ctx context.Context, repository, digest string) ([]byte, error) {
+	resp, err := c.Do(ctx, http.MethodGet, c.URL(fmt.Sprintf("/v2/%s/blobs/%s", repository, digest)), nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blob: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := CheckResponse(resp, "get blob"); err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob %s: %w", digest, err)
+	}
+	return body, nil
+}
+
+// GetPlatformManifest fetches the manifest of repository for ref and, when it is an image index,
+// the image manifest for platform. An empty platform selects the first manifest that is not an attestation.
+func (c *Client) GetPlatformManifest(ctx context.Context, repository, ref, platform string) (*Manifest, error) {
+	manifest, err := c.GetManifest(ctx, repository, ref)
+	if err != nil {
+		return nil, err
+	}
+	if !manifest.IsIndex() {
+		return manifest, nil
+	}
+
+	var want *Platform
+	if platform != "" {
+		p, err := ParsePlatform(platform)
+		if err != nil {
+			return nil, err
+		}
+		want = &p
+	}
+	content, err := manifest.Content()
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range content.Manifests {
+		if d.IsAttestation() {
+			continue
+		}
+		if want != nil {
+			if d.Platform == nil || d.Platform.OS != want.OS || d.Platform.Architecture != want.Architecture {
+				continue
+			}
+			if want.Variant != "" && d.Platform.Variant != want.Variant {
+				continue
+			}
+		}
+		return c.GetManifest(ctx, repository, d.Digest)
+	}
+	if want != nil {
+		return nil, fmt.Errorf("image index %s has no manifest for platform %s", manifest.Digest, want)
+	}
+	return nil, fmt.Errorf("image index %s has no image manifest", manifest.Digest)
+}
+
+// GetImageConfig fetches the image configuration of repository for ref.
+// platform selects the image of a multi-platform image as for GetPlatformManifest.
+func (c *Client) GetImageConfig(ctx context.Context, repository, ref, platform string) (*ImageConfig, error) {
+	manifest, err := c.GetPlatformManifest(ctx, repository, ref, platform)
+	if err != nil {
+		return nil, err
+	}
+	content, err := manifest.Content()
+	if err != nil {
+		return nil, err
+	}
+	if content.Config.Digest == "" {
+		return nil, fmt.Errorf("manifest %s has no config", manifest.Digest)
+	}
+	body, err := c.GetBlob(ctx, repository, content.Config.Digest)
+	if err != nil {
+		return nil, err
+	}
+	var config ImageConfig
+	if err := json.Unmarshal(body, &config); err != nil {
+		return nil, fmt.Errorf("failed to decode image config: %w", err)
+	}
+	return &config, nil
+}
+
+// PlatformManifest is an image manifest for a single platform.
+type PlatformManifest struct {
+	Platform Platform
+	Digest   string
+}
+
+// ListPlatforms returns the platforms of the image of repository for ref, excluding attestation manifests.
+// For a single-platform image, the platform is read from the image configuration.
+func (c *Client) ListPlatforms(ctx context.Context, repository, ref string) (*Manifest, []PlatformManifest, error) {
+	manifest, err := c.GetManifest(ctx, repository, ref)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := manifest.Content()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !manifest.IsIndex() {
+		if content.Config.Digest == "" {
+			return nil, nil, fmt.Errorf("manifest %s has no config", manifest.Digest)
+		}
+		body, err := c.GetBlob(ctx, repository, content.Config.Digest)
+		if err != nil {
+			return nil, nil, err
+		}
+		var config ImageConfig
+		if err := json.Unmarshal(body, &config); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode image config: %w", err)
+		}
+		return manifest, []PlatformManifest{{
+			Platform: Platform{OS: config.OS, Architecture: config.Architecture, Variant: config.Variant},
+			Digest:   manifest.Digest,
+		}}, nil
+	}
+
+	platforms := make([]PlatformManifest, 0, len(content.Manifests))
+	for _, d := range content.Manifests {
+		if d.IsAttestation() || d.Platform == nil {
+			continue
+		}
+		platforms = append(platforms, PlatformManifest{Platform: *d.Platform, Digest: d.Digest})
+	}
+	return manifest, platforms, nil
+}
+
+// ListAttestations returns the digests of the attestation manifests (provenance, SBOM) of the image of repository
+// for ref, keyed by the platform of the image manifest they describe.
+// It returns an empty map for single-platform images, which cannot carry attestations.
+func (c *Client) ListAttestations(ctx context.Context, repository, ref string) (map[string]string, error) {
+	manifest, err := c.GetManifest(ctx, repository, ref)
+	if err != nil {
+		return nil, err
+	}
+	attestations := map[string]string{}
+	if !manifest.IsIndex() {
+		return attestations, nil
+	}
+	content, err := manifest.Content()
+	if err != nil {
+		return nil, err
+	}
+
+	platforms := map[string]string{}
+	for _, d := range content.Manifests {
+		if !d.IsAttestation() && d.Platform != nil {
+			platforms[d.Digest] = d.Platform.String()
+		}
+	}
+	for _, d := range content.Manifests {
+		if !d.IsAttestation() {
+			continue
+		}
+		platform, ok := platforms[d.Annotations["vnd.docker.reference.digest"]]
+		if !ok {
+			platform = "unknown"
+		}
+		attestations[platform] = d.Digest
+	}
+	return attestations, nil
+}
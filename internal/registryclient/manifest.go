@@ -0,0 +1,122 @@
+package registryclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/distribution/reference"
+	ocidigest "github.com/opencontainers/go-digest"
+)
+
+// Manifest media types accepted from registries.
+const (
+	MediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	MediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+)
+
+// manifestAcceptTypes lists the manifest media types requested from registries.
+var manifestAcceptTypes = []string{
+	MediaTypeDockerManifest,
+	MediaTypeDockerManifestList,
+	MediaTypeOCIManifest,
+	MediaTypeOCIIndex,
+}
+
+// Manifest is a manifest (or index) fetched from a registry.
+type Manifest struct {
+	// Body is the raw manifest as returned by the registry.
+	Body []byte
+	// MediaType is the media type of the manifest.
+	MediaType string
+	// Digest is the digest of the manifest, as used in repo@digest references.
+	Digest string
+}
+
+// ParseImageReference parses an image reference such as "ghcr.io/owner/app:v1", "nginx" or "app@sha256:..."
+// and returns the registry host, the repository path and the tag or digest.
+// Docker Hub short names are normalized and "latest" is used when neither tag nor digest is given.
+func ParseImageReference(imageRef string) (string, string, string, error) {
+	named, err := reference.ParseNormalizedNamed(imageRef)
+	if err != nil {
+		return "", "", "", fmt.Errorf("invalid image reference %q: %w", imageRef, err)
+	}
+	named = reference.TagNameOnly(named)
+	var ref string
+	if canonical, ok := named.(reference.Canonical); ok {
+		ref = canonical.Digest().String()
+	} else if tagged, ok := named.(reference.Tagged); ok {
+		ref = tagged.Tag()
+	}
+	return reference.Domain(named), reference.Path(named), ref, nil
+}
+
+// GetManifest fetches the manifest of repository for reference (a tag or digest).
+func (c *Client) GetManifest(ctx context.Context, repository, ref string) (*Manifest, error) {
+	header := http.Header{}
+	for _, mediaType := range manifestAcceptTypes {
+		header.Add("Accept", mediaType)
+	}
+	resp, err := c.Do(ctx, http.MethodGet, c.URL(fmt.Sprintf("/v2/%s/manifests/%s", repository, ref)), header, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get manifest: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := CheckResponse(resp, "get manifest"); err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest body: %w", err)
+	}
+
+	manifest := &Manifest{
+		Body:   body,
+		Digest: resp.Header.Get("Docker-Content-Digest"),
+	}
+	if manifest.Digest == "" {
+		manifest.Digest = ocidigest.FromBytes(body).String()
+	}
+	var content struct {
+		MediaType string `json:"mediaType"`
+	}
+	if err := json.Unmarshal(body, &content); err == nil && content.MediaType != "" {
+		manifest.MediaType = content.MediaType
+	} else if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil {
+		manifest.MediaType = mediaType
+	}
+	return manifest, nil
+}
+
+// ResolveDigest returns the digest of the manifest of repository for ref (a tag or digest).
+// It uses a HEAD request, which does not count against pull rate limits on Docker Hub,
+// and falls back to fetching the manifest when the registry does not report Docker-Content-Digest.
+func (c *Client) ResolveDigest(ctx context.Context, repository, ref string) (string, error) {
+	header := http.Header{}
+	for _, mediaType := range manifestAcceptTypes {
+		header.Add("Accept", mediaType)
+	}
+	resp, err := c.Do(ctx, http.MethodHead, c.URL(fmt.Sprintf("/v2/%s/manifests/%s", repository, ref)), header, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to head manifest: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := CheckResponse(resp, "head manifest"); err != nil {
+		return "", err
+	}
+	if digest := resp.Header.Get("Docker-Content-Digest"); digest != "" {
+		return digest, nil
+	}
+
+	manifest, err := c.GetManifest(ctx, repository, ref)
+	if err != nil {
+		return "", err
+	}
+	return manifest.Digest, nil
+}
@@ -0,0 +1,90 @@
+package registryclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// APIHost returns the host serving the Registry HTTP API for a registry hostname
+// as returned by reference.Domain. Docker Hub images use "docker.io",
+// but its API is served from registry-1.docker.io.
+func APIHost(host string) string {
+	if host == "docker.io" {
+		return "registry-1.docker.io"
+	}
+	return host
+}
+
+// Ping checks that the registry at host is reachable and accepts the credentials in authHeader
+// by calling the /v2/ endpoint. When the registry requests bearer token authentication,
+// the credentials are verified by requesting a token.
+// The returned error describes the problem in terms actionable by the user
+// (unreachable registry, TLS problem, rejected credentials).
+func Ping(ctx context.Context, client *http.Client, host, authHeader string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("https://%s/v2/", APIHost(host)), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create ping request: %w", err)
+	}
+	if authHeader != "" {
+		req.Header.Add("Authorization", authHeader)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return describeTransportError(host, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized:
+		challenge := ParseChallenge(resp.Header.Get("WWW-Authenticate"))
+		if challenge != nil && challenge.Scheme == "bearer" {
+			if _, err := FetchToken(ctx, client, challenge, "", authHeader); err != nil {
+				return authError(host, authHeader, err)
+			}
+			return nil
+		}
+		return authError(host, authHeader, errors.New("credentials were rejected"))
+	case http.StatusForbidden:
+		return fmt.Errorf("access to registry %s was denied: check the permissions of the configured registry_auth credentials", host)
+	case http.StatusNotFound:
+		return fmt.Errorf("%s does not serve the Docker Registry HTTP API v2 (GET /v2/ returned 404)", host)
+	default:
+		return fmt.Errorf("registry %s returned an unexpected response for GET /v2/: %w", host, CheckResponse(resp, "ping registry"))
+	}
+}
+
+// authError returns an authentication failure message that tells whether credentials were sent.
+func authError(host, authHeader string, cause error) error {
+	if authHeader == "" {
+		return fmt.Errorf("registry %s requires authentication, but no registry_auth entry is configured for it: %w", host, cause)
+	}
+	return fmt.Errorf("authentication to registry %s failed: check the registry_auth credentials (tokens may have expired): %w", host, cause)
+}
+
+// describeTransportError classifies a failed request to the registry.
+func describeTransportError(host string, err error) error {
+	var dnsErr *net.DNSError
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var certInvalidErr x509.CertificateInvalidError
+	var recordHeaderErr tls.RecordHeaderError
+	switch {
+	case errors.As(err, &dnsErr):
+		return fmt.Errorf("registry %s is unreachable: the hostname could not be resolved: %w", host, err)
+	case errors.As(err, &unknownAuthorityErr),
+		errors.As(err, &hostnameErr),
+		errors.As(err, &certInvalidErr),
+		errors.As(err, &recordHeaderErr):
+		return fmt.Errorf("TLS error connecting to registry %s: check the registry certificate and that it serves HTTPS: %w", host, err)
+	default:
+		return fmt.Errorf("registry %s is unreachable: %w", host, err)
+	}
+}
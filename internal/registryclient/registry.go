@@ -0,0 +1,194 @@
+package registryclient
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/distribution/reference"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/providerconfig"
+)
+
+// Client calls the Registry HTTP API v2 of a single registry host.
+// It authenticates with the provider registry_auth credentials for the host, using them
+// either as HTTP Basic credentials or to obtain a bearer token when the registry requests one.
+type Client struct {
+	httpClient *http.Client
+	host       string
+	basicAuth  string
+
+	mu    sync.Mutex
+	token string
+}
+
+// New returns a Client for the registry host (as returned by reference.Domain),
+// using the credentials configured for the host in cfg, if any.
+func New(cfg *providerconfig.Config, host string) (*Client, error) {
+	c := &Client{
+		httpClient: NewHTTPClient(),
+		host:       host,
+	}
+	if cfg == nil {
+		return c, nil
+	}
+	creds, ok := cfg.RegistryAuth[host]
+	if !ok {
+		return c, nil
+	}
+	if creds.Username == "" || creds.Password == "" {
+		return nil, fmt.Errorf("registry_auth for %q has empty username or password", host)
+	}
+	c.basicAuth = BasicAuthHeader(creds.Username, creds.Password)
+	return c, nil
+}
+
+// BasicAuthHeader returns an HTTP Authorization header value for Basic authentication.
+func BasicAuthHeader(username, password string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+}
+
+// Host returns the registry hostname of the client.
+func (c *Client) Host() string {
+	return c.host
+}
+
+// HTTPClient returns the underlying HTTP client.
+func (c *Client) HTTPClient() *http.Client {
+	return c.httpClient
+}
+
+// BasicAuth returns the Basic Authorization header value for the configured credentials.
+// It is empty when no credentials are configured for the host.
+func (c *Client) BasicAuth() string {
+	return c.basicAuth
+}
+
+// URL returns the URL of an API path such as "/v2/<name>/tags/list".
+func (c *Client) URL(path string) string {
+	return fmt.Sprintf("https://%s%s", APIHost(c.host), path)
+}
+
+// Do sends a request to the registry with authentication.
+// header is added to the request (e.g. Accept); body may be nil.
+// When the registry answers 401 with a bearer challenge, a token is requested and the request is retried once.
+func (c *Client) Do(ctx context.Context, method, rawURL string, header http.Header, body io.ReadSeeker) (*http.Response, error) {
+	resp, err := c.send(ctx, method, rawURL, header, body, c.authorization())
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	challenge := ParseChallenge(resp.Header.Get("WWW-Authenticate"))
+	if challenge == nil || challenge.Scheme != "bearer" {
+		return resp, nil
+	}
+	if body != nil {
+		if _, err := body.Seek(0, io.SeekStart); err != nil {
+			return resp, nil
+		}
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	token, err := FetchToken(ctx, c.httpClient, challenge, "", c.basicAuth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to authenticate to registry %s: %w", c.host, err)
+	}
+	c.mu.Lock()
+	c.token = token.Token
+	c.mu.Unlock()
+	return c.send(ctx, method, rawURL, header, body, "Bearer "+token.Token)
+}
+
+// authorization returns the Authorization header value to use for the next request.
+func (c *Client) authorization() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" {
+		return "Bearer " + c.token
+	}
+	return c.basicAuth
+}
+
+func (c *Client) send(ctx context.Context, method, rawURL string, header http.Header, body io.ReadSeeker, authorization string) (*http.Response, error) {
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = body
+	}
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, values := range header {
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+	// Send a Content-Length rather than a chunked body for streamed blobs, which some registries reject
+	if sized, ok := body.(interface{ Size() int64 }); ok && req.ContentLength == 0 {
+		req.ContentLength = sized.Size()
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	return c.httpClient.Do(req)
+}
+
+// ParseRepository parses a repository reference such as "ghcr.io/owner/app" or "nginx"
+// (Docker Hub short names are normalized) and returns the registry host and repository path.
+// A tag or digest in the reference is ignored.
+func ParseRepository(repository string) (string, string, error) {
+	named, err := reference.ParseNormalizedNamed(repository)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid repository %q: %w", repository, err)
+	}
+	return reference.Domain(named), reference.Path(named), nil
+}
+
+// nextLink returns the URL of the rel="next" entry in a Link header, resolved against base.
+// It returns an empty string when there is no next page.
+func nextLink(base *url.URL, header string) string {
+	for _, link := range strings.Split(header, ",") {
+		target, params, ok := strings.Cut(strings.TrimSpace(link), ";")
+		if !ok || !strings.Contains(strings.ReplaceAll(params, " ", ""), `rel="next"`) {
+			continue
+		}
+		target = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(target), "<"), ">")
+		next, err := base.Parse(target)
+		if err != nil {
+			return ""
+		}
+		return next.String()
+	}
+	return ""
+}
+
+// RequestToken performs the registry token exchange with the configured credentials and returns
+// a bearer token for scope (e.g. "repository:owner/app:pull"; empty for the scope requested by the registry).
+// It returns nil without error when the registry does not use bearer token authentication.
+func (c *Client) RequestToken(ctx context.Context, scope string) (*Token, error) {
+	resp, err := c.send(ctx, http.MethodGet, c.URL("/v2/"), nil, nil, "")
+	if err != nil {
+		return nil, describeTransportError(c.host, err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		return nil, nil
+	}
+
+	challenge := ParseChallenge(resp.Header.Get("WWW-Authenticate"))
+	if challenge == nil || challenge.Scheme != "bearer" {
+		return nil, nil
+	}
+	token, err := FetchToken(ctx, c.httpClient, challenge, scope, c.basicAuth)
+	if err != nil {
+		return nil, authError(c.host, c.basicAuth, err)
+	}
+	return token, nil
+}
@@ -0,0 +1,49 @@
+package registryclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// tagsPageSize is the number of tags requested per page.
+const tagsPageSize = 1000
+
+// ListTags returns all tags of the repository, following Link header pagination.
+func (c *Client) ListTags(ctx context.Context, repository string) ([]string, error) {
+	var tags []string
+	next := c.URL(fmt.Sprintf("/v2/%s/tags/list?n=%d", repository, tagsPageSize))
+	for next != "" {
+		pageURL, err := url.Parse(next)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tags list URL %q: %w", next, err)
+		}
+
+		resp, err := c.Do(ctx, http.MethodGet, next, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tags: %w", err)
+		}
+		page, link, err := func() ([]string, string, error) {
+			defer resp.Body.Close()
+			if err := CheckResponse(resp, "list tags"); err != nil {
+				return nil, "", err
+			}
+			var body struct {
+				Tags []string `json:"tags"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				return nil, "", fmt.Errorf("failed to decode tags list: %w", err)
+			}
+			return body.Tags, resp.Header.Get("Link"), nil
+		}()
+		if err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, page...)
+		next = nextLink(pageURL, link)
+	}
+	return tags, nil
+}
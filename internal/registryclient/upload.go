@@ -0,0 +1,219 @@
+package registryclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+
+	ocidigest "github.com/opencontainers/go-digest"
+)
+
+// Media types used when pushing OCI artifacts.
+const (
+	// MediaTypeEmptyJSON is the media type of the empty config ("{}") of artifacts without a config.
+	MediaTypeEmptyJSON = "application/vnd.oci.empty.v1+json"
+	// AnnotationTitle is the annotation holding the file name of a layer.
+	AnnotationTitle = "org.opencontainers.image.title"
+)
+
+// BlobExists reports whether the blob identified by digest exists in repository.
+func (c *Client) BlobExists(ctx context.Context, repository, digest string) (bool, error) {
+	resp, err := c.Do(ctx, http.MethodHead, c.URL(fmt.Sprintf("/v2/%s/blobs/%s", repository, digest)), nil, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to head blob: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := CheckResponse(resp, "head blob"); err != nil {
+		if IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// UploadBlob uploads content to repository unless a blob with the same digest already exists,
+// and returns its descriptor with mediaType.
+func (c *Client) UploadBlob(ctx context.Context, repository, mediaType string, content []byte) (Descriptor, error) {
+	desc := Descriptor{
+		MediaType: mediaType,
+		Digest:    ocidigest.FromBytes(content).String(),
+		Size:      int64(len(content)),
+	}
+	exists, err := c.BlobExists(ctx, repository, desc.Digest)
+	if err != nil {
+		return desc, err
+	}
+	if exists {
+		return desc, nil
+	}
+	return desc, c.uploadBlob(ctx, repository, desc.Digest, bytes.NewReader(content))
+}
+
+// UploadBlobFile uploads the file at path as the blob desc.Digest unless it already exists in repository.
+// The file is streamed rather than read into memory, so it is suitable for image layers.
+// It reports whether the blob was uploaded.
+func (c *Client) UploadBlobFile(ctx context.Context, repository string, desc Descriptor, path string) (bool, error) {
+	exists, err := c.BlobExists(ctx, repository, desc.Digest)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		return false, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to open blob: %w", err)
+	}
+	defer f.Close()
+	if err := c.uploadBlob(ctx, repository, desc.Digest, io.NewSectionReader(f, 0, desc.Size)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// uploadBlob uploads body as the blob digest to repository.
+func (c *Client) uploadBlob(ctx context.Context, repository, digest string, body io.ReadSeeker) error {
+	// Start an upload session, then complete it with a single monolithic PUT.
+	startURL := c.URL(fmt.Sprintf("/v2/%s/blobs/uploads/", repository))
+	resp, err := c.Do(ctx, http.MethodPost, startURL, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start blob upload: %w", err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	if err := CheckResponse(resp, "start blob upload"); err != nil {
+		return err
+	}
+
+	base, err := url.Parse(startURL)
+	if err != nil {
+		return fmt.Errorf("invalid upload URL %q: %w", startURL, err)
+	}
+	uploadURL, err := base.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return fmt.Errorf("invalid upload location %q: %w", resp.Header.Get("Location"), err)
+	}
+	query := uploadURL.Query()
+	query.Set("digest", digest)
+	uploadURL.RawQuery = query.Encode()
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/octet-stream")
+	resp, err = c.Do(ctx, http.MethodPut, uploadURL.String(), header, body)
+	if err != nil {
+		return fmt.Errorf("failed to upload blob: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := CheckResponse(resp, "upload blob"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// PutManifest pushes a manifest to repository with ref (a tag or digest) and returns the manifest digest.
+func (c *Client) PutManifest(ctx context.Context, repository, ref, mediaType string, body []byte) (string, error) {
+	header := http.Header{}
+	header.Set("Content-Type", mediaType)
+	resp, err := c.Do(ctx, http.MethodPut, c.URL(fmt.Sprintf("/v2/%s/manifests/%s", repository, ref)), header, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to put manifest: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := CheckResponse(resp, "put manifest"); err != nil {
+		return "", err
+	}
+	if digest := resp.Header.Get("Docker-Content-Digest"); digest != "" {
+		return digest, nil
+	}
+	return ocidigest.FromBytes(body).String(), nil
+}
+
+// DeleteManifest deletes the manifest identified by ref (a digest) from repository.
+// With a tag as ref, registries supporting tag deletion remove only the tag.
+// Use IsNotFound and IsUnsupported to classify the returned error.
+func (c *Client) DeleteManifest(ctx context.Context, repository, ref string) error {
+	resp, err := c.Do(ctx, http.MethodDelete, c.URL(fmt.Sprintf("/v2/%s/manifests/%s", repository, ref)), nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete manifest: %w", err)
+	}
+	defer resp.Body.Close()
+	return CheckResponse(resp, "delete manifest")
+}
+
+// ImageManifest is an OCI image manifest, used to push artifacts.
+type ImageManifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType"`
+	ArtifactType  string            `json:"artifactType,omitempty"`
+	Config        Descriptor        `json:"config"`
+	Layers        []Descriptor      `json:"layers"`
+	Subject       *Descriptor       `json:"subject,omitempty"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// Layer is a layer of an artifact to push.
+type Layer struct {
+	MediaType   string
+	Content     []byte
+	Annotations map[string]string
+}
+
+// Artifact is an OCI artifact to push.
+type Artifact struct {
+	// ArtifactType is the artifactType of the manifest. Required when Config is nil.
+	ArtifactType string
+	// ConfigMediaType and Config are the config blob. The empty JSON config is used when Config is nil.
+	ConfigMediaType string
+	Config          []byte
+	Layers          []Layer
+	// Subject makes the artifact a referrer of another manifest (e.g. a signature or an attestation).
+	Subject     *Descriptor
+	Annotations map[string]string
+}
+
+// PushArtifact uploads the blobs of artifact and pushes its manifest to repository with ref
+// (a tag, or empty to push by digest). It returns the manifest digest.
+func (c *Client) PushArtifact(ctx context.Context, repository, ref string, artifact *Artifact) (string, error) {
+	configMediaType, config := artifact.ConfigMediaType, artifact.Config
+	if config == nil {
+		configMediaType, config = MediaTypeEmptyJSON, []byte("{}")
+	}
+	configDesc, err := c.UploadBlob(ctx, repository, configMediaType, config)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload config: %w", err)
+	}
+
+	manifest := ImageManifest{
+		SchemaVersion: 2,
+		MediaType:     MediaTypeOCIManifest,
+		ArtifactType:  artifact.ArtifactType,
+		Config:        configDesc,
+		Layers:        make([]Descriptor, 0, len(artifact.Layers)),
+		Subject:       artifact.Subject,
+		Annotations:   artifact.Annotations,
+	}
+	for _, layer := range artifact.Layers {
+		desc, err := c.UploadBlob(ctx, repository, layer.MediaType, layer.Content)
+		if err != nil {
+			return "", fmt.Errorf("failed to upload layer: %w", err)
+		}
+		desc.Annotations = layer.Annotations
+		manifest.Layers = append(manifest.Layers, desc)
+	}
+
+	body, err := json.Marshal(manifest)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if ref == "" {
+		ref = ocidigest.FromBytes(body).String()
+	}
+	return c.PutManifest(ctx, repository, ref, MediaTypeOCIManifest, body)
+}
@@ -0,0 +1,23 @@
+package artifact
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// FileModel describes a file pushed as a layer of the artifact.
+type FileModel struct {
+	Path      types.String `tfsdk:"path"`
+	MediaType types.String `tfsdk:"media_type"`
+}
+
+// ArtifactResourceModel describes the containerregistry_artifact resource data model.
+type ArtifactResourceModel struct {
+	ID             types.String `tfsdk:"id"`
+	ImageURI       types.String `tfsdk:"image_uri"`
+	ArtifactType   types.String `tfsdk:"artifact_type"`
+	Files          []FileModel  `tfsdk:"files"`
+	Annotations    types.Map    `tfsdk:"annotations"`
+	Triggers       types.Map    `tfsdk:"triggers"`
+	DeleteArtifact types.Bool   `tfsdk:"delete_artifact"`
+	SHA256Digest   types.String `tfsdk:"sha256_digest"`
+}
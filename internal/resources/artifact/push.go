@@ -0,0 +1,85 @@
+package artifact
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// defaultLayerMediaType is the media type of files without media_type.
+const defaultLayerMediaType = "application/octet-stream"
+
+// newClient returns a registry client for image_uri along with the repository and tag.
+func (r *ArtifactResource) newClient(model *ArtifactResourceModel) (*registryclient.Client, string, string, error) {
+	host, repository, ref, err := registryclient.ParseImageReference(model.ImageURI.ValueString())
+	if err != nil {
+		return nil, "", "", err
+	}
+	if strings.Contains(ref, ":") {
+		return nil, "", "", fmt.Errorf("image URI %s must specify a tag, not a digest", model.ImageURI.ValueString())
+	}
+	client, err := registryclient.New(r.providerConfig, host)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return client, repository, ref, nil
+}
+
+// pushArtifact pushes the files as an OCI artifact and returns the manifest digest.
+func (r *ArtifactResource) pushArtifact(ctx context.Context, model *ArtifactResourceModel) (string, error) {
+	client, repository, tag, err := r.newClient(model)
+	if err != nil {
+		return "", err
+	}
+
+	artifact := &registryclient.Artifact{
+		ArtifactType: model.ArtifactType.ValueString(),
+		Layers:       make([]registryclient.Layer, 0, len(model.Files)),
+	}
+	if !model.Annotations.IsNull() && !model.Annotations.IsUnknown() {
+		annotations := map[string]string{}
+		if diags := model.Annotations.ElementsAs(ctx, &annotations, false); diags.HasError() {
+			return "", fmt.Errorf("invalid annotations")
+		}
+		artifact.Annotations = annotations
+	}
+	for _, file := range model.Files {
+		content, err := os.ReadFile(file.Path.ValueString())
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", file.Path.ValueString(), err)
+		}
+		mediaType := file.MediaType.ValueString()
+		if mediaType == "" {
+			mediaType = defaultLayerMediaType
+		}
+		artifact.Layers = append(artifact.Layers, registryclient.Layer{
+			MediaType: mediaType,
+			Content:   content,
+			Annotations: map[string]string{
+				registryclient.AnnotationTitle: filepath.Base(file.Path.ValueString()),
+			},
+		})
+	}
+
+	tflog.Info(ctx, "Pushing artifact", map[string]interface{}{
+		"image_uri":     model.ImageURI.ValueString(),
+		"artifact_type": artifact.ArtifactType,
+		"files":         len(artifact.Layers),
+	})
+
+	digest, err := client.PushArtifact(ctx, repository, tag, artifact)
+	if err != nil {
+		return "", err
+	}
+	tflog.Info(ctx, "Successfully pushed artifact", map[string]interface{}{
+		"image_uri": model.ImageURI.ValueString(),
+		"digest":    digest,
+	})
+	return digest, nil
+}
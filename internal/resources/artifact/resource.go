@@ -0,0 +1,229 @@
+package artifact
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/logging"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/providerconfig"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/resources/registrydelete"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ resource.Resource = &ArtifactResource{}
+var _ resource.ResourceWithConfigure = &ArtifactResource{}
+
+// NewArtifactResource returns a new resource implementing the containerregistry_artifact resource type.
+func NewArtifactResource() resource.Resource {
+	return &ArtifactResource{}
+}
+
+// ArtifactResource defines the resource implementation.
+type ArtifactResource struct {
+	providerConfig *providerconfig.Config
+}
+
+// Metadata returns the resource type name.
+func (r *ArtifactResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_artifact"
+}
+
+// Schema defines the schema for the resource.
+func (r *ArtifactResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Generic OCI artifact (e.g. WASM modules, policy bundles) pushed to a container registry",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Identifier of the artifact",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"image_uri": schema.StringAttribute{
+				MarkdownDescription: "URI with a tag to push the artifact to",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"artifact_type": schema.StringAttribute{
+				MarkdownDescription: "Artifact type set in the manifest (e.g. `application/vnd.wasm.config.v0+json`)",
+				Required:            true,
+			},
+			"files": schema.ListNestedAttribute{
+				MarkdownDescription: "Files to push, each as a layer of the artifact. " +
+					"The file name is recorded in the `org.opencontainers.image.title` annotation of the layer.",
+				Required: true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"path": schema.StringAttribute{
+							MarkdownDescription: "Path of the file",
+							Required:            true,
+						},
+						"media_type": schema.StringAttribute{
+							MarkdownDescription: "Media type of the layer",
+							Optional:            true,
+							Computed:            true,
+							Default:             stringdefault.StaticString(defaultLayerMediaType),
+						},
+					},
+				},
+			},
+			"annotations": schema.MapAttribute{
+				MarkdownDescription: "Annotations of the manifest",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"triggers": schema.MapAttribute{
+				MarkdownDescription: "Map of arbitrary strings that, when changed, will force the artifact to be pushed again " +
+					"(e.g. hashes of the files)",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"delete_artifact": schema.BoolAttribute{
+				MarkdownDescription: "Whether to delete the artifact from the registry when the resource is deleted",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"sha256_digest": schema.StringAttribute{
+				MarkdownDescription: "SHA256 digest of the artifact manifest in the registry",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+// Configure adds the provider configured client to the resource.
+func (r *ArtifactResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	if cfg, ok := req.ProviderData.(*providerconfig.Config); ok {
+		r.providerConfig = cfg
+	}
+}
+
+// Create pushes the artifact and sets the initial Terraform state.
+func (r *ArtifactResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	// Initialize the HTTP logging subsystem and header masking for this request.
+	ctx = logging.WithHTTPLoggingSubsystem(ctx)
+
+	var plan ArtifactResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	digest, err := r.pushArtifact(ctx, &plan)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error pushing artifact",
+			fmt.Sprintf("Could not push artifact %s: %s", plan.ImageURI.ValueString(), err),
+		)
+		return
+	}
+
+	plan.ID = plan.ImageURI
+	plan.SHA256Digest = types.StringValue(digest)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+// Read refreshes the Terraform state with the digest in the registry.
+func (r *ArtifactResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// Initialize the HTTP logging subsystem and header masking for this request.
+	ctx = logging.WithHTTPLoggingSubsystem(ctx)
+
+	var state ArtifactResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, repository, tag, err := r.newClient(&state)
+	if err != nil {
+		resp.Diagnostics.AddError("Error configuring registry client", err.Error())
+		return
+	}
+	digest, err := client.ResolveDigest(ctx, repository, tag)
+	if registryclient.IsNotFound(err) {
+		tflog.Warn(ctx, "Artifact not found in registry", map[string]interface{}{
+			"image_uri": state.ImageURI.ValueString(),
+			"error":     err.Error(),
+		})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error reading artifact",
+			fmt.Sprintf("Could not read artifact %s: %s", state.ImageURI.ValueString(), err),
+		)
+		return
+	}
+
+	state.SHA256Digest = types.StringValue(digest)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+// Update pushes the artifact again and sets the updated Terraform state on success.
+func (r *ArtifactResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	// Initialize the HTTP logging subsystem and header masking for this request.
+	ctx = logging.WithHTTPLoggingSubsystem(ctx)
+
+	var plan ArtifactResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	digest, err := r.pushArtifact(ctx, &plan)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error pushing artifact",
+			fmt.Sprintf("Could not push artifact %s: %s", plan.ImageURI.ValueString(), err),
+		)
+		return
+	}
+
+	plan.SHA256Digest = types.StringValue(digest)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+// Delete deletes the artifact from the registry when delete_artifact is set.
+func (r *ArtifactResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	// Initialize the HTTP logging subsystem and header masking for this request.
+	ctx = logging.WithHTTPLoggingSubsystem(ctx)
+
+	var state ArtifactResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !state.DeleteArtifact.ValueBool() || state.SHA256Digest.ValueString() == "" {
+		return
+	}
+
+	tflog.Info(ctx, "Deleting artifact from registry", map[string]interface{}{
+		"image_uri": state.ImageURI.ValueString(),
+		"digest":    state.SHA256Digest.ValueString(),
+	})
+	client, repository, _, err := r.newClient(&state)
+	if err == nil {
+		err = client.DeleteManifest(ctx, repository, state.SHA256Digest.ValueString())
+	}
+	// Continue with resource deletion even if artifact deletion fails
+	registrydelete.WarnDeleteError(&resp.Diagnostics, "artifact", state.ImageURI.ValueString(), "delete_artifact", err)
+}
@@ -0,0 +1,16 @@
+package attestation
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// AttestationResourceModel describes the containerregistry_attestation resource data model.
+type AttestationResourceModel struct {
+	ID                types.String `tfsdk:"id"`
+	ImageURI          types.String `tfsdk:"image_uri"`
+	PredicateType     types.String `tfsdk:"predicate_type"`
+	Predicate         types.String `tfsdk:"predicate"`
+	BuilderID         types.String `tfsdk:"builder_id"`
+	DeleteAttestation types.Bool   `tfsdk:"delete_attestation"`
+	SHA256Digest      types.String `tfsdk:"sha256_digest"`
+}
@@ -0,0 +1,223 @@
+package attestation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/logging"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/providerconfig"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/resources/registrydelete"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ resource.Resource = &AttestationResource{}
+var _ resource.ResourceWithConfigure = &AttestationResource{}
+
+// NewAttestationResource returns a new resource implementing the containerregistry_attestation resource type.
+func NewAttestationResource() resource.Resource {
+	return &AttestationResource{}
+}
+
+// AttestationResource defines the resource implementation.
+type AttestationResource struct {
+	providerConfig *providerconfig.Config
+}
+
+// Metadata returns the resource type name.
+func (r *AttestationResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_attestation"
+}
+
+// Schema defines the schema for the resource.
+func (r *AttestationResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "In-toto attestation (e.g. SLSA provenance) attached to an image as an OCI referrer",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Identifier of the attestation",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"image_uri": schema.StringAttribute{
+				MarkdownDescription: "Image to attest, pinned by digest (e.g. `ghcr.io/owner/app@sha256:...`)",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"predicate_type": schema.StringAttribute{
+				MarkdownDescription: "Predicate type of the statement. Defaults to SLSA provenance v1 (`https://slsa.dev/provenance/v1`).",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString(slsaProvenanceV1),
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"predicate": schema.StringAttribute{
+				MarkdownDescription: "Predicate in JSON format. When omitted for SLSA provenance, " +
+					"a minimal provenance recording the image and `builder_id` is generated.",
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"builder_id": schema.StringAttribute{
+				MarkdownDescription: "Builder ID recorded in generated provenance. Ignored when `predicate` is set.",
+				Optional:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"delete_attestation": schema.BoolAttribute{
+				MarkdownDescription: "Whether to delete the attestation from the registry when the resource is deleted",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"sha256_digest": schema.StringAttribute{
+				MarkdownDescription: "SHA256 digest of the attestation manifest in the registry",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+// Configure adds the provider configured client to the resource.
+func (r *AttestationResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	if cfg, ok := req.ProviderData.(*providerconfig.Config); ok {
+		r.providerConfig = cfg
+	}
+}
+
+// newClient returns a registry client for image_uri along with the repository and the image digest.
+func (r *AttestationResource) newClient(model *AttestationResourceModel) (*registryclient.Client, string, string, error) {
+	host, repository, ref, err := registryclient.ParseImageReference(model.ImageURI.ValueString())
+	if err != nil {
+		return nil, "", "", err
+	}
+	if !strings.Contains(ref, ":") {
+		return nil, "", "", fmt.Errorf("image URI %s must specify a digest (e.g. repo@sha256:...)", model.ImageURI.ValueString())
+	}
+	client, err := registryclient.New(r.providerConfig, host)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return client, repository, ref, nil
+}
+
+// Create pushes the attestation and sets the initial Terraform state.
+func (r *AttestationResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	// Initialize the HTTP logging subsystem and header masking for this request.
+	ctx = logging.WithHTTPLoggingSubsystem(ctx)
+
+	var plan AttestationResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	digest, err := r.attest(ctx, &plan)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error pushing attestation",
+			fmt.Sprintf("Could not attach an attestation to %s: %s", plan.ImageURI.ValueString(), err),
+		)
+		return
+	}
+
+	plan.ID = types.StringValue(digest)
+	plan.SHA256Digest = types.StringValue(digest)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+// Read checks that the attestation still exists in the registry.
+func (r *AttestationResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// Initialize the HTTP logging subsystem and header masking for this request.
+	ctx = logging.WithHTTPLoggingSubsystem(ctx)
+
+	var state AttestationResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, repository, _, err := r.newClient(&state)
+	if err != nil {
+		resp.Diagnostics.AddError("Error configuring registry client", err.Error())
+		return
+	}
+	_, err = client.ResolveDigest(ctx, repository, state.SHA256Digest.ValueString())
+	if registryclient.IsNotFound(err) {
+		tflog.Warn(ctx, "Attestation not found in registry", map[string]interface{}{
+			"image_uri": state.ImageURI.ValueString(),
+			"digest":    state.SHA256Digest.ValueString(),
+			"error":     err.Error(),
+		})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error reading attestation",
+			fmt.Sprintf("Could not read attestation %s: %s", state.SHA256Digest.ValueString(), err),
+		)
+		return
+	}
+}
+
+// Update stores delete_attestation; every other change replaces the attestation.
+func (r *AttestationResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan AttestationResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+// Delete deletes the attestation from the registry when delete_attestation is set.
+func (r *AttestationResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	// Initialize the HTTP logging subsystem and header masking for this request.
+	ctx = logging.WithHTTPLoggingSubsystem(ctx)
+
+	var state AttestationResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !state.DeleteAttestation.ValueBool() || state.SHA256Digest.ValueString() == "" {
+		return
+	}
+
+	tflog.Info(ctx, "Deleting attestation from registry", map[string]interface{}{
+		"image_uri": state.ImageURI.ValueString(),
+		"digest":    state.SHA256Digest.ValueString(),
+	})
+	client, repository, _, err := r.newClient(&state)
+	if err == nil {
+		err = client.DeleteManifest(ctx, repository, state.SHA256Digest.ValueString())
+	}
+	// Continue with resource deletion even if attestation deletion fails
+	registrydelete.WarnDeleteError(&resp.Diagnostics, "attestation", state.SHA256Digest.ValueString(), "delete_attestation", err)
+}
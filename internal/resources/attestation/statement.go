@@ -0,0 +1,128 @@
+package attestation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+const (
+	// mediaTypeInToto is the media type of in-toto statements.
+	mediaTypeInToto = "application/vnd.in-toto+json"
+	// annotationPredicateType is the layer annotation holding the predicate type, as used by BuildKit.
+	annotationPredicateType = "in-toto.io/predicate-type"
+	// statementType is the in-toto statement type.
+	statementType = "https://in-toto.io/Statement/v1"
+	// slsaProvenanceV1 is the predicate type of SLSA provenance v1.
+	slsaProvenanceV1 = "https://slsa.dev/provenance/v1"
+	// defaultBuilderID is the builder ID used in generated provenance.
+	defaultBuilderID = "https://github.com/ikedam/terraform-provider-containerregistry"
+	// provenanceBuildType is the build type used in generated provenance.
+	provenanceBuildType = "https://github.com/ikedam/terraform-provider-containerregistry/attestation@v1"
+)
+
+// statement is an in-toto statement.
+type statement struct {
+	Type          string             `json:"_type"`
+	Subject       []statementSubject `json:"subject"`
+	PredicateType string             `json:"predicateType"`
+	Predicate     json.RawMessage    `json:"predicate"`
+}
+
+type statementSubject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// generateProvenance returns a minimal SLSA provenance v1 predicate for the image.
+// It contains no timestamps so that applying the same configuration produces the same attestation.
+func generateProvenance(imageURI, builderID string) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"buildDefinition": map[string]interface{}{
+			"buildType": provenanceBuildType,
+			"externalParameters": map[string]interface{}{
+				"image_uri": imageURI,
+			},
+		},
+		"runDetails": map[string]interface{}{
+			"builder": map[string]interface{}{
+				"id": builderID,
+			},
+		},
+	})
+}
+
+// attest pushes an in-toto attestation as a referrer of the image and returns its manifest digest.
+func (r *AttestationResource) attest(ctx context.Context, model *AttestationResourceModel) (string, error) {
+	client, repository, digest, err := r.newClient(model)
+	if err != nil {
+		return "", err
+	}
+
+	predicateType := model.PredicateType.ValueString()
+	var predicate []byte
+	if !model.Predicate.IsNull() && model.Predicate.ValueString() != "" {
+		predicate = []byte(model.Predicate.ValueString())
+		if !json.Valid(predicate) {
+			return "", fmt.Errorf("predicate is not valid JSON")
+		}
+	} else {
+		if predicateType != slsaProvenanceV1 {
+			return "", fmt.Errorf("predicate is required for predicate type %s", predicateType)
+		}
+		builderID := model.BuilderID.ValueString()
+		if builderID == "" {
+			builderID = defaultBuilderID
+		}
+		predicate, err = generateProvenance(model.ImageURI.ValueString(), builderID)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// The subject of the referrer must describe the image manifest exactly.
+	manifest, err := client.GetManifest(ctx, repository, digest)
+	if err != nil {
+		return "", fmt.Errorf("failed to get image manifest: %w", err)
+	}
+	algorithm, hex, _ := strings.Cut(manifest.Digest, ":")
+	body, err := json.Marshal(statement{
+		Type: statementType,
+		Subject: []statementSubject{
+			{
+				Name:   fmt.Sprintf("%s/%s", client.Host(), repository),
+				Digest: map[string]string{algorithm: hex},
+			},
+		},
+		PredicateType: predicateType,
+		Predicate:     predicate,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode statement: %w", err)
+	}
+
+	tflog.Info(ctx, "Pushing attestation", map[string]interface{}{
+		"image_uri":      model.ImageURI.ValueString(),
+		"predicate_type": predicateType,
+	})
+	return client.PushArtifact(ctx, repository, "", &registryclient.Artifact{
+		ArtifactType: mediaTypeInToto,
+		Layers: []registryclient.Layer{
+			{
+				MediaType:   mediaTypeInToto,
+				Content:     body,
+				Annotations: map[string]string{annotationPredicateType: predicateType},
+			},
+		},
+		Subject: &registryclient.Descriptor{
+			MediaType: manifest.MediaType,
+			Digest:    manifest.Digest,
+			Size:      int64(len(manifest.Body)),
+		},
+	})
+}
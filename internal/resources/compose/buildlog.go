@@ -3,7 +3,11 @@ package compose
 import (
 	"bufio"
 	"context"
+	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
@@ -23,9 +27,21 @@ type buildLogCapture struct {
 	bufstart  int
 	bufnext   int
 	log       string // trace, debug, info, warn, error
+	steps     bool
+	stepNames map[string]string // BuildKit vertex ID -> step name
+	file      *os.File
 	done      chan struct{}
 }
 
+var (
+	// buildKitStepPattern matches the first line of a step in BuildKit plain progress output, e.g. "#5 [build 2/4] RUN make".
+	buildKitStepPattern = regexp.MustCompile(`^#(\d+) (\[[^\]]+\] .+)$`)
+	// buildKitStepEndPattern matches the last line of a step in BuildKit plain progress output, e.g. "#5 DONE 12.3s" or "#5 CACHED".
+	buildKitStepEndPattern = regexp.MustCompile(`^#(\d+) (DONE [0-9.]+s|CACHED|ERROR.*)$`)
+	// classicStepPattern matches a step of the classic builder output, e.g. "Step 2/4 : RUN make".
+	classicStepPattern = regexp.MustCompile(`^Step (\d+/\d+) : (.+)$`)
+)
+
 // syncWriter serializes writes so both WithOutputStream and WithErrorStream can share one pipe.
 type syncWriter struct {
 	mu sync.Mutex
@@ -57,7 +73,7 @@ func logBuildLine(ctx context.Context, level, line string) {
 	}
 }
 
-func newBuildLogCapture(_ context.Context, timestamp bool, lines int, log string) *buildLogCapture {
+func newBuildLogCapture(_ context.Context, timestamp bool, lines int, log string, steps bool) *buildLogCapture {
 	if lines <= 0 {
 		lines = 1
 	}
@@ -72,6 +88,8 @@ func newBuildLogCapture(_ context.Context, timestamp bool, lines int, log string
 		bufstart:  0,
 		bufnext:   0,
 		log:       log,
+		steps:     steps,
+		stepNames: map[string]string{},
 		done:      make(chan struct{}),
 	}
 
@@ -92,6 +110,11 @@ func (c *buildLogCapture) Start(ctx context.Context) {
 // run reads lines from the pipe and either buffers or streams.
 func (c *buildLogCapture) run(ctx context.Context) {
 	defer close(c.done)
+	defer func() {
+		if c.file != nil {
+			_ = c.file.Close()
+		}
+	}()
 	scanner := bufio.NewScanner(c.pipeR)
 	// Allow long lines (e.g. progress lines)
 	scanner.Buffer(nil, 1024*1024)
@@ -100,9 +123,19 @@ func (c *buildLogCapture) run(ctx context.Context) {
 		if c.timestamp {
 			line = time.Now().UTC().Format("2006-01-02T15:04:05.000Z07:00") + " " + line
 		}
+		if c.steps {
+			c.logStep(ctx, scanner.Text())
+		}
 		if c.log != "" {
 			logBuildLine(ctx, c.log, line)
 		}
+		if c.file != nil {
+			if _, err := fmt.Fprintln(c.file, line); err != nil {
+				tflog.Warn(ctx, "Failed to write build log file: ignored", map[string]interface{}{"error": err.Error()})
+				_ = c.file.Close()
+				c.file = nil
+			}
+		}
 		func() {
 			c.ringbuf[c.bufnext] = line
 			c.bufnext = (c.bufnext + 1) % len(c.ringbuf)
@@ -121,6 +154,49 @@ func (c *buildLogCapture) run(ctx context.Context) {
 	}
 }
 
+// WriteToFile makes the capture write every line to the file at path, replacing its content.
+// Call before Start.
+func (c *buildLogCapture) WriteToFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for build log file: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create build log file: %w", err)
+	}
+	c.file = f
+	return nil
+}
+
+// logStep logs a summary at info level when line starts or ends a build step.
+func (c *buildLogCapture) logStep(ctx context.Context, line string) {
+	if m := buildKitStepPattern.FindStringSubmatch(line); m != nil {
+		// Internal steps (loading the Dockerfile, .dockerignore, metadata) are not instructions
+		if _, ok := c.stepNames[m[1]]; ok || strings.HasPrefix(m[2], "[internal]") {
+			return
+		}
+		c.stepNames[m[1]] = m[2]
+		tflog.Info(ctx, "Build step started", map[string]interface{}{"step": m[2]})
+		return
+	}
+	if m := buildKitStepEndPattern.FindStringSubmatch(line); m != nil {
+		name, ok := c.stepNames[m[1]]
+		if !ok {
+			return
+		}
+		tflog.Info(ctx, "Build step finished", map[string]interface{}{
+			"step":   name,
+			"result": m[2],
+		})
+		return
+	}
+	if m := classicStepPattern.FindStringSubmatch(line); m != nil {
+		tflog.Info(ctx, "Build step started", map[string]interface{}{
+			"step": fmt.Sprintf("[%s] %s", m[1], m[2]),
+		})
+	}
+}
+
 // Close closes the pipe writer so the reader goroutine exits.
 func (c *buildLogCapture) Close() error {
 	return c.pipeW.Close()
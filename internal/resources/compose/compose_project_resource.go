@@ -0,0 +1,708 @@
+package compose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/logging"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/providerconfig"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ resource.Resource = &ComposeProjectResource{}
+var _ resource.ResourceWithConfigure = &ComposeProjectResource{}
+var _ resource.ResourceWithValidateConfig = &ComposeProjectResource{}
+var _ resource.ResourceWithModifyPlan = &ComposeProjectResource{}
+
+// composeProjectImageType is the type of the elements of the images attribute.
+var composeProjectImageType = types.ObjectType{
+	AttrTypes: map[string]attr.Type{
+		"image_uri":     types.StringType,
+		"sha256_digest": types.StringType,
+	},
+}
+
+// NewComposeProjectResource returns a new resource implementing the containerregistry_compose_project resource type.
+func NewComposeProjectResource() resource.Resource {
+	return &ComposeProjectResource{}
+}
+
+// ComposeProjectResource builds and pushes the images of several services of a compose file.
+// Each service is built and pushed in the same way as a containerregistry_compose resource with compose_file and service.
+type ComposeProjectResource struct {
+	compose ComposeResource
+}
+
+// Metadata returns the resource type name.
+func (r *ComposeProjectResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_compose_project"
+}
+
+// Schema defines the schema for the resource.
+func (r *ComposeProjectResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Container registry images built from the services of a compose file",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Identifier of the resource",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"compose_file": schema.StringAttribute{
+				MarkdownDescription: "Path of the compose file (compose.yaml) defining the services to build",
+				Required:            true,
+			},
+			"services": schema.MapAttribute{
+				MarkdownDescription: "URIs of the images to build and push, keyed by the name of the service in `compose_file`",
+				Required:            true,
+				ElementType:         types.StringType,
+				PlanModifiers: []planmodifier.Map{
+					servicesRequiresReplace(),
+				},
+			},
+			"environment":         environmentAttribute(),
+			"env_file":            envFileAttribute(),
+			"builder":             builderAttribute(),
+			"platform":            platformAttribute(),
+			"frontend_image":      frontendImageAttribute(),
+			"cache_from_previous": cacheFromPreviousAttribute(),
+			"squash":              squashAttribute(),
+			"additional_tags":     additionalTagsAttribute(),
+			"direct_push":         directPushAttribute(),
+			"push_by_digest":      pushByDigestAttribute(),
+			"on_tag_conflict":     onTagConflictAttribute(),
+			"on_drift":            onDriftAttribute(),
+			"offline":             offlineAttribute(),
+			"dry_run":             dryRunAttribute(),
+			"option":              optionAttribute(),
+			"labels": schema.MapAttribute{
+				MarkdownDescription: "Labels for the images",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"oci_labels":           ociLabelsAttribute(),
+			"annotations":          annotationsAttribute(),
+			"media_type":           mediaTypeAttribute(),
+			"wait_for_scan":        waitForScanAttribute(),
+			"fail_on_severity":     failOnSeverityAttribute(),
+			"wait_for_replication": waitForReplicationAttribute(),
+			"wait_for_available":   waitForAvailableAttribute(),
+			"triggers": schema.MapAttribute{
+				MarkdownDescription: "Map of arbitrary strings that, when changed, will force the images to be rebuilt",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"watch_paths": watchPathsAttribute(),
+			"rebuild":     rebuildAttribute(),
+			"delete_image": schema.BoolAttribute{
+				MarkdownDescription: "Whether to delete the images when the resource is deleted",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"delete_previous_tag": deletePreviousTagAttribute(),
+			"delete_image_scope":  deleteImageScopeAttribute(),
+			"prune_local": schema.BoolAttribute{
+				MarkdownDescription: "Whether to remove the locally built images from the Docker daemon after a successful push",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"prune_local_parents": schema.BoolAttribute{
+				MarkdownDescription: "Whether `prune_local` also removes the untagged parent images of the images, like `docker rmi` does by default. " +
+					"Default is false, keeping the parents that later builds may reuse as cache",
+				Optional: true,
+				Computed: true,
+				Default:  booldefault.StaticBool(false),
+			},
+			"images": schema.MapAttribute{
+				MarkdownDescription: "Pushed images keyed by service name, with `image_uri` and `sha256_digest` (SHA256 digest of the image in the registry)",
+				Computed:            true,
+				ElementType:         composeProjectImageType,
+			},
+			"build_duration_seconds": buildDurationSecondsAttribute(),
+			"push_duration_seconds":  pushDurationSecondsAttribute(),
+			"pushed_bytes":           pushedBytesAttribute(),
+			"context_hash":           contextHashAttribute(),
+		},
+
+		Blocks: map[string]schema.Block{
+			"timeouts":          timeoutsBlock(),
+			"create_repository": createRepositoryBlock(),
+			"test":              smokeTestBlock(),
+			"on_push":           onPushBlock(),
+		},
+	}
+}
+
+// Configure adds the provider configured client to the resource.
+func (r *ComposeProjectResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	if cfg, ok := req.ProviderData.(*providerconfig.Config); ok {
+		r.compose.providerConfig = cfg
+	}
+}
+
+// ValidateConfig checks the services and platform attributes and the option and timeouts blocks.
+func (r *ComposeProjectResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config ComposeProjectResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(validateTimeouts(config.Timeouts)...)
+	resp.Diagnostics.Append(validateBuilder(config.Builder, config.PruneLocal.ValueBool())...)
+	resp.Diagnostics.Append(validateCreateRepository(config.CreateRepository, types.StringNull())...)
+	resp.Diagnostics.Append(validateSmokeTest(config.Test, config.Builder, config.DirectPush)...)
+	resp.Diagnostics.Append(validateOnPush(config.OnPush)...)
+	resp.Diagnostics.Append(validateScan(config.WaitForScan, config.FailOnSeverity, types.StringNull())...)
+	resp.Diagnostics.Append(validateWaitForReplication(ctx, config.WaitForReplication, types.StringNull())...)
+	resp.Diagnostics.Append(validateWaitForAvailable(config.WaitForAvailable)...)
+	resp.Diagnostics.Append(validateOption(config.Option)...)
+	resp.Diagnostics.Append(validatePlatform(config.Platform)...)
+	resp.Diagnostics.Append(validateFrontendImage(config.FrontendImage)...)
+	resp.Diagnostics.Append(validateAdditionalTags(ctx, config.AdditionalTags)...)
+	resp.Diagnostics.Append(validateDirectPush(config.DirectPush, config.Builder, config.Squash)...)
+	resp.Diagnostics.Append(validatePushByDigest(config.PushByDigest, config.Builder, config.DirectPush)...)
+	resp.Diagnostics.Append(validateOnTagConflict(config.OnTagConflict, config.Builder, config.DirectPush)...)
+	resp.Diagnostics.Append(validateOnDrift(config.OnDrift)...)
+	resp.Diagnostics.Append(validateDeleteImageScope(config.DeleteImageScope, types.StringNull())...)
+	resp.Diagnostics.Append(validateOffline(config.Offline, config.Builder, config.CacheFromPrevious, config.Option)...)
+	resp.Diagnostics.Append(validateDryRun(config.DryRun, config.Builder, config.DirectPush)...)
+	resp.Diagnostics.Append(validateWatchPaths(ctx, config.WatchPaths)...)
+	resp.Diagnostics.Append(validateRebuild(config.Rebuild)...)
+	resp.Diagnostics.Append(validateAnnotations(config.Annotations, config.Builder, config.Squash)...)
+	resp.Diagnostics.Append(validateMediaType(config.MediaType, config.Builder, config.Squash, config.Annotations, config.Option)...)
+	if !config.Services.IsNull() && !config.Services.IsUnknown() && len(config.Services.Elements()) == 0 {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("services"),
+			"No services to build",
+			"services must list at least one service of compose_file.",
+		)
+	}
+}
+
+// serviceImages returns the image URIs in services and the service names in sorted order, which is the build order.
+func serviceImages(ctx context.Context, model *ComposeProjectResourceModel) (map[string]string, []string, error) {
+	images := map[string]string{}
+	if diags := model.Services.ElementsAs(ctx, &images, false); diags.HasError() {
+		return nil, nil, errors.New("invalid services")
+	}
+	names := make([]string, 0, len(images))
+	for name := range images {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return images, names, nil
+}
+
+// buildContextHash returns the context_hash of model: a hash of the build contexts of all services and of watch_paths,
+// null when they are all remote without watch_paths, and unknown while the compose file or the services are unknown.
+func (r *ComposeProjectResource) buildContextHash(ctx context.Context, model *ComposeProjectResourceModel) (types.String, error) {
+	if model.ComposeFile.IsUnknown() || model.Services.IsUnknown() || model.EnvFile.IsUnknown() || model.Environment.IsUnknown() {
+		return types.StringUnknown(), nil
+	}
+	_, names, err := serviceImages(ctx, model)
+	if err != nil {
+		return types.StringUnknown(), err
+	}
+	envFiles, environment, err := buildEnvironment(ctx, model.EnvFile, model.Environment)
+	if err != nil {
+		return types.StringUnknown(), err
+	}
+	project, err := loadComposeProject(ctx, model.ComposeFile.ValueString(), envFiles, environment)
+	if err != nil {
+		return types.StringUnknown(), err
+	}
+	hashes := make(map[string]string, len(names))
+	for _, name := range names {
+		buildSpec, err := composeServiceBuild(project, model.ComposeFile.ValueString(), name)
+		if err != nil {
+			return types.StringUnknown(), err
+		}
+		if hashes[name], err = contextHash(buildSpec); err != nil {
+			return types.StringUnknown(), fmt.Errorf("service %s: %w", name, err)
+		}
+	}
+	return withWatchPaths(ctx, combineContextHashes(hashes), model.WatchPaths)
+}
+
+// ModifyPlan recomputes context_hash from the build contexts of the services, planning a rebuild when the files changed.
+func (r *ComposeProjectResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to build when the resource is destroyed
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	var model ComposeProjectResourceModel
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("compose_file"), &model.ComposeFile)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("services"), &model.Services)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("env_file"), &model.EnvFile)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("environment"), &model.Environment)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("watch_paths"), &model.WatchPaths)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("builder"), &model.Builder)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	hash, err := r.buildContextHash(ctx, &model)
+	if !checkContextHashError(ctx, resp, err) {
+		return
+	}
+	rebuilt := map[string]attr.Value{
+		"images":                 types.MapUnknown(composeProjectImageType),
+		"build_duration_seconds": types.Float64Unknown(),
+		"push_duration_seconds":  types.Float64Unknown(),
+		"pushed_bytes":           types.Int64Unknown(),
+	}
+	planContextHash(ctx, req, resp, hash, rebuilt)
+	planDriftRebuild(ctx, req, resp, rebuilt)
+	planAlwaysRebuild(ctx, req, resp, rebuilt)
+	planMissingImages(ctx, req, resp, rebuilt)
+	r.compose.planDockerDaemonCheck(ctx, req, resp, &ComposeResourceModel{Builder: model.Builder}, "images")
+}
+
+// toComposeModel returns the equivalent containerregistry_compose model building service to imageURI.
+func (r *ComposeProjectResource) toComposeModel(model *ComposeProjectResourceModel, service, imageURI string) *ComposeResourceModel {
+	return &ComposeResourceModel{
+		ID:                 types.StringValue(imageURI),
+		ImageURI:           types.StringValue(imageURI),
+		ComposeFile:        model.ComposeFile,
+		Service:            types.StringValue(service),
+		Environment:        model.Environment,
+		EnvFile:            model.EnvFile,
+		Builder:            model.Builder,
+		Platform:           model.Platform,
+		FrontendImage:      model.FrontendImage,
+		CacheFromPrevious:  model.CacheFromPrevious,
+		Squash:             model.Squash,
+		AdditionalTags:     model.AdditionalTags,
+		DirectPush:         model.DirectPush,
+		PushByDigest:       model.PushByDigest,
+		OnTagConflict:      model.OnTagConflict,
+		Offline:            model.Offline,
+		DryRun:             model.DryRun,
+		Timeouts:           model.Timeouts,
+		CreateRepository:   model.CreateRepository,
+		Test:               model.Test,
+		OnPush:             model.OnPush,
+		Option:             model.Option,
+		Labels:             model.Labels,
+		OCILabels:          model.OCILabels,
+		Annotations:        model.Annotations,
+		MediaType:          model.MediaType,
+		WaitForScan:        model.WaitForScan,
+		FailOnSeverity:     model.FailOnSeverity,
+		WaitForReplication: model.WaitForReplication,
+		WaitForAvailable:   model.WaitForAvailable,
+		Triggers:           model.Triggers,
+		DeleteImage:        model.DeleteImage,
+		DeleteImageScope:   model.DeleteImageScope,
+		PruneLocal:         model.PruneLocal,
+		PruneLocalParents:  model.PruneLocalParents,
+	}
+}
+
+// buildAndPush builds and pushes the images of all services one after another, setting images in model.
+// previous holds the images pushed by the previous apply, and is empty when the resource is created.
+func (r *ComposeProjectResource) buildAndPush(ctx context.Context, model *ComposeProjectResourceModel, previous map[string]ComposeProjectImageModel) error {
+	images, names, err := serviceImages(ctx, model)
+	if err != nil {
+		return err
+	}
+
+	// Fail before building anything when a service is missing from the compose file
+	envFiles, environment, err := buildEnvironment(ctx, model.EnvFile, model.Environment)
+	if err != nil {
+		return err
+	}
+	project, err := loadComposeProject(ctx, model.ComposeFile.ValueString(), envFiles, environment)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if _, err := composeServiceBuild(project, model.ComposeFile.ValueString(), name); err != nil {
+			return err
+		}
+	}
+
+	pushed := make(map[string]ComposeProjectImageModel, len(names))
+	var total buildMetrics
+	// The images pushed so far are recorded even when a service fails, so that Terraform keeps track of them
+	record := func() error {
+		imagesValue, diags := types.MapValueFrom(ctx, composeProjectImageType, pushed)
+		if diags.HasError() {
+			return errors.New("failed to set images")
+		}
+		model.Images = imagesValue
+		model.BuildDuration, model.PushDuration, model.PushedBytes = metricsValues(&total)
+		return nil
+	}
+	var pending []string
+	for _, name := range names {
+		composeModel := r.toComposeModel(model, name, images[name])
+		tflog.Info(ctx, "Building compose service", map[string]interface{}{
+			"service":   name,
+			"image_uri": images[name],
+		})
+
+		var metrics buildMetrics
+		previousImage := previousImageRef(previous[name].ImageURI.ValueString(), previous[name].SHA256Digest.ValueString())
+		lastBuildLines, err := r.compose.buildAndPushImage(ctx, composeModel, previousImage, &metrics)
+		if err != nil && !errors.Is(err, errDigestPending) && !imagePushed(composeModel.PushedImageURI) {
+			err = fmt.Errorf("service %s (%s): %w", name, images[name], err)
+			if len(lastBuildLines) > 0 {
+				err = fmt.Errorf("%w\n\nLast build log lines:\n%s", err, strings.Join(lastBuildLines, "\n"))
+			}
+			return errors.Join(err, record())
+		}
+		// image_uri is the reference the image was pushed as, e.g. with a suffixed tag after a tag conflict,
+		// and sha256_digest is null for an image pushed without its digest, which the next refresh reads
+		pushed[name] = ComposeProjectImageModel{
+			ImageURI:     composeModel.PushedImageURI,
+			SHA256Digest: composeModel.SHA256Digest,
+		}
+		total.add(metrics)
+		if errors.Is(err, errDigestPending) {
+			pending = append(pending, fmt.Sprintf("%s (%s)", name, composeModel.PushedImageURI.ValueString()))
+			continue
+		}
+		if err != nil {
+			// A step following the push failed
+			return errors.Join(fmt.Errorf("service %s (%s): %w", name, images[name], err), record())
+		}
+
+		if err := r.compose.writeApplySummary(ctx, composeModel, &metrics); err != nil {
+			tflog.Warn(ctx, "Error writing apply summary", map[string]any{
+				"error": err.Error(),
+			})
+		}
+	}
+
+	if err := record(); err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("services %s: %w", strings.Join(pending, ", "), errDigestPending)
+	}
+	return nil
+}
+
+// imagesRecorded reports whether buildAndPush recorded pushed images in model, which is saved in the state along with its error.
+func imagesRecorded(model *ComposeProjectResourceModel) bool {
+	return !model.Images.IsUnknown() && !model.Images.IsNull() && len(model.Images.Elements()) > 0
+}
+
+// warnPendingDigests warns that images of services were pushed without their digests, which the next refresh reads.
+// err is the error of buildAndPush naming the services.
+func warnPendingDigests(ctx context.Context, diags *diag.Diagnostics, err error) {
+	tflog.Warn(ctx, "Images pushed without their digests", map[string]interface{}{
+		"error": err.Error(),
+	})
+	diags.AddWarning(
+		"Image digest not recorded",
+		fmt.Sprintf("%s\n\n"+
+			"sha256_digest of these images is null until the next refresh reads the digests; the images are not built again. "+
+			"Steps after the push (e.g. additional_tags, wait_for_scan and on_push) were skipped.", err),
+	)
+}
+
+// planMissingImages plans a rebuild when images of the state lacks a service of the plan, left unbuilt by a failed apply,
+// by setting the computed attributes written by the build to the unknown values of rebuilt.
+func planMissingImages(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse, rebuilt map[string]attr.Value) {
+	if req.State.Raw.IsNull() {
+		return
+	}
+	var services, images types.Map
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("services"), &services)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("images"), &images)...)
+	if resp.Diagnostics.HasError() || services.IsUnknown() || images.IsNull() || images.IsUnknown() {
+		return
+	}
+	for name := range services.Elements() {
+		if _, ok := images.Elements()[name]; ok {
+			continue
+		}
+		tflog.Info(ctx, "Service not pushed by the last apply: planning a rebuild", map[string]interface{}{
+			"service": name,
+		})
+		for name, unknown := range rebuilt {
+			resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root(name), unknown)...)
+		}
+		return
+	}
+}
+
+// Create builds and pushes the images and sets the initial Terraform state.
+func (r *ComposeProjectResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	// Initialize the HTTP logging subsystem and header masking for this request.
+	ctx = logging.WithHTTPLoggingSubsystem(ctx)
+
+	var plan ComposeProjectResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Info(ctx, "Creating container registry images from compose file", map[string]interface{}{
+		"compose_file": plan.ComposeFile.ValueString(),
+	})
+
+	plan.ID = types.StringValue(generateUUID())
+	ctx, cancel, err := withTimeout(ctx, plan.Timeouts.createTimeout())
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid timeout", err.Error())
+		return
+	}
+	defer cancel()
+
+	plan.ContextHash = resolveContextHash(ctx, plan.ContextHash, func() (types.String, error) {
+		return r.buildContextHash(ctx, &plan)
+	})
+	err = r.buildAndPush(ctx, &plan, nil)
+	if errors.Is(err, errDigestPending) {
+		warnPendingDigests(ctx, &resp.Diagnostics, err)
+	} else if err != nil {
+		err = timeoutError(ctx, plan.Timeouts.createTimeout(), err)
+		resp.Diagnostics.AddError(
+			"Error building and pushing images",
+			fmt.Sprintf("Could not build and push images of %s: %s", plan.ComposeFile.ValueString(), err),
+		)
+		// The images pushed before the failure are recorded, and Terraform taints the resource
+		if !imagesRecorded(&plan) {
+			return
+		}
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+// Read refreshes the digests of the images in the registry.
+// The resource is removed from the state when any image is missing, so that all images are rebuilt.
+func (r *ComposeProjectResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// Initialize the HTTP logging subsystem and header masking for this request.
+	ctx = logging.WithHTTPLoggingSubsystem(ctx)
+
+	var state ComposeProjectResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Images only built with dry_run have nothing to refresh from the registry
+	if state.DryRun.ValueBool() {
+		return
+	}
+
+	images := map[string]ComposeProjectImageModel{}
+	if !state.Images.IsNull() && !state.Images.IsUnknown() {
+		resp.Diagnostics.Append(state.Images.ElementsAs(ctx, &images, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+	names := make([]string, 0, len(images))
+	for name := range images {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var drifts []imageDrift
+	for _, name := range names {
+		image := images[name]
+		imageURI := registryImageURI(&ComposeResourceModel{
+			ImageURI:     image.ImageURI,
+			SHA256Digest: image.SHA256Digest,
+			PushByDigest: state.PushByDigest,
+		})
+		imageInfo, err := r.compose.getImageInfoFromRegistry(ctx, &ComposeResourceModel{
+			ImageURI: types.StringValue(imageURI),
+		})
+		if err != nil {
+			// Keep the state unless the registry tells that the image is gone
+			if !registryclient.IsNotFound(err) {
+				resp.Diagnostics.AddError(
+					"Error reading image",
+					fmt.Sprintf("Could not read image %s of service %s from the registry: %s", image.ImageURI.ValueString(), name, err),
+				)
+				return
+			}
+			tflog.Warn(ctx, "Image not found in registry", map[string]interface{}{
+				"service":   name,
+				"image_uri": image.ImageURI.ValueString(),
+				"error":     err.Error(),
+			})
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		if drift, ok := detectDrift(imageURI, image.SHA256Digest, imageInfo.ManifestDigest); ok {
+			drifts = append(drifts, drift)
+		}
+		if imageInfo.ManifestDigest != "" {
+			// The digest of an image pushed without it is now recorded
+			if image.SHA256Digest.IsNull() {
+				tflog.Info(ctx, "Read the digest of the image pushed by the last apply", map[string]interface{}{
+					"service":   name,
+					"image_uri": imageURI,
+					"digest":    imageInfo.ManifestDigest,
+				})
+			}
+			image.SHA256Digest = types.StringValue(imageInfo.ManifestDigest)
+			images[name] = image
+		}
+	}
+
+	// Other images may have been pushed to the tags out of band
+	adopt, diags := applyOnDrift(ctx, state.OnDrift, drifts, resp.Private)
+	resp.Diagnostics.Append(diags...)
+	if !adopt || resp.Diagnostics.HasError() {
+		return
+	}
+
+	imagesValue, diags := types.MapValueFrom(ctx, composeProjectImageType, images)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	state.Images = imagesValue
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+// Update rebuilds and pushes the images and sets the updated Terraform state on success.
+func (r *ComposeProjectResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	// Initialize the HTTP logging subsystem and header masking for this request.
+	ctx = logging.WithHTTPLoggingSubsystem(ctx)
+
+	var plan, state ComposeProjectResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	previous := map[string]ComposeProjectImageModel{}
+	if !state.Images.IsNull() && !state.Images.IsUnknown() {
+		resp.Diagnostics.Append(state.Images.ElementsAs(ctx, &previous, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	tflog.Info(ctx, "Updating container registry images from compose file", map[string]interface{}{
+		"compose_file": plan.ComposeFile.ValueString(),
+	})
+
+	// Only attributes not affecting the images changed: the plan keeps the images pushed by the previous apply
+	if !plan.Images.IsUnknown() {
+		resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+		return
+	}
+
+	ctx, cancel, err := withTimeout(ctx, plan.Timeouts.updateTimeout())
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid timeout", err.Error())
+		return
+	}
+	defer cancel()
+
+	plan.ContextHash = resolveContextHash(ctx, plan.ContextHash, func() (types.String, error) {
+		return r.buildContextHash(ctx, &plan)
+	})
+	err = r.buildAndPush(ctx, &plan, previous)
+	if errors.Is(err, errDigestPending) {
+		warnPendingDigests(ctx, &resp.Diagnostics, err)
+	} else if err != nil {
+		err = timeoutError(ctx, plan.Timeouts.updateTimeout(), err)
+		resp.Diagnostics.AddError(
+			"Error building and pushing images",
+			fmt.Sprintf("Could not build and push images of %s: %s", plan.ComposeFile.ValueString(), err),
+		)
+		// The images pushed before the failure are recorded; the services left out are built by the next apply
+		if !imagesRecorded(&plan) {
+			return
+		}
+	}
+
+	// The images are pushed again over drifted tags
+	resp.Diagnostics.Append(resp.Private.SetKey(ctx, driftPrivateKey, nil)...)
+
+	// The tags of services changed in place; the previous tags are kept until the digests of the new images are known
+	if !state.DryRun.ValueBool() && !resp.Diagnostics.HasError() {
+		pushed := map[string]ComposeProjectImageModel{}
+		resp.Diagnostics.Append(plan.Images.ElementsAs(ctx, &pushed, false)...)
+		for name, image := range pushed {
+			tag := previousTag(plan.DeletePreviousTag, previous[name].ImageURI.ValueString(), image.ImageURI.ValueString())
+			if tag == "" || image.SHA256Digest.IsNull() {
+				continue
+			}
+			if err := r.compose.deletePreviousTag(ctx, tag, image.SHA256Digest.ValueString()); err != nil {
+				resp.Diagnostics.AddWarning("Error deleting previous tag", fmt.Sprintf("service %s: %s", name, err))
+			}
+		}
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+// Delete deletes the images from the registry when delete_image is set.
+func (r *ComposeProjectResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	// Initialize the HTTP logging subsystem and header masking for this request.
+	ctx = logging.WithHTTPLoggingSubsystem(ctx)
+
+	var state ComposeProjectResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, err := withTimeout(ctx, state.Timeouts.deleteTimeout())
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid timeout", err.Error())
+		return
+	}
+	defer cancel()
+
+	images, names, err := serviceImages(ctx, &state)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid state", err.Error())
+		return
+	}
+	pushed := map[string]ComposeProjectImageModel{}
+	if !state.Images.IsNull() && !state.Images.IsUnknown() {
+		resp.Diagnostics.Append(state.Images.ElementsAs(ctx, &pushed, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+	for _, name := range names {
+		// The compose resource only reads image_uri, sha256_digest, push_by_digest, pushed_image_uri, dry_run, delete_image
+		// and delete_image_scope from the state on deletion.
+		r.compose.deleteImage(ctx, &ComposeResourceModel{
+			ID:               types.StringValue(images[name]),
+			ImageURI:         types.StringValue(images[name]),
+			SHA256Digest:     pushed[name].SHA256Digest,
+			PushedImageURI:   pushed[name].ImageURI,
+			PushByDigest:     state.PushByDigest,
+			DryRun:           state.DryRun,
+			DeleteImage:      state.DeleteImage,
+			DeleteImageScope: state.DeleteImageScope,
+		}, resp)
+	}
+}
@@ -0,0 +1,94 @@
+package compose
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	composetypes "github.com/compose-spec/compose-go/v2/types"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	tfplugintypes "github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// annotationsAttribute returns the schema of the annotations attribute shared by the image resources.
+func annotationsAttribute() schema.MapAttribute {
+	return schema.MapAttribute{
+		MarkdownDescription: "Annotations added to the pushed image manifest, and to the image index as well for multi-platform images " +
+			"(e.g. `org.opencontainers.image.source`). Unlike `labels`, they are not part of the image config. " +
+			"BuildKit adds them while building, which requires the buildx plugin, and the image keeps them when pushed with `direct_push` " +
+			"or from a Docker daemon with the containerd image store. " +
+			"Cannot be used with `builder = \"classic\"`, `\"kaniko\"` or `\"podman\"`, `squash`, or an existing image.",
+		Optional:    true,
+		ElementType: tfplugintypes.StringType,
+	}
+}
+
+// validateAnnotations reports annotation keys that BuildKit cannot parse, and the attributes that cannot be used with annotations,
+// as they build without BuildKit or rewrite the built image.
+func validateAnnotations(annotations tfplugintypes.Map, builder tfplugintypes.String, squash tfplugintypes.Bool) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if annotations.IsNull() || annotations.IsUnknown() {
+		return diags
+	}
+	for key := range annotations.Elements() {
+		if key == "" || strings.ContainsAny(key, "=,") {
+			diags.AddAttributeError(
+				path.Root("annotations"),
+				"Invalid annotation",
+				fmt.Sprintf("annotation key %q must not be empty or contain \"=\" or \",\".", key),
+			)
+		}
+	}
+	if b := builder.ValueString(); b == builderClassic || b == builderPodman || b == builderKaniko {
+		diags.AddAttributeError(
+			path.Root("annotations"),
+			"Annotations require BuildKit",
+			fmt.Sprintf("annotations cannot be used with builder = %q.", b),
+		)
+	}
+	if squash.ValueBool() {
+		diags.AddAttributeError(
+			path.Root("annotations"),
+			"Annotations not supported",
+			"squash rebuilds the image manifest and cannot be used with annotations.",
+		)
+	}
+	return diags
+}
+
+// extractAnnotations returns the annotations of model.
+func extractAnnotations(model *ComposeResourceModel) map[string]string {
+	annotations := make(map[string]string)
+	if model.Annotations.IsNull() || model.Annotations.IsUnknown() {
+		return annotations
+	}
+	for k, v := range model.Annotations.Elements() {
+		if strVal, ok := v.(tfplugintypes.String); ok {
+			annotations[k] = strVal.ValueString()
+		}
+	}
+	return annotations
+}
+
+// bakeAnnotations returns the annotations of model in the format of buildx bake, in the order of their keys:
+// they apply to the image manifests, and to the image index as well when buildSpec builds several platforms.
+// BuildKit rejects index annotations for a single-platform image.
+func bakeAnnotations(buildSpec *composetypes.BuildConfig, model *ComposeResourceModel) []string {
+	annotations := extractAnnotations(model)
+	keys := make([]string, 0, len(annotations))
+	for key := range annotations {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	kinds := "manifest"
+	if len(buildSpec.Platforms) > 1 {
+		kinds = "index,manifest"
+	}
+	result := make([]string, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, kinds+":"+key+"="+annotations[key])
+	}
+	return result
+}
@@ -0,0 +1,106 @@
+package compose
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// validateAttestation reports an option value (option.provenance or option.sbom) that is neither a boolean nor
+// a list of attestation parameters such as "mode=max".
+func validateAttestation(name string, value types.String) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if value.IsNull() || value.IsUnknown() {
+		return diags
+	}
+	if err := parseAttestation(value.ValueString()); err != nil {
+		diags.AddAttributeError(path.Root("option").AtName(name), "Invalid "+name, err.Error())
+	}
+	return diags
+}
+
+// parseAttestation checks an attestation option: "true", "false" or comma-separated key=value parameters,
+// where mode, when present, must be min or max.
+func parseAttestation(value string) error {
+	if value == "true" || value == "false" {
+		return nil
+	}
+	for _, field := range strings.Split(value, ",") {
+		key, v, ok := strings.Cut(field, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("must be true, false or key=value parameters (e.g. mode=max), but got %q", value)
+		}
+		if key == "mode" && v != "min" && v != "max" {
+			return fmt.Errorf("mode must be min or max, but got %q", v)
+		}
+	}
+	return nil
+}
+
+// checkAttestations returns an error when attestations are requested but cannot be generated
+// because the classic builder is used.
+func checkAttestations(dockerCli command.Cli, option *OptionModel) error {
+	if !attestationsRequested(option) {
+		return nil
+	}
+	buildkit, err := dockerCli.BuildKitEnabled()
+	if err != nil {
+		return fmt.Errorf("failed to determine whether BuildKit is enabled: %w", err)
+	}
+	if !buildkit {
+		return fmt.Errorf("option.provenance and option.sbom require BuildKit, but the classic builder is used")
+	}
+	return nil
+}
+
+// attestationsRequested reports whether option requests provenance or SBOM attestations.
+func attestationsRequested(option *OptionModel) bool {
+	if option == nil {
+		return false
+	}
+	for _, value := range []types.String{option.Provenance, option.SBOM} {
+		if v := value.ValueString(); v != "" && v != "false" {
+			return true
+		}
+	}
+	return false
+}
+
+// recordAttestations sets attestation_digests to the attestation manifests pushed with image_uri
+// when option requests attestations.
+func (r *ComposeResource) recordAttestations(ctx context.Context, model *ComposeResourceModel) error {
+	if !attestationsRequested(model.Option) {
+		return nil
+	}
+	host, repository, _, err := registryclient.ParseImageReference(model.ImageURI.ValueString())
+	if err != nil {
+		return err
+	}
+	c, err := registryclient.New(r.providerConfig, host)
+	if err != nil {
+		return err
+	}
+	attestations, err := c.ListAttestations(ctx, repository, model.SHA256Digest.ValueString())
+	if err != nil {
+		return fmt.Errorf("failed to get attestations after push: %w", err)
+	}
+	if len(attestations) == 0 {
+		tflog.Warn(ctx, "No attestation was pushed: the Docker daemon may not use the containerd image store", map[string]interface{}{
+			"image_uri": model.ImageURI.ValueString(),
+		})
+	}
+	value, diags := types.MapValueFrom(ctx, types.StringType, attestations)
+	if diags.HasError() {
+		return fmt.Errorf("failed to record attestation digests")
+	}
+	model.AttestationDigests = value
+	return nil
+}
@@ -0,0 +1,93 @@
+package compose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	tfplugintypes "github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// availablePollInterval is the interval between checks of the pushed image with wait_for_available.
+const availablePollInterval = 2 * time.Second
+
+// waitForAvailableAttribute returns the schema of the wait_for_available attribute shared by the image resources.
+func waitForAvailableAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		MarkdownDescription: "Maximum time to wait after the push until `pushed_image_uri` resolves to `sha256_digest` in the registry, " +
+			"as a Go duration (e.g. `2m`), for eventually consistent registries (multi-region Artifact Registry, caching proxies) " +
+			"that may serve the previous image or nothing for a while, which would make deployments right after the apply fail. " +
+			"The apply fails when the image is not available in time. Omit to not wait.",
+		Optional: true,
+	}
+}
+
+// validateWaitForAvailable reports a wait_for_available value that is not a valid duration.
+func validateWaitForAvailable(waitForAvailable tfplugintypes.String) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if _, err := parseTimeout(waitForAvailable); err != nil {
+		diags.AddAttributeError(path.Root("wait_for_available"), "Invalid wait_for_available", err.Error())
+	}
+	return diags
+}
+
+// waitForAvailable polls the registry until the reference of the pushed image of model resolves to sha256_digest,
+// for up to wait_for_available.
+func (r *ComposeResource) waitForAvailable(ctx context.Context, model *ComposeResourceModel) error {
+	limit, err := parseTimeout(model.WaitForAvailable)
+	if err != nil || limit == 0 {
+		return err
+	}
+	imageURI := registryImageURI(model)
+	digest := model.SHA256Digest.ValueString()
+	host, repository, ref, err := registryclient.ParseImageReference(imageURI)
+	if err != nil {
+		return err
+	}
+	c, err := registryclient.New(r.providerConfig, host)
+	if err != nil {
+		return err
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, limit)
+	defer cancel()
+	started := time.Now()
+	for {
+		remote, err := c.ResolveDigest(waitCtx, repository, ref)
+		switch {
+		case err == nil && remote == digest:
+			tflog.Info(ctx, "Pushed image is available in the registry", map[string]interface{}{
+				"image_uri": imageURI,
+				"digest":    digest,
+				"waited":    time.Since(started).String(),
+			})
+			return nil
+		case err == nil:
+			tflog.Debug(ctx, "Registry still serves another image", map[string]interface{}{
+				"image_uri": imageURI,
+				"digest":    remote,
+			})
+		case registryclient.IsNotFound(err):
+			tflog.Debug(ctx, "Pushed image is not available yet", map[string]interface{}{
+				"image_uri": imageURI,
+			})
+		case waitCtx.Err() == nil:
+			return fmt.Errorf("failed to resolve pushed image %s: %w", imageURI, err)
+		}
+		select {
+		case <-waitCtx.Done():
+			if ctx.Err() == nil && errors.Is(waitCtx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("%s did not resolve to %s in the registry within %s (wait_for_available)", imageURI, digest, model.WaitForAvailable.ValueString())
+			}
+			return ctx.Err()
+		case <-time.After(availablePollInterval):
+		}
+	}
+}
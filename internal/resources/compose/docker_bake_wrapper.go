@@ -0,0 +1,230 @@
+package compose
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/docker/cli/cli-plugins/manager"
+	"github.com/docker/cli/cli/command"
+	"github.com/spf13/cobra"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/providerconfig"
+)
+
+// bakeWrapper describes what a buildx plugin wrapper changes in the buildx bake run by compose,
+// for the options that compose does not pass to bake itself.
+type bakeWrapper struct {
+	// allow lists the entitlements granted to bake with --allow.
+	allow []string
+	// captureMetadata keeps a copy of the build result metadata written by bake, which compose removes after the build.
+	captureMetadata bool
+	// registryAuth holds credentials added to the Docker configuration bake authenticates to registries with.
+	registryAuth map[string]providerconfig.RegistryAuthCredentials
+	// annotations lists the annotations added to the built image, in the format of buildx bake.
+	annotations []string
+	// output replaces the output compose passes to bake, when not empty.
+	output string
+	// noProvenance disables the provenance attestation buildx adds by default.
+	noProvenance bool
+
+	// metadataFile is where the copy of the build result metadata is written, set by install.
+	metadataFile string
+}
+
+// install puts a buildx plugin wrapper applying w ahead of the buildx plugin in the plugin directories of dockerCli.
+// The returned cleanup function restores the plugin directories and removes the wrapper.
+func (w *bakeWrapper) install(dockerCli command.Cli) (func(), error) {
+	cleanup := func() {}
+	if len(w.allow) == 0 && !w.captureMetadata && len(w.registryAuth) == 0 && len(w.annotations) == 0 && w.output == "" && !w.noProvenance {
+		return cleanup, nil
+	}
+	if runtime.GOOS == "windows" {
+		return cleanup, errors.New("wrapping buildx bake (for build.entitlements, direct_push, annotations or media_type) is not supported on Windows")
+	}
+	plugin, err := manager.GetPlugin("buildx", dockerCli, &cobra.Command{})
+	if err == nil {
+		err = plugin.Err
+	}
+	if err != nil {
+		return cleanup, fmt.Errorf("the buildx plugin is required: %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", "containerregistry-plugins-")
+	if err != nil {
+		return cleanup, fmt.Errorf("failed to create directory for the buildx plugin: %w", err)
+	}
+	pluginDir := filepath.Join(dir, "cli-plugins")
+	if err := os.Mkdir(pluginDir, 0700); err != nil {
+		_ = os.RemoveAll(dir)
+		return cleanup, fmt.Errorf("failed to create directory for the buildx plugin: %w", err)
+	}
+
+	var script strings.Builder
+	script.WriteString("#!/bin/sh\nif [ \"$1\" = bake ]; then\n  shift\n")
+	if len(w.registryAuth) > 0 {
+		configDir := filepath.Join(dir, "config")
+		if err := writeBakeDockerConfig(dockerCli, configDir, w.registryAuth); err != nil {
+			_ = os.RemoveAll(dir)
+			return cleanup, err
+		}
+		script.WriteString("  DOCKER_CONFIG=" + shellQuote(configDir) + "\n  export DOCKER_CONFIG\n")
+	}
+	bake := shellQuote(plugin.Path) + " bake"
+	for _, entitlement := range w.allow {
+		bake += " --allow=" + entitlement
+	}
+	for _, annotation := range w.annotations {
+		bake += " --set " + shellQuote("*.annotations="+annotation)
+	}
+	if w.output != "" {
+		bake += " --set " + shellQuote("*.output="+w.output)
+	}
+	if w.noProvenance {
+		bake += " --provenance=false"
+	}
+	if w.captureMetadata {
+		// Compose passes --metadata-file and removes the file as soon as bake exits
+		w.metadataFile = filepath.Join(dir, "metadata.json")
+		script.WriteString("  metadata=\n  previous=\n" +
+			"  for arg in \"$@\"; do\n" +
+			"    if [ \"$previous\" = --metadata-file ]; then metadata=$arg; fi\n" +
+			"    previous=$arg\n" +
+			"  done\n" +
+			"  " + bake + " \"$@\"\n" +
+			"  status=$?\n" +
+			"  if [ -n \"$metadata\" ] && [ -f \"$metadata\" ]; then cp \"$metadata\" " + shellQuote(w.metadataFile) + "; fi\n" +
+			"  exit $status\n")
+	} else {
+		script.WriteString("  exec " + bake + " \"$@\"\n")
+	}
+	script.WriteString("fi\nexec " + shellQuote(plugin.Path) + " \"$@\"\n")
+	if err := os.WriteFile(filepath.Join(pluginDir, filepath.Base(plugin.Path)), []byte(script.String()), 0755); err != nil {
+		_ = os.RemoveAll(dir)
+		return cleanup, fmt.Errorf("failed to write the buildx plugin: %w", err)
+	}
+
+	configFile := dockerCli.ConfigFile()
+	extraDirs := configFile.CLIPluginsExtraDirs
+	configFile.CLIPluginsExtraDirs = append([]string{pluginDir}, extraDirs...)
+	return func() {
+		configFile.CLIPluginsExtraDirs = extraDirs
+		_ = os.RemoveAll(dir)
+	}, nil
+}
+
+// checkBake verifies that compose builds with buildx bake, which is what the wrapper applies attribute to.
+func checkBake(dockerCli command.Cli, attribute string) error {
+	buildkit, err := dockerCli.BuildKitEnabled()
+	if err != nil {
+		return fmt.Errorf("failed to determine whether BuildKit is enabled: %w", err)
+	}
+	if !buildkit {
+		return fmt.Errorf("%s requires BuildKit, but the classic builder is used: set builder = \"buildkit\"", attribute)
+	}
+	plugin, err := manager.GetPlugin("buildx", dockerCli, &cobra.Command{})
+	if err == nil {
+		err = plugin.Err
+	}
+	if err != nil {
+		return fmt.Errorf("%s requires the buildx plugin: %w", attribute, err)
+	}
+	return nil
+}
+
+// writeBakeDockerConfig writes into dir the Docker configuration of dockerCli with the credentials registryAuth,
+// linking the other entries of the configuration directory (buildx builders, contexts) as they are.
+// Registries that are logged in through the credentials store keep it as their credential helper,
+// as a credentials store would otherwise take precedence over the added credentials.
+func writeBakeDockerConfig(dockerCli command.Cli, dir string, registryAuth map[string]providerconfig.RegistryAuthCredentials) error {
+	if err := os.Mkdir(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create Docker config directory: %w", err)
+	}
+	config := map[string]json.RawMessage{}
+	if filename := dockerCli.ConfigFile().Filename; filename != "" {
+		entries, err := os.ReadDir(filepath.Dir(filename))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to read Docker config directory: %w", err)
+		}
+		for _, entry := range entries {
+			if entry.Name() == filepath.Base(filename) {
+				continue
+			}
+			if err := os.Symlink(filepath.Join(filepath.Dir(filename), entry.Name()), filepath.Join(dir, entry.Name())); err != nil {
+				return fmt.Errorf("failed to link Docker config: %w", err)
+			}
+		}
+		body, err := os.ReadFile(filename)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to read Docker config: %w", err)
+		}
+		if len(body) > 0 {
+			if err := json.Unmarshal(body, &config); err != nil {
+				return fmt.Errorf("failed to decode Docker config: %w", err)
+			}
+		}
+	}
+
+	auths := map[string]json.RawMessage{}
+	credHelpers := map[string]string{}
+	var credsStore string
+	for key, value := range map[string]any{"auths": &auths, "credHelpers": &credHelpers, "credsStore": &credsStore} {
+		if body, ok := config[key]; ok {
+			if err := json.Unmarshal(body, value); err != nil {
+				return fmt.Errorf("failed to decode %s in Docker config: %w", key, err)
+			}
+		}
+	}
+	if credsStore != "" {
+		for host := range auths {
+			if _, ok := credHelpers[host]; !ok {
+				credHelpers[host] = credsStore
+			}
+		}
+		delete(config, "credsStore")
+	}
+	for host, creds := range registryAuth {
+		if creds.Username == "" || creds.Password == "" {
+			return fmt.Errorf("registry_auth for %q has empty username or password", host)
+		}
+		key := host
+		if host == "docker.io" || host == "index.docker.io" {
+			key = dockerHubAuthKey
+		}
+		auth, err := json.Marshal(map[string]string{
+			"auth": base64.StdEncoding.EncodeToString([]byte(creds.Username + ":" + creds.Password)),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to encode Docker config: %w", err)
+		}
+		auths[key] = auth
+		delete(credHelpers, key)
+	}
+
+	var err error
+	if config["auths"], err = json.Marshal(auths); err != nil {
+		return fmt.Errorf("failed to encode Docker config: %w", err)
+	}
+	if config["credHelpers"], err = json.Marshal(credHelpers); err != nil {
+		return fmt.Errorf("failed to encode Docker config: %w", err)
+	}
+	body, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to encode Docker config: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), body, 0600); err != nil {
+		return fmt.Errorf("failed to write Docker config: %w", err)
+	}
+	return nil
+}
+
+// shellQuote quotes s as a single word for sh.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -6,22 +6,47 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 
+	"github.com/compose-spec/compose-go/v2/dotenv"
 	composeinterp "github.com/compose-spec/compose-go/v2/interpolation"
 	composetypes "github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/cli/cli/command"
 	"github.com/go-viper/mapstructure/v2"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
-// parseBuildSpec parses the build specification from the model
+// parseBuildSpec parses the build specification from the model, loading the build section of service
+// from compose_file when it is set.
 // This function mimics the behavior of docker compose's loader (loadYamlFile) by:
 // 1. Parsing JSON to map[string]any
 // 2. Performing variable interpolation (${VAR} expansion)
 // 3. Using mapstructure to decode to BuildConfig (which calls DecodeMapstructure for args)
-func (r *ComposeResource) parseBuildSpec(_ context.Context, model *ComposeResourceModel) (*composetypes.BuildConfig, error) {
+func (r *ComposeResource) parseBuildSpec(ctx context.Context, model *ComposeResourceModel) (*composetypes.BuildConfig, error) {
+	envFiles, environment, err := buildEnvironment(ctx, model.EnvFile, model.Environment)
+	if err != nil {
+		return nil, err
+	}
+	if !model.ComposeFile.IsNull() {
+		return loadComposeService(ctx, model.ComposeFile.ValueString(), model.Service.ValueString(), envFiles, environment)
+	}
+	lookupEnv, err := interpolationLookup(envFiles, environment)
+	if err != nil {
+		return nil, err
+	}
+
 	// The build attribute contains a Docker Compose compatible build specification in JSON format
-	buildJSON := model.Build.ValueString()
+	return decodeBuildSpec(model.Build.ValueString(), lookupEnv)
+}
+
+// decodeBuildSpec decodes the build specification in buildJSON, resolving variables with lookupEnv.
+func decodeBuildSpec(buildJSON string, lookupEnv func(string) (string, bool)) (*composetypes.BuildConfig, error) {
 	if buildJSON == "" {
 		return nil, errors.New("build specification is empty")
 	}
@@ -32,10 +57,15 @@ func (r *ComposeResource) parseBuildSpec(_ context.Context, model *ComposeResour
 		return nil, fmt.Errorf("invalid JSON in build specification: %w", err)
 	}
 
+	// cgroup_parent is a service field in the compose specification, not a build field, and no build backend applies it
+	if _, ok := raw["cgroup_parent"]; ok {
+		return nil, errors.New("cgroup_parent is not supported in the build specification: neither BuildKit nor the classic builder applies it through Compose")
+	}
+
 	// Step 2: Perform variable interpolation (${VAR} expansion)
-	// This uses os.LookupEnv by default to resolve environment variables
+	// Variables are resolved from environment, the environment variables and env_file, in this order
 	interpolated, err := composeinterp.Interpolate(raw, composeinterp.Options{
-		LookupValue: os.LookupEnv,
+		LookupValue: lookupEnv,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to interpolate variables in build specification: %w", err)
@@ -47,10 +77,20 @@ func (r *ComposeResource) parseBuildSpec(_ context.Context, model *ComposeResour
 	// The JSON is expected to be a direct BuildConfig structure (not nested under "build")
 	// Note: This is implemented in normalize.go in docker compose (not exported)
 	if args, ok := interpolated["args"]; ok {
-		resolvedArgs, _ := resolveBuildArgs(args, os.LookupEnv)
+		resolvedArgs, _ := resolveBuildArgs(args, lookupEnv)
 		interpolated["args"] = resolvedArgs
 	}
 
+	// Step 2.6: Normalize the list format of build.ssh (["default", "id=path"]) to the map format
+	// SSHConfig decodes, as the compose loader does in transform/ssh.go
+	if ssh, ok := interpolated["ssh"]; ok {
+		normalized, err := normalizeSSH(ssh)
+		if err != nil {
+			return nil, err
+		}
+		interpolated["ssh"] = normalized
+	}
+
 	// Step 3: Use mapstructure to decode to BuildConfig
 	// This ensures DecodeMapstructure is called for MappingWithEquals (args field),
 	// which supports both array format (["KEY=VALUE"]) and map format ({"KEY": "VALUE"})
@@ -68,10 +108,192 @@ func (r *ComposeResource) parseBuildSpec(_ context.Context, model *ComposeResour
 	if err := decoder.Decode(interpolated); err != nil {
 		return nil, fmt.Errorf("failed to decode build specification: %w", err)
 	}
+	if buildConfig.Dockerfile != "" && buildConfig.DockerfileInline != "" {
+		return nil, errors.New("dockerfile and dockerfile_inline cannot be specified together")
+	}
 
 	return &buildConfig, nil
 }
 
+// buildSpecKeys returns the keys of the compose build specification, from the json tags of BuildConfig.
+func buildSpecKeys() map[string]bool {
+	keys := map[string]bool{}
+	buildConfigType := reflect.TypeOf(composetypes.BuildConfig{})
+	for i := 0; i < buildConfigType.NumField(); i++ {
+		name, _, _ := strings.Cut(buildConfigType.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			keys[name] = true
+		}
+	}
+	return keys
+}
+
+// validateBuildSpec reports a build specification of model that is not a JSON object, has keys that are not in the
+// compose build specification, has values of the wrong types or uses fields the builder does not support,
+// so that it fails at plan time rather than in the middle of the apply.
+// Values are only checked when the variables for the interpolation are known.
+func validateBuildSpec(ctx context.Context, model *ComposeResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	build := model.Build
+	if build.IsNull() || build.IsUnknown() {
+		return diags
+	}
+	var raw map[string]any
+	if err := json.Unmarshal([]byte(build.ValueString()), &raw); err != nil || raw == nil {
+		detail := "build must be a JSON object with the build section of a compose service."
+		if err != nil {
+			detail = fmt.Sprintf("%s %s", detail, err)
+		}
+		diags.AddAttributeError(path.Root("build"), "Invalid build specification", detail)
+		return diags
+	}
+
+	keys := buildSpecKeys()
+	var unknown []string
+	for key := range raw {
+		// Extensions (x-*) are allowed as in compose files
+		if !keys[key] && !strings.HasPrefix(key, "x-") {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		detail := fmt.Sprintf("build.%s is not a field of the compose build specification.", key)
+		if key == "cgroup_parent" {
+			detail = "build.cgroup_parent is a field of compose services, not of the build specification: neither BuildKit nor the classic builder applies it through Compose."
+		}
+		diags.AddAttributeError(path.Root("build"), "Unsupported build field", detail)
+	}
+	if len(unknown) > 0 {
+		return diags
+	}
+
+	// The variables may only be known at apply time, and env_file may be written by another resource
+	if model.EnvFile.IsUnknown() || model.Environment.IsUnknown() {
+		return diags
+	}
+	envFiles, environment, err := buildEnvironment(ctx, model.EnvFile, model.Environment)
+	if err != nil {
+		return diags
+	}
+	lookupEnv, err := interpolationLookup(envFiles, environment)
+	if err != nil {
+		return diags
+	}
+	buildSpec, err := decodeBuildSpec(build.ValueString(), lookupEnv)
+	if err != nil {
+		diags.AddAttributeError(path.Root("build"), "Invalid build specification", err.Error())
+		return diags
+	}
+	if model.Builder.ValueString() == builderKaniko {
+		if err := checkKanikoBuild(buildSpec, model); err != nil {
+			diags.AddError("Build not supported by kaniko", err.Error())
+		}
+	}
+	return diags
+}
+
+// environmentAttribute returns the schema of the environment attribute shared by the resources building from compose specifications.
+func environmentAttribute() schema.MapAttribute {
+	return schema.MapAttribute{
+		MarkdownDescription: "Variables for `${VAR}` interpolation in the build specification, taking precedence over the environment variables and `env_file`. " +
+			"Also used for build args listed without a value.",
+		Optional:    true,
+		ElementType: types.StringType,
+	}
+}
+
+// envFileAttribute returns the schema of the env_file attribute shared by the resources building from compose specifications.
+func envFileAttribute() schema.ListAttribute {
+	return schema.ListAttribute{
+		MarkdownDescription: "Paths of env files (`KEY=VALUE` lines) providing variables for `${VAR}` interpolation in the build specification, " +
+			"as `docker compose --env-file` does. The environment variables take precedence over them. " +
+			"With `compose_file`, they replace the `.env` file next to it.",
+		Optional:    true,
+		ElementType: types.StringType,
+	}
+}
+
+// buildEnvironment returns the paths in env_file and the variables in environment.
+func buildEnvironment(ctx context.Context, envFile types.List, environment types.Map) ([]string, map[string]string, error) {
+	var files []string
+	if !envFile.IsNull() && !envFile.IsUnknown() {
+		if diags := envFile.ElementsAs(ctx, &files, false); diags.HasError() {
+			return nil, nil, errors.New("invalid env_file")
+		}
+	}
+	variables := map[string]string{}
+	if !environment.IsNull() && !environment.IsUnknown() {
+		if diags := environment.ElementsAs(ctx, &variables, false); diags.HasError() {
+			return nil, nil, errors.New("invalid environment")
+		}
+	}
+	return files, variables, nil
+}
+
+// interpolationLookup returns the lookup function for variables in the build specification.
+// As with `docker compose --env-file`, the environment variables take precedence over the variables in envFiles,
+// and variables set in environment take precedence over both.
+func interpolationLookup(envFiles []string, environment map[string]string) (func(string) (string, bool), error) {
+	fileEnv := map[string]string{}
+	if len(envFiles) > 0 {
+		current := composetypes.NewMapping(os.Environ())
+		var err error
+		if fileEnv, err = dotenv.GetEnvFromFile(current, envFiles); err != nil {
+			return nil, fmt.Errorf("failed to read env_file: %w", err)
+		}
+	}
+	return func(key string) (string, bool) {
+		if value, ok := environment[key]; ok {
+			return value, true
+		}
+		if value, ok := os.LookupEnv(key); ok {
+			return value, true
+		}
+		value, ok := fileEnv[key]
+		return value, ok
+	}, nil
+}
+
+// prepareDockerfileInline writes build.dockerfile_inline to a temporary Dockerfile and points build.dockerfile to it
+// when the classic builder is used, as compose only passes dockerfile_inline to BuildKit (buildx bake).
+// The returned cleanup function removes the temporary Dockerfile.
+func prepareDockerfileInline(dockerCli command.Cli, buildSpec *composetypes.BuildConfig) (func(), error) {
+	cleanup := func() {}
+	if buildSpec.DockerfileInline == "" {
+		return cleanup, nil
+	}
+	buildkit, err := dockerCli.BuildKitEnabled()
+	if err != nil {
+		return cleanup, fmt.Errorf("failed to determine whether BuildKit is enabled: %w", err)
+	}
+	if buildkit {
+		return cleanup, nil
+	}
+	return writeDockerfileInline(buildSpec)
+}
+
+// writeDockerfileInline writes build.dockerfile_inline to a temporary Dockerfile and points build.dockerfile to it,
+// for builders that only read the Dockerfile from a file. The returned cleanup function removes the temporary Dockerfile.
+func writeDockerfileInline(buildSpec *composetypes.BuildConfig) (func(), error) {
+	cleanup := func() {}
+	if buildSpec.DockerfileInline == "" {
+		return cleanup, nil
+	}
+	dir, err := os.MkdirTemp("", "containerregistry-dockerfile-")
+	if err != nil {
+		return cleanup, fmt.Errorf("failed to create directory for dockerfile_inline: %w", err)
+	}
+	dockerfile := filepath.Join(dir, "Dockerfile")
+	if err := os.WriteFile(dockerfile, []byte(buildSpec.DockerfileInline), 0644); err != nil {
+		_ = os.RemoveAll(dir)
+		return cleanup, fmt.Errorf("failed to write dockerfile_inline: %w", err)
+	}
+	buildSpec.Dockerfile = dockerfile
+	buildSpec.DockerfileInline = ""
+	return func() { _ = os.RemoveAll(dir) }, nil
+}
+
 // extractLabels extracts labels from the model
 func (r *ComposeResource) extractLabels(model *ComposeResourceModel) map[string]string {
 	labels := make(map[string]string)
@@ -138,3 +360,29 @@ func resolveBuildArgs(args any, lookupEnv func(string) (string, bool)) (any, boo
 		return args, false
 	}
 }
+
+// normalizeSSH converts build.ssh in list format (["default", "id=path"]) to map format ({"default": null, "id": "path"}).
+// Only "default" may omit the path, which means the SSH agent socket in SSH_AUTH_SOCK.
+func normalizeSSH(ssh any) (any, error) {
+	list, ok := ssh.([]any)
+	if !ok {
+		return ssh, nil
+	}
+	result := make(map[string]any, len(list))
+	for _, e := range list {
+		str, ok := e.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid build.ssh entry type %T", e)
+		}
+		id, path, ok := strings.Cut(str, "=")
+		if !ok {
+			if id != "default" {
+				return nil, fmt.Errorf("invalid build.ssh entry %q: expected default or id=path", str)
+			}
+			result[id] = nil
+			continue
+		}
+		result[id] = path
+	}
+	return result, nil
+}
@@ -0,0 +1,288 @@
+package compose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	composetypes "github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/cli/cli-plugins/manager"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/docker/client"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/spf13/cobra"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/buildx"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// Build backends accepted by the builder attribute.
+const (
+	// builderAuto lets Compose choose: BuildKit (through buildx bake) when buildx is available, the classic builder otherwise.
+	builderAuto = "auto"
+	// builderBuildKit always builds with BuildKit through buildx, failing when buildx is not available.
+	builderBuildKit = "buildkit"
+	// builderClassic always builds with the classic Docker Engine builder.
+	builderClassic = "classic"
+	// builderKaniko builds and pushes with the kaniko executor, without a Docker daemon.
+	builderKaniko = "kaniko"
+	// builderPodman builds with Buildah through the Docker-compatible API of Podman, without Docker.
+	builderPodman = "podman"
+)
+
+// builderAttribute returns the schema of the builder attribute shared by the image resources.
+func builderAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		MarkdownDescription: "Build backend. `auto` (default) builds with BuildKit through buildx when the buildx plugin is available " +
+			"and falls back to the classic builder otherwise, as `docker compose build` does. " +
+			"`buildkit` always builds with BuildKit (RUN --mount, heredocs, cache mounts) and fails when buildx is not available. " +
+			"`classic` always uses the classic Docker Engine builder. " +
+			"`kaniko` builds and pushes with the kaniko executor without a Docker daemon; it must run in a kaniko image or find `executor` in PATH. " +
+			"`podman` builds with Buildah through the Docker-compatible API socket of Podman (rootless or rootful) on hosts without Docker.",
+		Optional: true,
+	}
+}
+
+// validateBuilder reports an unsupported builder, and builder = "kaniko" when the build needs the image
+// in the local Docker daemon (localImage), e.g. for export, load_into or prune_local.
+func validateBuilder(builder types.String, localImage bool) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if builder.IsNull() || builder.IsUnknown() {
+		return diags
+	}
+	switch builder.ValueString() {
+	case builderAuto, builderBuildKit, builderClassic, builderPodman:
+	case builderKaniko:
+		if localImage {
+			diags.AddAttributeError(
+				path.Root("builder"),
+				"Local image required",
+				fmt.Sprintf("builder = %q pushes the image without a Docker daemon and cannot be used with export, load_into or prune_local.", builderKaniko),
+			)
+		}
+	default:
+		diags.AddAttributeError(
+			path.Root("builder"),
+			"Invalid builder",
+			fmt.Sprintf("builder must be %q, %q, %q, %q or %q.", builderAuto, builderBuildKit, builderClassic, builderKaniko, builderPodman),
+		)
+	}
+	return diags
+}
+
+// platformAttribute returns the schema of the platform attribute shared by the image resources.
+func platformAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		MarkdownDescription: "Target platform of the image in os/arch[/variant] form (e.g. `linux/arm64`), " +
+			"equivalent to a single entry in build.platforms. Images for another architecture than the host are built through emulation (binfmt). " +
+			"Requires BuildKit.",
+		Optional: true,
+	}
+}
+
+// validatePlatform reports a platform attribute that is not in os/arch[/variant] form.
+func validatePlatform(platform types.String) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if platform.IsNull() || platform.IsUnknown() {
+		return diags
+	}
+	if _, err := registryclient.ParsePlatform(platform.ValueString()); err != nil {
+		diags.AddAttributeError(path.Root("platform"), "Invalid platform", err.Error())
+	}
+	return diags
+}
+
+// optionAttribute returns the schema of the option attribute shared by the image resources.
+func optionAttribute() schema.SingleNestedAttribute {
+	return schema.SingleNestedAttribute{
+		MarkdownDescription: "Build options",
+		Optional:            true,
+		Attributes: map[string]schema.Attribute{
+			"pull": schema.BoolAttribute{
+				MarkdownDescription: "Always pull the latest version of the base images (equivalent to --pull). " +
+					"Default is false, which uses base images already present locally, e.g. in air-gapped environments.",
+				Optional: true,
+				Computed: true,
+				Default:  booldefault.StaticBool(false),
+			},
+			"no_cache": schema.BoolAttribute{
+				MarkdownDescription: "Do not use cache when building the image (equivalent to --no-cache). Default is false.",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"progress": schema.StringAttribute{
+				MarkdownDescription: "Progress output format of the build log (equivalent to --progress): `auto` (default), `plain`, `quiet` or `rawjson`. " +
+					"The build output is captured by the provider rather than written to a terminal, so `auto` renders as `plain` " +
+					"unless `BUILDKIT_PROGRESS` is set; `quiet` suppresses the build log.",
+				Optional: true,
+				Computed: true,
+				Default:  stringdefault.StaticString("auto"),
+			},
+			"provenance": schema.StringAttribute{
+				MarkdownDescription: "Provenance attestation (equivalent to --provenance): `true`, `false` or parameters such as `mode=min` or `mode=max`. " +
+					"Omit to keep the buildx default. Set `false` for registries that do not accept the attestation manifest in the pushed image index. " +
+					"Requires BuildKit.",
+				Optional: true,
+			},
+			"sbom": schema.StringAttribute{
+				MarkdownDescription: "SBOM attestation (equivalent to --sbom): `true`, `false` or parameters such as `generator=<image>`. " +
+					"The attestation is pushed with the image; see `attestation_digests`. Requires BuildKit.",
+				Optional: true,
+			},
+		},
+	}
+}
+
+// progressModes lists the option.progress values. tty is not accepted as the build output is never a terminal.
+var progressModes = []string{"auto", "plain", "quiet", "rawjson"}
+
+// validateOption reports an invalid option.progress, option.provenance or option.sbom.
+func validateOption(option *OptionModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if option == nil {
+		return diags
+	}
+	diags.Append(validateAttestation("provenance", option.Provenance)...)
+	diags.Append(validateAttestation("sbom", option.SBOM)...)
+	if option.Progress.IsNull() || option.Progress.IsUnknown() {
+		return diags
+	}
+	if !slices.Contains(progressModes, option.Progress.ValueString()) {
+		diags.AddAttributeError(
+			path.Root("option").AtName("progress"),
+			"Invalid progress mode",
+			fmt.Sprintf("option.progress must be one of %s, but got %q.", strings.Join(progressModes, ", "), option.Progress.ValueString()),
+		)
+	}
+	return diags
+}
+
+// builderCli overrides whether BuildKit is enabled, which Compose and the build checks query through command.Cli.
+type builderCli struct {
+	command.Cli
+	buildkit bool
+}
+
+// BuildKitEnabled returns the backend selected by the builder attribute instead of consulting DOCKER_BUILDKIT.
+func (c *builderCli) BuildKitEnabled() (bool, error) {
+	return c.buildkit, nil
+}
+
+// selectBuilder returns dockerCli adjusted to the builder attribute of model.
+// Compose silently falls back to the classic builder when buildx is missing, so builder = "buildkit"
+// checks for buildx up front to fail with an actionable error instead.
+func (r *ComposeResource) selectBuilder(dockerCli command.Cli, model *ComposeResourceModel) (command.Cli, error) {
+	switch model.Builder.ValueString() {
+	case "", builderAuto:
+		return dockerCli, nil
+	case builderClassic, builderPodman:
+		// Podman serves the classic build API of the Docker Engine, with Buildah behind it
+		return &builderCli{Cli: dockerCli, buildkit: false}, nil
+	case builderBuildKit:
+		plugin, err := manager.GetPlugin("buildx", dockerCli, &cobra.Command{})
+		if err == nil {
+			err = plugin.Err
+		}
+		if err != nil {
+			return nil, fmt.Errorf("builder = %q requires the buildx plugin (set buildx_install_if_missing = true in the provider to install it): %w", builderBuildKit, err)
+		}
+		return &builderCli{Cli: dockerCli, buildkit: true}, nil
+	default:
+		return nil, fmt.Errorf("unsupported builder %q: must be %s, %s, %s, %s or %s", model.Builder.ValueString(), builderAuto, builderBuildKit, builderClassic, builderKaniko, builderPodman)
+	}
+}
+
+// remoteBuilderName registers the provider remote_builder as a buildx builder and returns its name.
+// It returns an empty string when no remote builder is configured, leaving the builder selection to Compose.
+func (r *ComposeResource) remoteBuilderName(ctx context.Context, dockerCli command.Cli) (string, error) {
+	if r.providerConfig == nil || r.providerConfig.RemoteBuilder == nil {
+		return "", nil
+	}
+	if r.providerConfig.RemoteBuilder.Unresolved {
+		return "", errors.New("remote_builder is configured, but its endpoint or env was not known when the provider was configured: " +
+			"refusing to build on the local Docker daemon instead. Make them known before the apply, e.g. with -target on the resources it depends on")
+	}
+
+	// Compose delegates to the remote builder through bake, which needs BuildKit and the buildx plugin
+	buildkit, err := dockerCli.BuildKitEnabled()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine whether BuildKit is enabled: %w", err)
+	}
+	if !buildkit {
+		return "", fmt.Errorf("remote_builder requires BuildKit, but BuildKit is disabled (DOCKER_BUILDKIT=0)")
+	}
+	plugin, err := manager.GetPlugin("buildx", dockerCli, &cobra.Command{})
+	if err != nil {
+		return "", fmt.Errorf("remote_builder requires the buildx plugin: %w", err)
+	}
+	if plugin.Err != nil {
+		return "", fmt.Errorf("remote_builder requires the buildx plugin: %w", plugin.Err)
+	}
+
+	if err := buildx.EnsureRemoteBuilder(ctx, plugin.Path, *r.providerConfig.RemoteBuilder); err != nil {
+		return "", err
+	}
+	return r.providerConfig.RemoteBuilder.Name, nil
+}
+
+// ephemeralBuilderName creates a temporary docker-container buildx builder when the build exports cache
+// and the current builder cannot: the docker driver exports only inline cache unless the daemon uses
+// the containerd image store. It returns an empty string when the current builder is suitable.
+// The returned function removes the temporary builder and must be called after the build.
+func (r *ComposeResource) ephemeralBuilderName(
+	ctx context.Context,
+	dockerCli command.Cli,
+	dockerClient *client.Client,
+	buildSpec *composetypes.BuildConfig,
+) (string, func(), error) {
+	noop := func() {}
+	if !exportsCache(buildSpec) {
+		return "", noop, nil
+	}
+	buildkit, err := dockerCli.BuildKitEnabled()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to determine whether BuildKit is enabled: %w", err)
+	}
+	if !buildkit {
+		return "", noop, nil
+	}
+	plugin, err := manager.GetPlugin("buildx", dockerCli, &cobra.Command{})
+	if err != nil || plugin.Err != nil {
+		// Compose falls back to the classic builder, which fails on cache_to by itself
+		return "", noop, nil
+	}
+
+	driver, err := buildx.CurrentBuilderDriver(ctx, plugin.Path, r.dockerEnv())
+	if err != nil {
+		return "", nil, err
+	}
+	if driver != "docker" {
+		return "", noop, nil
+	}
+	containerd, err := usesContainerdImageStore(ctx, dockerClient)
+	if err != nil {
+		return "", nil, err
+	}
+	if containerd {
+		return "", noop, nil
+	}
+	return buildx.CreateEphemeralBuilder(ctx, plugin.Path, r.dockerEnv(), bakeEntitlements(buildSpec))
+}
+
+// exportsCache reports whether buildSpec exports build cache other than inline cache.
+func exportsCache(buildSpec *composetypes.BuildConfig) bool {
+	for _, entry := range buildSpec.CacheTo {
+		if cacheAttrs(entry)["type"] != "inline" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package compose
+
+import (
+	"fmt"
+
+	composetypes "github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/cli/cli/command"
+)
+
+// checkBuildFields verifies that the build fields of buildSpec that only one build backend applies are honored.
+// Compose forwards shm_size, ulimits, privileged and entitlements to BuildKit only, and isolation to the classic builder only,
+// silently dropping them otherwise.
+func checkBuildFields(dockerCli command.Cli, buildSpec *composetypes.BuildConfig) error {
+	buildkit, err := dockerCli.BuildKitEnabled()
+	if err != nil {
+		return fmt.Errorf("failed to determine whether BuildKit is enabled: %w", err)
+	}
+
+	if buildkit {
+		if buildSpec.Isolation != "" && buildSpec.Isolation != "default" {
+			return fmt.Errorf("build.isolation is only applied by the classic builder, but BuildKit is used: set builder = \"classic\"")
+		}
+		return nil
+	}
+
+	var fields []string
+	if buildSpec.ShmSize != 0 {
+		fields = append(fields, "shm_size")
+	}
+	if len(buildSpec.Ulimits) > 0 {
+		fields = append(fields, "ulimits")
+	}
+	if buildSpec.Privileged {
+		fields = append(fields, "privileged")
+	}
+	if len(buildSpec.Entitlements) > 0 {
+		fields = append(fields, "entitlements")
+	}
+	if len(fields) > 0 {
+		return fmt.Errorf("build.%s is only applied by BuildKit, but the classic builder is used: set builder = \"buildkit\"", fields[0])
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package compose
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	composetypes "github.com/compose-spec/compose-go/v2/types"
+	"github.com/distribution/reference"
+	"github.com/docker/cli/cli/command"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/providerconfig"
+)
+
+// gcsEndpoint is the Cloud Storage XML API endpoint, which is compatible with the S3 API used by the BuildKit s3 cache.
+const gcsEndpoint = "https://storage.googleapis.com"
+
+// cacheAttrs parses a cache_from / cache_to entry such as "type=gha,scope=main" into its attributes.
+// An entry without "=" is a registry reference, as in `docker buildx build --cache-from`.
+func cacheAttrs(entry string) map[string]string {
+	attrs := map[string]string{}
+	if !strings.Contains(entry, "=") {
+		attrs["type"] = "registry"
+		attrs["ref"] = entry
+		return attrs
+	}
+	for _, field := range strings.Split(entry, ",") {
+		key, value, _ := strings.Cut(field, "=")
+		attrs[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return attrs
+}
+
+// formatCacheAttrs renders attrs as a cache_from / cache_to entry, with type first and the other keys sorted.
+func formatCacheAttrs(attrs map[string]string) string {
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		if key != "type" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	fields := []string{"type=" + attrs["type"]}
+	for _, key := range keys {
+		fields = append(fields, key+"="+attrs[key])
+	}
+	return strings.Join(fields, ",")
+}
+
+// prepareCacheBackends verifies that the cache_from / cache_to backends of buildSpec can be used,
+// so that a misconfiguration fails before the build instead of silently building without cache.
+// type=s3 entries are completed with the cache_storage_auth credentials, and type=gcs entries
+// are rewritten to type=s3 entries against the Cloud Storage XML API, which BuildKit supports natively.
+func prepareCacheBackends(dockerCli command.Cli, buildSpec *composetypes.BuildConfig, cfg *providerconfig.Config) error {
+	if len(buildSpec.CacheFrom) == 0 && len(buildSpec.CacheTo) == 0 {
+		return nil
+	}
+	var auth providerconfig.CacheStorageAuth
+	if cfg != nil && cfg.CacheStorageAuth != nil {
+		auth = *cfg.CacheStorageAuth
+	}
+
+	for _, entries := range []composetypes.StringList{buildSpec.CacheFrom, buildSpec.CacheTo} {
+		for i, entry := range entries {
+			attrs := cacheAttrs(entry)
+			var err error
+			switch attrs["type"] {
+			case "gha":
+				err = checkGHACache(attrs)
+			case "s3":
+				err = prepareS3Cache(attrs, auth)
+			case "gcs":
+				err = prepareGCSCache(attrs, auth)
+			default:
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if err := requireBuildKitForCache(dockerCli, attrs["type"]); err != nil {
+				return err
+			}
+			if attrs["type"] != "gha" {
+				entries[i] = formatCacheAttrs(attrs)
+			}
+		}
+	}
+	return nil
+}
+
+// requireBuildKitForCache returns an error when the cache backend typ cannot be used because the classic builder is used.
+func requireBuildKitForCache(dockerCli command.Cli, typ string) error {
+	buildkit, err := dockerCli.BuildKitEnabled()
+	if err != nil {
+		return fmt.Errorf("failed to determine whether BuildKit is enabled: %w", err)
+	}
+	if !buildkit {
+		return fmt.Errorf("cache type=%s requires BuildKit, but the classic builder is used", typ)
+	}
+	return nil
+}
+
+// prepareS3Cache completes a type=s3 entry with the region and credentials of auth.
+// Without credentials, buildx reads them from the AWS credential chain of its environment.
+func prepareS3Cache(attrs map[string]string, auth providerconfig.CacheStorageAuth) error {
+	if attrs["bucket"] == "" {
+		return fmt.Errorf("cache type=s3 requires bucket")
+	}
+	if attrs["region"] == "" {
+		attrs["region"] = firstNonEmpty(auth.AWSRegion, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"))
+	}
+	if attrs["region"] == "" {
+		return fmt.Errorf("cache type=s3 requires region: set region= in the cache entry, cache_storage_auth.aws_region or AWS_REGION")
+	}
+	if attrs["access_key_id"] == "" && auth.AWSAccessKeyID != "" {
+		attrs["access_key_id"] = auth.AWSAccessKeyID
+		attrs["secret_access_key"] = auth.AWSSecretAccessKey
+		if auth.AWSSessionToken != "" {
+			attrs["session_token"] = auth.AWSSessionToken
+		}
+	}
+	return nil
+}
+
+// prepareGCSCache rewrites a type=gcs entry into a type=s3 entry against the Cloud Storage XML API,
+// authenticated with the HMAC key of auth.
+func prepareGCSCache(attrs map[string]string, auth providerconfig.CacheStorageAuth) error {
+	if attrs["bucket"] == "" {
+		return fmt.Errorf("cache type=gcs requires bucket")
+	}
+	if attrs["access_key_id"] == "" {
+		if auth.GCSHMACAccessID == "" {
+			return fmt.Errorf("cache type=gcs requires an HMAC key: set cache_storage_auth.gcs_hmac_access_id and gcs_hmac_secret")
+		}
+		attrs["access_key_id"] = auth.GCSHMACAccessID
+		attrs["secret_access_key"] = auth.GCSHMACSecret
+	}
+	attrs["type"] = "s3"
+	attrs["endpoint_url"] = gcsEndpoint
+	attrs["use_path_style"] = "true"
+	if attrs["region"] == "" {
+		attrs["region"] = "auto"
+	}
+	return nil
+}
+
+// firstNonEmpty returns the first non-empty value of values.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// checkGHACache checks that the GitHub Actions cache service can be reached by buildx.
+// buildx reads the service URL and token from the environment unless they are set in the entry.
+func checkGHACache(attrs map[string]string) error {
+	if attrs["token"] == "" && os.Getenv("ACTIONS_RUNTIME_TOKEN") == "" {
+		return fmt.Errorf("cache type=gha requires ACTIONS_RUNTIME_TOKEN, which GitHub Actions only exposes to actions; " +
+			"export it to the environment of terraform (e.g. with crazy-max/ghaction-github-runtime) or set token= in the cache entry")
+	}
+	if attrs["url"] == "" && attrs["url_v2"] == "" && os.Getenv("ACTIONS_CACHE_URL") == "" && os.Getenv("ACTIONS_RESULTS_URL") == "" {
+		return fmt.Errorf("cache type=gha requires ACTIONS_CACHE_URL or ACTIONS_RESULTS_URL, which GitHub Actions only exposes to actions; " +
+			"export it to the environment of terraform (e.g. with crazy-max/ghaction-github-runtime) or set url= in the cache entry")
+	}
+	return nil
+}
+
+// cacheFromPreviousAttribute returns the schema of the cache_from_previous attribute shared by the image resources.
+func cacheFromPreviousAttribute() schema.BoolAttribute {
+	return schema.BoolAttribute{
+		MarkdownDescription: "Whether to use the image pushed by the previous apply (`image_uri` at `sha256_digest` in the state) as cache source " +
+			"and to export inline cache into the pushed image, so that rebuilds reuse layers even on fresh CI runners without cache configuration. " +
+			"Requires BuildKit.",
+		Optional: true,
+	}
+}
+
+// previousImageRef returns the reference of the image recorded in the state as image_uri and sha256_digest,
+// pinned by digest so that the cache comes from the image this resource pushed even when the tag moved since.
+func previousImageRef(imageURI, digest string) string {
+	if imageURI == "" {
+		return ""
+	}
+	named, err := reference.ParseNormalizedNamed(imageURI)
+	if err != nil || digest == "" {
+		return imageURI
+	}
+	return reference.TrimNamed(named).String() + "@" + digest
+}
+
+// cacheFromPreviousImage adds previousImage, the image pushed by the previous apply, as a cache source of buildSpec
+// and exports inline cache, so that every pushed image carries the cache metadata for the next build.
+// It requires BuildKit, as the classic builder only uses cache sources present in the local Docker daemon.
+func cacheFromPreviousImage(dockerCli command.Cli, buildSpec *composetypes.BuildConfig, previousImage string) error {
+	buildkit, err := dockerCli.BuildKitEnabled()
+	if err != nil {
+		return fmt.Errorf("failed to determine whether BuildKit is enabled: %w", err)
+	}
+	if !buildkit {
+		return fmt.Errorf("cache_from_previous requires BuildKit, but the classic builder is used: set builder = \"buildkit\"")
+	}
+	if previousImage != "" {
+		buildSpec.CacheFrom = append(buildSpec.CacheFrom, previousImage)
+	}
+	for _, entry := range buildSpec.CacheTo {
+		if cacheAttrs(entry)["type"] == "inline" {
+			return nil
+		}
+	}
+	buildSpec.CacheTo = append(buildSpec.CacheTo, "type=inline")
+	return nil
+}
@@ -0,0 +1,281 @@
+package compose
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	composetypes "github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/cli/cli/command"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+)
+
+// classicContextsDir is the directory in the staged build context holding the local additional contexts for the classic builder.
+const classicContextsDir = ".containerregistry-contexts"
+
+// dockerImageContextPrefix is the prefix of additional contexts referring to an image.
+const dockerImageContextPrefix = "docker-image://"
+
+// prepareClassicAdditionalContexts emulates build.additional_contexts for the classic builder, which reads a single build context
+// and rejects named contexts. Image contexts (docker-image://) are substituted in FROM and in --from of COPY / ADD,
+// and local directories are staged next to the main build context, with COPY / ADD --from rewritten to copy from there.
+// The rewritten Dockerfile replaces build.dockerfile (and build.dockerfile_inline).
+// The returned cleanup function removes the staged build context and the rewritten Dockerfile.
+func prepareClassicAdditionalContexts(ctx context.Context, dockerCli command.Cli, buildSpec *composetypes.BuildConfig) (func(), error) {
+	cleanup := func() {}
+	if len(buildSpec.AdditionalContexts) == 0 {
+		return cleanup, nil
+	}
+	buildkit, err := dockerCli.BuildKitEnabled()
+	if err != nil {
+		return cleanup, fmt.Errorf("failed to determine whether BuildKit is enabled: %w", err)
+	}
+	if buildkit {
+		return cleanup, nil
+	}
+	if isRemoteContext(buildSpec.Context) {
+		return cleanup, fmt.Errorf("build.additional_contexts requires a local build.context with the classic builder")
+	}
+
+	images := map[string]string{}
+	dirs := map[string]string{}
+	for name, value := range buildSpec.AdditionalContexts {
+		if ref, ok := strings.CutPrefix(value, dockerImageContextPrefix); ok {
+			images[name] = ref
+			continue
+		}
+		if strings.Contains(value, "://") || strings.HasPrefix(value, composetypes.ServicePrefix) || isRemoteContext(value) {
+			return cleanup, fmt.Errorf("build.additional_contexts.%s: only images (%s) and local directories are supported with the classic builder", name, dockerImageContextPrefix)
+		}
+		dir, err := filepath.Abs(value)
+		if err != nil {
+			return cleanup, fmt.Errorf("failed to resolve build.additional_contexts.%s: %w", name, err)
+		}
+		if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+			return cleanup, fmt.Errorf("build.additional_contexts.%s: %s is not a directory", name, value)
+		}
+		dirs[name] = dir
+	}
+
+	contextDir := buildSpec.Context
+	if contextDir == "" {
+		contextDir = "."
+	}
+	if contextDir, err = filepath.Abs(contextDir); err != nil {
+		return cleanup, fmt.Errorf("failed to resolve build context: %w", err)
+	}
+	dockerfile, err := readDockerfile(buildSpec)
+	if err != nil {
+		return cleanup, err
+	}
+	rewritten, err := rewriteClassicDockerfile(dockerfile, images, dirs)
+	if err != nil {
+		return cleanup, err
+	}
+
+	var tempDirs []string
+	cleanup = func() {
+		for _, dir := range tempDirs {
+			_ = os.RemoveAll(dir)
+		}
+	}
+	dockerfileDir, err := os.MkdirTemp("", "containerregistry-dockerfile-")
+	if err != nil {
+		return func() {}, fmt.Errorf("failed to create directory for the Dockerfile: %w", err)
+	}
+	tempDirs = append(tempDirs, dockerfileDir)
+	dockerfilePath := filepath.Join(dockerfileDir, "Dockerfile")
+	if err := os.WriteFile(dockerfilePath, []byte(rewritten), 0644); err != nil {
+		cleanup()
+		return func() {}, fmt.Errorf("failed to write Dockerfile: %w", err)
+	}
+
+	if len(dirs) > 0 {
+		staged, err := os.MkdirTemp("", "containerregistry-context-")
+		if err != nil {
+			cleanup()
+			return func() {}, fmt.Errorf("failed to create directory for the build context: %w", err)
+		}
+		tempDirs = append(tempDirs, staged)
+		tflog.Debug(ctx, "Staging additional contexts for the classic builder", map[string]interface{}{
+			"context": contextDir,
+			"path":    staged,
+		})
+		if err := stageClassicContext(staged, contextDir, dirs); err != nil {
+			cleanup()
+			return func() {}, err
+		}
+		contextDir = staged
+	}
+
+	buildSpec.Context = contextDir
+	buildSpec.Dockerfile = dockerfilePath
+	buildSpec.DockerfileInline = ""
+	buildSpec.AdditionalContexts = nil
+	return cleanup, nil
+}
+
+// rewriteClassicDockerfile substitutes the additional contexts in dockerfile: images replace the base image in FROM
+// and --from in COPY / ADD, and COPY / ADD --from a local directory copy from the directory staged in classicContextsDir.
+// Build stages take precedence over additional contexts of the same name, as in BuildKit.
+func rewriteClassicDockerfile(dockerfile []byte, images, dirs map[string]string) (string, error) {
+	result, err := parser.Parse(bytes.NewReader(dockerfile))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse Dockerfile: %w", err)
+	}
+	lines := strings.Split(string(dockerfile), "\n")
+	stages := map[string]bool{}
+	for _, node := range result.AST.Children {
+		var args []string
+		for next := node.Next; next != nil; next = next.Next {
+			args = append(args, next.Value)
+		}
+		var instruction string
+		switch command := strings.ToUpper(node.Value); command {
+		case "FROM":
+			if len(args) == 0 {
+				continue
+			}
+			base := args[0]
+			isStage := stages[strings.ToLower(base)]
+			if len(args) == 3 && strings.EqualFold(args[1], "AS") {
+				stages[strings.ToLower(args[2])] = true
+			}
+			if isStage {
+				continue
+			}
+			if _, ok := dirs[base]; ok {
+				return "", fmt.Errorf("build.additional_contexts.%s: a local directory cannot be used as base image with the classic builder", base)
+			}
+			ref, ok := images[base]
+			if !ok {
+				continue
+			}
+			args[0] = ref
+			instruction = strings.Join(append(append([]string{command}, node.Flags...), args...), " ")
+		case "COPY", "ADD":
+			index := -1
+			var from string
+			for i, flag := range node.Flags {
+				if value, ok := strings.CutPrefix(flag, "--from="); ok {
+					index, from = i, value
+				}
+			}
+			if index < 0 || stages[strings.ToLower(from)] || len(args) < 2 {
+				continue
+			}
+			flags := append([]string{}, node.Flags...)
+			if ref, ok := images[from]; ok {
+				flags[index] = "--from=" + ref
+			} else if _, ok := dirs[from]; ok {
+				flags = append(flags[:index], flags[index+1:]...)
+				for i, src := range args[:len(args)-1] {
+					args[i] = path.Join(classicContextsDir, from, strings.TrimPrefix(src, "/"))
+				}
+			} else {
+				continue
+			}
+			// The JSON form keeps paths with spaces intact
+			argsJSON, err := json.Marshal(args)
+			if err != nil {
+				return "", fmt.Errorf("failed to encode %s arguments: %w", command, err)
+			}
+			instruction = strings.Join(append(append([]string{command}, flags...), string(argsJSON)), " ")
+		default:
+			continue
+		}
+		// Blank out continuation lines so that line numbers in build errors still match the original Dockerfile
+		lines[node.StartLine-1] = instruction
+		for i := node.StartLine; i < node.EndLine; i++ {
+			lines[i] = ""
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// stageClassicContext copies the build context contextDir into staged, and the local additional contexts dirs
+// into classicContextsDir in it. A .dockerignore of the build context is extended so that it keeps the additional contexts.
+func stageClassicContext(staged, contextDir string, dirs map[string]string) error {
+	if err := copyTree(contextDir, staged); err != nil {
+		return fmt.Errorf("failed to copy build context: %w", err)
+	}
+	for name, dir := range dirs {
+		if err := copyTree(dir, filepath.Join(staged, classicContextsDir, name)); err != nil {
+			return fmt.Errorf("failed to copy build.additional_contexts.%s: %w", name, err)
+		}
+	}
+	dockerignore := filepath.Join(staged, ".dockerignore")
+	if _, err := os.Stat(dockerignore); err == nil {
+		f, err := os.OpenFile(dockerignore, os.O_APPEND|os.O_WRONLY, 0)
+		if err != nil {
+			return fmt.Errorf("failed to update .dockerignore: %w", err)
+		}
+		_, err = fmt.Fprintf(f, "\n!%s\n!%s/**\n", classicContextsDir, classicContextsDir)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update .dockerignore: %w", err)
+		}
+	}
+	return nil
+}
+
+// copyTree copies the directory src to dst, keeping file modes and symbolic links.
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			return copyFile(p, target, info.Mode().Perm())
+		default:
+			// Sockets, devices and pipes cannot be sent in a build context anyway
+			return nil
+		}
+	})
+}
+
+// copyFile copies the regular file src to dst with mode.
+func copyFile(src, dst string, mode fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,204 @@
+package compose
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/connhelper"
+	"github.com/docker/cli/cli/context/docker"
+	"github.com/docker/cli/cli/flags"
+	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/tlsconfig"
+)
+
+// dockerHost returns the Docker daemon address configured in the provider (docker_host), overridden by host when not empty.
+// An empty result leaves the daemon selection to DOCKER_HOST and the current Docker context.
+func (r *ComposeResource) dockerHost(host string) string {
+	if host == "" && r.providerConfig != nil {
+		host = r.providerConfig.DockerHost
+	}
+	return host
+}
+
+// dockerCertPath returns the directory holding ca.pem, cert.pem and key.pem for the provider docker_host, if configured.
+// The certificates only apply to the provider docker_host, not to an address passed as override.
+func (r *ComposeResource) dockerCertPath(host string) string {
+	if host != "" || r.providerConfig == nil {
+		return ""
+	}
+	return r.providerConfig.DockerCertPath
+}
+
+// dockerContext returns the Docker context configured in the provider (docker_context), unless host overrides the daemon.
+func (r *ComposeResource) dockerContext(host string) string {
+	if host != "" || r.providerConfig == nil {
+		return ""
+	}
+	return r.providerConfig.DockerContext
+}
+
+// dockerCliOptions returns the Docker CLI options connecting to the daemon selected as in dockerHost,
+// or to the endpoint of the provider docker_context.
+func (r *ComposeResource) dockerCliOptions(host string) *flags.ClientOptions {
+	opts := &flags.ClientOptions{Context: r.dockerContext(host)}
+	if daemonHost := r.dockerHost(host); daemonHost != "" {
+		opts.Hosts = []string{daemonHost}
+	}
+	if certPath := r.dockerCertPath(host); certPath != "" {
+		opts.TLS = true
+		opts.TLSVerify = true
+		opts.TLSOptions = &tlsconfig.Options{
+			CAFile:   filepath.Join(certPath, flags.DefaultCaFile),
+			CertFile: filepath.Join(certPath, flags.DefaultCertFile),
+			KeyFile:  filepath.Join(certPath, flags.DefaultKeyFile),
+		}
+	}
+	return opts
+}
+
+// newDockerClient returns a Docker Engine API client for the daemon selected as in dockerHost,
+// or for the endpoint of the provider docker_context.
+func (r *ComposeResource) newDockerClient(host string) (*client.Client, error) {
+	opts := []client.Opt{client.FromEnv}
+	if name := r.dockerContext(host); name != "" {
+		endpoint, err := dockerContextEndpoint(name)
+		if err != nil {
+			return nil, err
+		}
+		if opts, err = appendHostOpts(opts, endpoint.Host); err != nil {
+			return nil, err
+		}
+		tlsConfig, err := contextTLSConfig(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TLS data in Docker context %q: %w", name, err)
+		}
+		if tlsConfig != nil {
+			opts = append(opts, withTLSConfig(tlsConfig))
+		}
+	} else if daemonHost := r.dockerHost(host); daemonHost != "" {
+		var err error
+		if opts, err = appendHostOpts(opts, daemonHost); err != nil {
+			return nil, err
+		}
+	}
+	if certPath := r.dockerCertPath(host); certPath != "" {
+		opts = append(opts, client.WithTLSClientConfig(
+			filepath.Join(certPath, flags.DefaultCaFile),
+			filepath.Join(certPath, flags.DefaultCertFile),
+			filepath.Join(certPath, flags.DefaultKeyFile),
+		))
+	}
+	opts = append(opts, client.WithAPIVersionNegotiation(), withLoggingHTTPClient)
+
+	dockerClient, err := client.NewClientWithOpts(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	return dockerClient, nil
+}
+
+// appendHostOpts appends the client options connecting to the daemon address host,
+// tunneling ssh:// addresses through the ssh command as the Docker CLI does.
+func appendHostOpts(opts []client.Opt, host string) ([]client.Opt, error) {
+	if host == "" {
+		return opts, nil
+	}
+	helper, err := connhelper.GetConnectionHelper(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Docker host %q: %w", host, err)
+	}
+	if helper != nil {
+		return append(opts, client.WithHost(helper.Host), client.WithDialContext(helper.Dialer)), nil
+	}
+	return append(opts, client.WithHost(host)), nil
+}
+
+// dockerContextEndpoint returns the Docker endpoint of the Docker context name,
+// read from the context store of the Docker CLI as `docker --context` does.
+func dockerContextEndpoint(name string) (docker.Endpoint, error) {
+	dockerCli, err := command.NewDockerCli()
+	if err != nil {
+		return docker.Endpoint{}, fmt.Errorf("failed to create Docker CLI: %w", err)
+	}
+	if err := dockerCli.Initialize(&flags.ClientOptions{Context: name}); err != nil {
+		return docker.Endpoint{}, fmt.Errorf("failed to initialize Docker CLI: %w", err)
+	}
+	contextStore := dockerCli.ContextStore()
+	metadata, err := contextStore.GetMetadata(name)
+	if err != nil {
+		return docker.Endpoint{}, fmt.Errorf("failed to load Docker context %q: %w", name, err)
+	}
+	endpointMeta, err := docker.EndpointFromContext(metadata)
+	if err != nil {
+		return docker.Endpoint{}, fmt.Errorf("failed to load Docker context %q: %w", name, err)
+	}
+	endpoint, err := docker.WithTLSData(contextStore, name, endpointMeta)
+	if err != nil {
+		return docker.Endpoint{}, fmt.Errorf("failed to load TLS data of Docker context %q: %w", name, err)
+	}
+	return endpoint, nil
+}
+
+// contextTLSConfig returns the TLS configuration of a Docker context endpoint, or nil when it does not use TLS.
+func contextTLSConfig(endpoint docker.Endpoint) (*tls.Config, error) {
+	if strings.HasPrefix(endpoint.Host, "unix://") || strings.HasPrefix(endpoint.Host, "ssh://") {
+		return nil, nil
+	}
+	if endpoint.TLSData == nil && !endpoint.SkipTLSVerify {
+		return nil, nil
+	}
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: endpoint.SkipTLSVerify,
+	}
+	if endpoint.TLSData != nil && endpoint.TLSData.CA != nil {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(endpoint.TLSData.CA) {
+			return nil, errors.New("failed to parse the CA certificate")
+		}
+		config.RootCAs = pool
+	}
+	if endpoint.TLSData != nil && endpoint.TLSData.Cert != nil && endpoint.TLSData.Key != nil {
+		cert, err := tls.X509KeyPair(endpoint.TLSData.Cert, endpoint.TLSData.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// withTLSConfig applies config to the transport of the client, as client.WithTLSClientConfig does for certificate files.
+func withTLSConfig(config *tls.Config) client.Opt {
+	return func(c *client.Client) error {
+		transport, ok := c.HTTPClient().Transport.(*http.Transport)
+		if !ok {
+			return fmt.Errorf("cannot apply TLS config to transport: %T", c.HTTPClient().Transport)
+		}
+		transport.TLSClientConfig = config
+		return nil
+	}
+}
+
+// dockerEnv returns the environment variables pointing the buildx plugin, when run by the provider,
+// at the daemon configured in the provider docker_host or docker_context. It is empty when neither is configured.
+func (r *ComposeResource) dockerEnv() []string {
+	if name := r.dockerContext(""); name != "" {
+		return []string{command.EnvOverrideContext + "=" + name}
+	}
+	host := r.dockerHost("")
+	if host == "" {
+		return nil
+	}
+	env := []string{client.EnvOverrideHost + "=" + host}
+	if certPath := r.dockerCertPath(""); certPath != "" {
+		env = append(env, client.EnvOverrideCertPath+"="+certPath, client.EnvTLSVerify+"=1")
+	}
+	return env
+}
@@ -0,0 +1,74 @@
+package compose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/compose-spec/compose-go/v2/cli"
+	composetypes "github.com/compose-spec/compose-go/v2/types"
+)
+
+// loadComposeService loads the compose file composeFile and returns the build section of service.
+func loadComposeService(ctx context.Context, composeFile, service string, envFiles []string, environment map[string]string) (*composetypes.BuildConfig, error) {
+	project, err := loadComposeProject(ctx, composeFile, envFiles, environment)
+	if err != nil {
+		return nil, err
+	}
+	return composeServiceBuild(project, composeFile, service)
+}
+
+// loadComposeProject loads the compose file composeFile as `docker compose -f composeFile` does
+// (interpolating variables from the environment and the .env file next to it, resolving extends and include).
+// As with `docker compose --env-file`, envFiles replace the .env file when set.
+// Variables in environment take precedence over the environment variables and the env files.
+func loadComposeProject(ctx context.Context, composeFile string, envFiles []string, environment map[string]string) (*composetypes.Project, error) {
+	path, err := filepath.Abs(composeFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve compose_file: %w", err)
+	}
+	options, err := cli.NewProjectOptions(
+		[]string{path},
+		cli.WithWorkingDirectory(filepath.Dir(path)),
+		cli.WithOsEnv,
+		cli.WithEnvFiles(envFiles...),
+		cli.WithDotEnv,
+		cli.WithEnv(environmentList(environment)),
+		// Build any service of the file, whichever profile it belongs to
+		cli.WithProfiles([]string{"*"}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load compose file %s: %w", composeFile, err)
+	}
+	project, err := options.LoadProject(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load compose file %s: %w", composeFile, err)
+	}
+	return project, nil
+}
+
+// composeServiceBuild returns the build section of service in project loaded from composeFile.
+func composeServiceBuild(project *composetypes.Project, composeFile, service string) (*composetypes.BuildConfig, error) {
+	serviceConfig, err := project.GetService(service)
+	if err != nil {
+		return nil, fmt.Errorf("service %q not found in compose file %s", service, composeFile)
+	}
+	if serviceConfig.Build == nil {
+		return nil, fmt.Errorf("service %q in compose file %s has no build section", service, composeFile)
+	}
+	// Labels are compared with the registry on refresh, so they must come from the labels attribute
+	if len(serviceConfig.Build.Labels) > 0 {
+		return nil, errors.New("build.labels in the compose file is not supported: set them in the labels attribute instead")
+	}
+	return serviceConfig.Build, nil
+}
+
+// environmentList returns environment in the KEY=VALUE format.
+func environmentList(environment map[string]string) []string {
+	list := make([]string, 0, len(environment))
+	for key, value := range environment {
+		list = append(list, key+"="+value)
+	}
+	return list
+}
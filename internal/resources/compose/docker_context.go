@@ -0,0 +1,96 @@
+package compose
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	composetypes "github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/cli/cli/command/image/build"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/tarball"
+)
+
+// isRemoteContext reports whether the build context is a Git repository or an HTTP(S) URL,
+// which Compose and buildx fetch themselves.
+func isRemoteContext(contextPath string) bool {
+	if _, err := os.Stat(contextPath); err == nil {
+		return false
+	}
+	contextType, err := build.DetectContextType(contextPath)
+	return err == nil && (contextType == build.ContextTypeGit || contextType == build.ContextTypeRemote)
+}
+
+// missingPathError reports a local build context or Dockerfile that does not exist.
+type missingPathError struct {
+	// kind is "build context" or "Dockerfile".
+	kind string
+	// path is the absolute path of the missing file.
+	path string
+}
+
+// Error implements error.
+func (e *missingPathError) Error() string {
+	return fmt.Sprintf("%s %s does not exist", e.kind, e.path)
+}
+
+// checkBuildPaths verifies that the local build context of buildSpec exists, and its Dockerfile too when the context
+// is a directory, returning a *missingPathError with the resolved absolute path otherwise.
+// A remote context, a tarball context and dockerfile_inline are not checked, and other errors are left to the build.
+func checkBuildPaths(buildSpec *composetypes.BuildConfig) error {
+	contextDir := buildSpec.Context
+	if contextDir == "" {
+		contextDir = "."
+	}
+	if isRemoteContext(contextDir) {
+		return nil
+	}
+	fi, err := os.Stat(contextDir)
+	if os.IsNotExist(err) {
+		return &missingPathError{kind: "build context", path: absPath(contextDir)}
+	}
+	if err != nil || !fi.IsDir() || buildSpec.DockerfileInline != "" || isRemoteContext(buildSpec.Dockerfile) {
+		return nil
+	}
+	dockerfile := dockerfilePath(buildSpec)
+	if _, err := os.Stat(dockerfile); os.IsNotExist(err) {
+		return &missingPathError{kind: "Dockerfile", path: absPath(dockerfile)}
+	}
+	return nil
+}
+
+// absPath returns the absolute path of p for diagnostics, or p itself when it cannot be resolved.
+func absPath(p string) string {
+	if abs, err := filepath.Abs(p); err == nil {
+		return abs
+	}
+	return p
+}
+
+// prepareContextArchive extracts build.context into a temporary directory and uses it as the context
+// when it is a tarball file (.tar, .tar.gz, ...), which Compose only accepts through a URL.
+// The returned cleanup function removes the temporary directory.
+func prepareContextArchive(ctx context.Context, buildSpec *composetypes.BuildConfig) (func(), error) {
+	cleanup := func() {}
+	fi, err := os.Stat(buildSpec.Context)
+	if err != nil || !fi.Mode().IsRegular() {
+		return cleanup, nil
+	}
+
+	dir, err := os.MkdirTemp("", "containerregistry-context-")
+	if err != nil {
+		return cleanup, fmt.Errorf("failed to create directory for the build context: %w", err)
+	}
+	tflog.Debug(ctx, "Extracting build context tarball", map[string]interface{}{
+		"context": buildSpec.Context,
+		"path":    dir,
+	})
+	if err := tarball.Extract(buildSpec.Context, dir); err != nil {
+		_ = os.RemoveAll(dir)
+		return cleanup, fmt.Errorf("failed to extract build context %s: %w", buildSpec.Context, err)
+	}
+	buildSpec.Context = dir
+	return func() { _ = os.RemoveAll(dir) }, nil
+}
@@ -0,0 +1,431 @@
+package compose
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	composetypes "github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/cli/cli/command/image/build"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	tfplugintypes "github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/moby/patternmatcher"
+	"github.com/moby/patternmatcher/ignorefile"
+)
+
+// contextHashAttribute returns the schema of the context_hash attribute shared by the image resources.
+func contextHashAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		MarkdownDescription: "SHA256 hash of the files of the local build context, excluding those matched by `.dockerignore`, and of the Dockerfile. " +
+			"It is recomputed on each plan, so that editing the sources rebuilds the image even when the build specification is unchanged. " +
+			"The files of `watch_paths` are included. " +
+			"Null for remote build contexts (Git repositories and URLs), which are not hashed, and for existing images, without `watch_paths`. " +
+			"Resources created by earlier versions of the provider record it on their next update.",
+		Computed: true,
+	}
+}
+
+// contextHash returns the hash of the local build context of buildSpec and of its Dockerfile,
+// or an empty string for a remote build context.
+// A tarball context is hashed as a file.
+func contextHash(buildSpec *composetypes.BuildConfig) (string, error) {
+	contextDir := buildSpec.Context
+	if contextDir == "" {
+		contextDir = "."
+	}
+	if isRemoteContext(contextDir) {
+		return "", nil
+	}
+	if err := checkBuildPaths(buildSpec); err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	fi, err := os.Stat(contextDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read build context: %w", err)
+	}
+	if fi.Mode().IsRegular() {
+		if err := hashFile(h, contextDir); err != nil {
+			return "", err
+		}
+	} else if err := hashContextDir(h, contextDir, buildSpec.Dockerfile); err != nil {
+		return "", err
+	}
+	// The Dockerfile may be outside of the build context
+	if buildSpec.DockerfileInline == "" && !isRemoteContext(buildSpec.Dockerfile) && !fi.Mode().IsRegular() {
+		dockerfile, err := readDockerfile(buildSpec)
+		if err != nil {
+			return "", err
+		}
+		_, _ = fmt.Fprintf(h, "dockerfile\x00%d\x00", len(dockerfile))
+		_, _ = h.Write(dockerfile)
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashContextDir writes the paths, modes and contents of the files of contextDir sent to the builder into h,
+// skipping the files excluded by the ignore file of dockerfile (Dockerfile.dockerignore) or by .dockerignore.
+func hashContextDir(h io.Writer, contextDir, dockerfile string) error {
+	excludes, err := contextExcludes(contextDir, dockerfile)
+	if err != nil {
+		return err
+	}
+	matcher, err := patternmatcher.New(excludes)
+	if err != nil {
+		return fmt.Errorf("invalid .dockerignore: %w", err)
+	}
+	return hashTree(h, contextDir, matcher)
+}
+
+// hashTree writes the paths relative to root, modes and contents of the files below root into h,
+// skipping the files excluded by matcher.
+func hashTree(h io.Writer, root string, matcher *patternmatcher.PatternMatcher) error {
+	// WalkDir visits the files in lexical order, so that the hash does not depend on the file system
+	return filepath.WalkDir(root, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, filePath)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		excluded, err := matcher.MatchesOrParentMatches(rel)
+		if err != nil {
+			return err
+		}
+		if excluded {
+			// Exclusions may re-include files below an excluded directory, as the Docker CLI allows
+			if d.IsDir() && !matcher.Exclusions() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(h, "%s\x00%s\x00", rel, info.Mode().String())
+		switch {
+		case info.Mode()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(filePath)
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(h, "%s\x00", target)
+		case info.Mode().IsRegular():
+			return hashFile(h, filePath)
+		}
+		return nil
+	})
+}
+
+// contextExcludes returns the patterns of the ignore file of dockerfile (e.g. Dockerfile.dockerignore), which BuildKit prefers,
+// or of .dockerignore in contextDir.
+func contextExcludes(contextDir, dockerfile string) ([]string, error) {
+	if dockerfile == "" {
+		dockerfile = "Dockerfile"
+	}
+	if !filepath.IsAbs(dockerfile) {
+		dockerfile = filepath.Join(contextDir, dockerfile)
+	}
+	f, err := os.Open(dockerfile + ".dockerignore")
+	if os.IsNotExist(err) {
+		return build.ReadDockerignore(contextDir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	patterns, err := ignorefile.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s.dockerignore: %w", filepath.Base(dockerfile), err)
+	}
+	return patterns, nil
+}
+
+// hashFile writes the size and the contents of the file at filePath into h.
+func hashFile(h io.Writer, filePath string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(h, "%d\x00", fi.Size())
+	if _, err := io.Copy(h, f); err != nil {
+		return fmt.Errorf("failed to read %s: %w", filePath, err)
+	}
+	return nil
+}
+
+// combineContextHashes returns a single hash of the context hashes of several images keyed by name,
+// or an empty string when none of them has a hash.
+func combineContextHashes(hashes map[string]string) string {
+	names := make([]string, 0, len(hashes))
+	for name, hash := range hashes {
+		if hash != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	h := sha256.New()
+	for _, name := range names {
+		_, _ = fmt.Fprintf(h, "%s\x00%s\x00", name, hashes[name])
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// contextHashValue converts a hash returned by contextHash to the value of context_hash.
+func contextHashValue(hash string) tfplugintypes.String {
+	if hash == "" {
+		return tfplugintypes.StringNull()
+	}
+	return tfplugintypes.StringValue(hash)
+}
+
+// planContextHash sets context_hash of the plan to hash, and plans a rebuild when it differs from the hash in the state
+// or other attributes change, by setting the computed attributes written by the build to the unknown values of rebuilt.
+// A change of the attributes of unbuiltAttributes only keeps the computed attributes of the state.
+// A state without context_hash, written by an earlier version of the provider, only records the hash along with other changes,
+// so that upgrading the provider does not rebuild every image.
+func planContextHash(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse, hash tfplugintypes.String, rebuilt map[string]attr.Value) {
+	if req.State.Raw.IsNull() {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("context_hash"), hash)...)
+		return
+	}
+	var prior tfplugintypes.String
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("context_hash"), &prior)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	updated := !req.Plan.Raw.Equal(req.State.Raw)
+	if prior.IsNull() && !updated {
+		return
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("context_hash"), hash)...)
+	if !updated {
+		if hash.Equal(prior) {
+			return
+		}
+		tflog.Info(ctx, "Build context changed: planning a rebuild", map[string]interface{}{
+			"previous": prior.ValueString(),
+			"current":  hash.ValueString(),
+		})
+	} else {
+		var changed []string
+		if prior.IsNull() {
+			changed = append(changed, "context_hash")
+		}
+		ok, err := unbuiltChange(req.State.Raw, resp.Plan.Raw, rebuilt, changed...)
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to plan the update", err.Error())
+			return
+		}
+		if ok {
+			keepBuiltAttributes(ctx, req, resp, rebuilt)
+			return
+		}
+	}
+	// Every update builds and pushes the image again: downstream resources must not see the digests of the previous image
+	for name, unknown := range rebuilt {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root(name), unknown)...)
+	}
+}
+
+// rebuiltImageAttributes returns the unknown values planned for the computed attributes written by the build
+// of the compose and dockerfile image resources.
+func rebuiltImageAttributes() map[string]attr.Value {
+	return map[string]attr.Value{
+		"sha256_digest":          tfplugintypes.StringUnknown(),
+		"previous_digest":        tfplugintypes.StringUnknown(),
+		"pushed_image_uri":       tfplugintypes.StringUnknown(),
+		"image_ref":              tfplugintypes.StringUnknown(),
+		"attestation_digests":    tfplugintypes.MapUnknown(tfplugintypes.StringType),
+		"scan_findings":          tfplugintypes.MapUnknown(tfplugintypes.Int64Type),
+		"build_duration_seconds": tfplugintypes.Float64Unknown(),
+		"push_duration_seconds":  tfplugintypes.Float64Unknown(),
+		"pushed_bytes":           tfplugintypes.Int64Unknown(),
+	}
+}
+
+// unbuiltAttributes returns the attributes of the image resources that do not affect the built image:
+// an update changing only them keeps the pushed image, without building and pushing it again.
+func unbuiltAttributes() []string {
+	return []string{
+		"delete_image",
+		"delete_previous_tag",
+		"delete_image_scope",
+		"prune_local",
+		"prune_local_parents",
+		"timeouts",
+		"on_drift",
+		"on_push",
+		"create_repository",
+		"wait_for_scan",
+		"fail_on_severity",
+		"wait_for_replication",
+		"wait_for_available",
+		"rebuild",
+		"on_label_change",
+	}
+}
+
+// unbuiltChange reports whether plan differs from state only in the attributes of unbuiltAttributes and in changed,
+// ignoring the computed attributes written by the build, named in rebuilt.
+func unbuiltChange(state, plan tftypes.Value, rebuilt map[string]attr.Value, changed ...string) (bool, error) {
+	ignored := map[string]bool{}
+	for name := range rebuilt {
+		ignored[name] = true
+	}
+	for _, name := range append(unbuiltAttributes(), changed...) {
+		ignored[name] = true
+	}
+	strip := func(value tftypes.Value) (tftypes.Value, error) {
+		return tftypes.Transform(value, func(p *tftypes.AttributePath, v tftypes.Value) (tftypes.Value, error) {
+			steps := p.Steps()
+			if len(steps) != 1 {
+				return v, nil
+			}
+			if name, ok := steps[0].(tftypes.AttributeName); ok && ignored[string(name)] {
+				return tftypes.NewValue(v.Type(), nil), nil
+			}
+			return v, nil
+		})
+	}
+	strippedState, err := strip(state)
+	if err != nil {
+		return false, err
+	}
+	strippedPlan, err := strip(plan)
+	if err != nil {
+		return false, err
+	}
+	return strippedState.Equal(strippedPlan), nil
+}
+
+// keepBuiltAttributes sets the computed attributes written by the build, named in rebuilt, to their values in the state.
+func keepBuiltAttributes(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse, rebuilt map[string]attr.Value) {
+	for name, unknown := range rebuilt {
+		prior, _, err := tftypes.WalkAttributePath(req.State.Raw, tftypes.NewAttributePath().WithAttributeName(name))
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to plan the update", err.Error())
+			return
+		}
+		value, err := unknown.Type(ctx).ValueFromTerraform(ctx, prior.(tftypes.Value))
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to plan the update", err.Error())
+			return
+		}
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root(name), value)...)
+	}
+}
+
+// buildContextHash returns the context_hash of model: the hash of the build context of build or compose_file and of watch_paths,
+// null for an existing image or a remote build context without watch_paths, and unknown while the build specification is unknown.
+func (r *ComposeResource) buildContextHash(ctx context.Context, model *ComposeResourceModel) (tfplugintypes.String, error) {
+	if model.Build.IsNull() && model.ComposeFile.IsNull() {
+		return withWatchPaths(ctx, "", model.WatchPaths)
+	}
+	if model.Build.IsUnknown() || model.ComposeFile.IsUnknown() || model.Service.IsUnknown() ||
+		model.EnvFile.IsUnknown() || model.Environment.IsUnknown() {
+		return tfplugintypes.StringUnknown(), nil
+	}
+	buildSpec, err := r.parseBuildSpec(ctx, model)
+	if err != nil {
+		return tfplugintypes.StringUnknown(), err
+	}
+	hash, err := contextHash(buildSpec)
+	if err != nil {
+		return tfplugintypes.StringUnknown(), err
+	}
+	return withWatchPaths(ctx, hash, model.WatchPaths)
+}
+
+// ModifyPlan recomputes context_hash from the build context, planning a rebuild when the files changed.
+func (r *ComposeResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to build when the resource is destroyed
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	var model ComposeResourceModel
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("build"), &model.Build)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("compose_file"), &model.ComposeFile)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("service"), &model.Service)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("env_file"), &model.EnvFile)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("environment"), &model.Environment)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("watch_paths"), &model.WatchPaths)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("builder"), &model.Builder)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("source_oci_layout"), &model.SourceOCILayout)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("source_tarball"), &model.SourceTarball)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("on_label_change"), &model.OnLabelChange)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	hash, err := r.buildContextHash(ctx, &model)
+	if !checkContextHashError(ctx, resp, err) {
+		return
+	}
+	planContextHash(ctx, req, resp, hash, rebuiltImageAttributes())
+	planDriftRebuild(ctx, req, resp, rebuiltImageAttributes())
+	planAlwaysRebuild(ctx, req, resp, rebuiltImageAttributes())
+	planTagChangeID(ctx, req, resp)
+	r.planDockerDaemonCheck(ctx, req, resp, &model, "sha256_digest")
+}
+
+// checkContextHashError reports a missing build context or Dockerfile, so that a misconfigured path fails the plan
+// rather than the apply after other resources are created, and returns false in that case.
+// Other errors are only logged, as the build reports them.
+func checkContextHashError(ctx context.Context, resp *resource.ModifyPlanResponse, err error) bool {
+	if err == nil {
+		return true
+	}
+	var missing *missingPathError
+	if errors.As(err, &missing) {
+		resp.Diagnostics.AddError("Build path not found", err.Error())
+		return false
+	}
+	tflog.Warn(ctx, "Failed to hash the build context", map[string]interface{}{
+		"error": err.Error(),
+	})
+	return true
+}
+
+// resolveContextHash returns hash, or the hash computed by compute when it was unknown at plan time.
+// A hash that still cannot be computed is recorded as null, as the state cannot hold unknown values.
+func resolveContextHash(ctx context.Context, hash tfplugintypes.String, compute func() (tfplugintypes.String, error)) tfplugintypes.String {
+	if !hash.IsUnknown() {
+		return hash
+	}
+	computed, err := compute()
+	if err != nil || computed.IsUnknown() {
+		if err != nil {
+			tflog.Warn(ctx, "Failed to hash the build context: context_hash is not recorded", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+		return tfplugintypes.StringNull()
+	}
+	return computed
+}
@@ -0,0 +1,147 @@
+package compose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	ecrtypes "github.com/aws/aws-sdk-go-v2/service/ecr/types"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	tfplugintypes "github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// CreateRepositoryModel represents the create_repository block of the image resources
+type CreateRepositoryModel struct {
+	ImageTagMutability tfplugintypes.String `tfsdk:"image_tag_mutability"`
+	ScanOnPush         tfplugintypes.Bool   `tfsdk:"scan_on_push"`
+	Encryption         tfplugintypes.String `tfsdk:"encryption"`
+}
+
+// createRepositoryBlock returns the schema of the create_repository block shared by the image resources.
+func createRepositoryBlock() schema.SingleNestedBlock {
+	return schema.SingleNestedBlock{
+		MarkdownDescription: "Create the ECR repository of `image_uri` before building when it does not exist, " +
+			"instead of failing on the push after a full build. The repository is created with the AWS SDK, " +
+			"using the AWS credentials and configuration of the environment (e.g. `AWS_PROFILE`), in the account and region of `image_uri`. " +
+			"The settings apply only to a created repository: an existing repository is left as it is, and the repository is never deleted. " +
+			"Requires an ECR `image_uri`.",
+		Attributes: map[string]schema.Attribute{
+			"image_tag_mutability": schema.StringAttribute{
+				MarkdownDescription: "Tag mutability of the created repository: `MUTABLE` or `IMMUTABLE`. Defaults to `MUTABLE`.",
+				Optional:            true,
+			},
+			"scan_on_push": schema.BoolAttribute{
+				MarkdownDescription: "Whether the created repository scans images on push. Defaults to false.",
+				Optional:            true,
+			},
+			"encryption": schema.StringAttribute{
+				MarkdownDescription: "Encryption type of the created repository: `AES256`, `KMS` (with the AWS managed KMS key) or `KMS_DSSE`. " +
+					"Defaults to `AES256`.",
+				Optional: true,
+			},
+		},
+	}
+}
+
+// validateCreateRepository reports create_repository settings that ECR does not accept,
+// and an image_uri that is not in an ECR registry.
+func validateCreateRepository(createRepository *CreateRepositoryModel, imageURI tfplugintypes.String) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if createRepository == nil {
+		return diags
+	}
+	if mutability := createRepository.ImageTagMutability; !mutability.IsNull() && !mutability.IsUnknown() &&
+		!slices.Contains(ecrtypes.ImageTagMutability("").Values(), ecrtypes.ImageTagMutability(mutability.ValueString())) {
+		diags.AddAttributeError(
+			path.Root("create_repository").AtName("image_tag_mutability"),
+			"Invalid image_tag_mutability",
+			fmt.Sprintf("image_tag_mutability must be one of %v.", ecrtypes.ImageTagMutability("").Values()),
+		)
+	}
+	if encryption := createRepository.Encryption; !encryption.IsNull() && !encryption.IsUnknown() &&
+		!slices.Contains(ecrtypes.EncryptionType("").Values(), ecrtypes.EncryptionType(encryption.ValueString())) {
+		diags.AddAttributeError(
+			path.Root("create_repository").AtName("encryption"),
+			"Invalid encryption",
+			fmt.Sprintf("encryption must be one of %v.", ecrtypes.EncryptionType("").Values()),
+		)
+	}
+	if imageURI.IsNull() || imageURI.IsUnknown() {
+		return diags
+	}
+	host, _, _, err := registryclient.ParseImageReference(imageURI.ValueString())
+	if err != nil {
+		return diags
+	}
+	if _, ok := registryclient.ParseECRHost(host); !ok {
+		diags.AddAttributeError(
+			path.Root("create_repository"),
+			"Repository creation not supported",
+			fmt.Sprintf("create_repository only creates ECR repositories, but image_uri is in %s.", host),
+		)
+	}
+	return diags
+}
+
+// ensureRepository creates the ECR repository of image_uri with the create_repository settings of model
+// when it does not exist yet.
+func (r *ComposeResource) ensureRepository(ctx context.Context, model *ComposeResourceModel) error {
+	if model.CreateRepository == nil {
+		return nil
+	}
+	client, registry, repository, err := newECRClient(ctx, model.ImageURI.ValueString(), "create_repository")
+	if err != nil {
+		return err
+	}
+
+	_, err = client.DescribeRepositories(ctx, &ecr.DescribeRepositoriesInput{
+		RegistryId:      aws.String(registry.Account),
+		RepositoryNames: []string{repository},
+	})
+	if err == nil {
+		tflog.Debug(ctx, "ECR repository already exists", map[string]interface{}{
+			"repository": repository,
+		})
+		return nil
+	}
+	var notFound *ecrtypes.RepositoryNotFoundException
+	if !errors.As(err, &notFound) {
+		return fmt.Errorf("failed to look up ECR repository %s: %w", repository, err)
+	}
+
+	settings := model.CreateRepository
+	input := &ecr.CreateRepositoryInput{
+		RegistryId:     aws.String(registry.Account),
+		RepositoryName: aws.String(repository),
+	}
+	if !settings.ImageTagMutability.IsNull() {
+		input.ImageTagMutability = ecrtypes.ImageTagMutability(settings.ImageTagMutability.ValueString())
+	}
+	if !settings.ScanOnPush.IsNull() {
+		input.ImageScanningConfiguration = &ecrtypes.ImageScanningConfiguration{ScanOnPush: settings.ScanOnPush.ValueBool()}
+	}
+	if !settings.Encryption.IsNull() {
+		input.EncryptionConfiguration = &ecrtypes.EncryptionConfiguration{EncryptionType: ecrtypes.EncryptionType(settings.Encryption.ValueString())}
+	}
+	if _, err := client.CreateRepository(ctx, input); err != nil {
+		// Another resource pushing to the same repository may have created it in the meantime
+		var exists *ecrtypes.RepositoryAlreadyExistsException
+		if errors.As(err, &exists) {
+			return nil
+		}
+		return fmt.Errorf("failed to create ECR repository %s: %w", repository, err)
+	}
+	tflog.Info(ctx, "Created ECR repository", map[string]interface{}{
+		"repository": repository,
+		"region":     registry.Region,
+	})
+	return nil
+}
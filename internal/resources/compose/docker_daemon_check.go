@@ -0,0 +1,121 @@
+package compose
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// dockerDaemonCheckTimeout bounds the probe of the Docker daemon, e.g. an ssh:// docker_host that does not answer.
+const dockerDaemonCheckTimeout = 10 * time.Second
+
+// dockerDaemonError is the failure to reach the Docker daemon at endpoint.
+type dockerDaemonError struct {
+	endpoint string
+	err      error
+}
+
+func (e *dockerDaemonError) Error() string {
+	// The Docker client already names the address it failed to connect to
+	if strings.Contains(e.err.Error(), e.endpoint) {
+		return e.err.Error()
+	}
+	return fmt.Sprintf("cannot connect to the Docker daemon at %s: %v", e.endpoint, e.err)
+}
+
+func (e *dockerDaemonError) Unwrap() error {
+	return e.err
+}
+
+// pingDockerDaemon checks that the Docker daemon used to build and push images answers.
+func (r *ComposeResource) pingDockerDaemon(ctx context.Context) error {
+	tflog.Debug(ctx, "Checking Docker daemon availability")
+	dockerClient, err := r.newDockerClient("")
+	if err != nil {
+		return &dockerDaemonError{endpoint: r.dockerDaemonEndpoint(nil), err: err}
+	}
+	defer dockerClient.Close()
+	ctx, cancel := context.WithTimeout(ctx, dockerDaemonCheckTimeout)
+	defer cancel()
+	if _, err := dockerClient.Ping(ctx); err != nil {
+		return &dockerDaemonError{endpoint: r.dockerDaemonEndpoint(dockerClient), err: err}
+	}
+	return nil
+}
+
+// dockerDaemonEndpoint describes the Docker daemon address tried by pingDockerDaemon.
+// dockerClient is nil when the client could not be created.
+func (r *ComposeResource) dockerDaemonEndpoint(dockerClient *client.Client) string {
+	if name := r.dockerContext(""); name != "" {
+		if endpoint, err := dockerContextEndpoint(name); err == nil {
+			return fmt.Sprintf("%s (Docker context %q)", endpoint.Host, name)
+		}
+		return fmt.Sprintf("Docker context %q", name)
+	}
+	if host := r.dockerHost(""); host != "" {
+		return host
+	}
+	if dockerClient != nil {
+		return dockerClient.DaemonHost()
+	}
+	if host := os.Getenv(client.EnvOverrideHost); host != "" {
+		return host
+	}
+	return client.DefaultDockerHost
+}
+
+// dockerDaemonErrorDetail returns the detail of the diagnostic reporting err from pingDockerDaemon,
+// with what to check and how to select another daemon.
+func dockerDaemonErrorDetail(err error) string {
+	return fmt.Sprintf("%s\n\n"+
+		"Images are built and pushed through the Docker daemon. Check that the daemon is running "+
+		"(`docker info` works as the user running Terraform) and that this user may access it: "+
+		"read and write permission on a unix:// socket (usually membership of the docker group, or a rootless daemon), "+
+		"SSH access to the host and its Docker socket for ssh://, and the certificates in docker_cert_path for tcp:// with TLS.\n\n"+
+		"To use another daemon, set docker_host (with docker_cert_path) or docker_context in the provider configuration, "+
+		"or DOCKER_HOST or DOCKER_CONTEXT in the environment. "+
+		"Set check_docker_daemon = false in the provider configuration to skip this check, e.g. when the daemon is started during the apply.", err)
+}
+
+// planDockerDaemonCheck fails the plan of a build using the Docker daemon when the daemon cannot be reached,
+// rather than letting the apply fail after other resources are changed. The daemon is probed once per provider
+// configuration, by the first resource planning such a build.
+// rebuilt is the computed attribute left unknown in the plan when the image is built.
+func (r *ComposeResource) planDockerDaemonCheck(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse, model *ComposeResourceModel, rebuilt string) {
+	if r.providerConfig == nil || r.providerConfig.DockerDaemonCheck == nil || !usesDockerDaemon(model) {
+		return
+	}
+	value, _, err := tftypes.WalkAttributePath(resp.Plan.Raw, tftypes.NewAttributePath().WithAttributeName(rebuilt))
+	if planned, ok := value.(tftypes.Value); err != nil || !ok || planned.IsKnown() {
+		return
+	}
+	// Rewriting the labels in the registry does not need the daemon
+	if !req.State.Raw.IsNull() && model.OnLabelChange.ValueString() == labelChangeMutate {
+		if ok, err := unbuiltChange(req.State.Raw, resp.Plan.Raw, rebuiltImageAttributes(), "labels"); err == nil && ok {
+			return
+		}
+	}
+	err = r.providerConfig.DockerDaemonCheck.Do(func() error {
+		return r.pingDockerDaemon(ctx)
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("Docker daemon not reachable", dockerDaemonErrorDetail(err))
+	}
+}
+
+// usesDockerDaemon reports whether building model needs the Docker daemon: kaniko, Podman,
+// and images pushed from an OCI layout or a tarball do without it.
+func usesDockerDaemon(model *ComposeResourceModel) bool {
+	switch model.Builder.ValueString() {
+	case builderKaniko, builderPodman:
+		return false
+	}
+	return model.SourceOCILayout.IsNull() && model.SourceTarball.IsNull()
+}
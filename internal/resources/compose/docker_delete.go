@@ -3,23 +3,34 @@ package compose
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/distribution/reference"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 
-	"github.com/ikedam/terraform-provider-containerregistry/internal/logging"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
 )
 
-// deleteImageFromRegistry deletes an image from a remote registry
+var (
+	// errImageAlreadyDeleted is returned when the image to delete no longer exists in the registry.
+	errImageAlreadyDeleted = errors.New("image does not exist in the registry")
+	// errImageDeletionUnsupported is returned when the registry does not allow deleting manifests.
+	errImageDeletionUnsupported = errors.New("the registry does not support deleting images")
+)
+
+// deleteImageFromRegistry deletes an image from a remote registry.
+// It returns an error wrapping errImageAlreadyDeleted when the image was already removed,
+// and one wrapping errImageDeletionUnsupported when the registry rejects deletion.
 func (r *ComposeResource) deleteImageFromRegistry(ctx context.Context, model *ComposeResourceModel) error {
 	tflog.Info(ctx, "Deleting image from registry", map[string]interface{}{
 		"image_uri": model.ImageURI.ValueString(),
 	})
 
 	// Parse the image reference to extract registry, repository, and tag/digest information
-	imageURI := model.ImageURI.ValueString()
+	// Images pushed by digest or with a suffixed tag are not found with the tag of image_uri
+	imageURI := registryImageURI(model)
 	ref, err := reference.ParseAnyReference(imageURI)
 	if err != nil {
 		return fmt.Errorf("invalid image URI format: %w", err)
@@ -64,8 +75,8 @@ func (r *ComposeResource) deleteFromDockerRegistry(ctx context.Context, ref refe
 		"digest":     digest,
 	})
 
-	// Create HTTP client with Terraform logging transport
-	client := logging.NewHTTPLoggingClient()
+	// Create HTTP client with Terraform logging transport and rate-limit retries
+	client := registryclient.NewHTTPClient()
 	var url string
 
 	if digest != "" {
@@ -98,12 +109,14 @@ func (r *ComposeResource) deleteFromDockerRegistry(ctx context.Context, ref refe
 		}
 		defer resp.Body.Close()
 
-		if resp.StatusCode == http.StatusUnauthorized {
-			return fmt.Errorf("authentication failed for registry: %s", registry)
-		}
-
-		if resp.StatusCode != http.StatusOK {
-			return fmt.Errorf("failed to get manifest, status: %d", resp.StatusCode)
+		if err := registryclient.CheckResponse(resp, "get manifest"); err != nil {
+			if registryclient.IsNotFound(err) {
+				return fmt.Errorf("%w: %w", errImageAlreadyDeleted, err)
+			}
+			if resp.StatusCode == http.StatusUnauthorized {
+				return fmt.Errorf("authentication failed for registry %s: %w", registry, err)
+			}
+			return err
 		}
 
 		// Extract the digest from the Docker-Content-Digest header
@@ -153,12 +166,17 @@ func (r *ComposeResource) deleteFromDockerRegistry(ctx context.Context, ref refe
 	defer resp.Body.Close()
 
 	// Check response
-	if resp.StatusCode == http.StatusUnauthorized {
-		return fmt.Errorf("authentication failed for registry: %s", registry)
-	}
-
-	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to delete image, status: %d", resp.StatusCode)
+	if err := registryclient.CheckResponse(resp, "delete image"); err != nil {
+		if registryclient.IsNotFound(err) {
+			return fmt.Errorf("%w: %w", errImageAlreadyDeleted, err)
+		}
+		if registryclient.IsUnsupported(err) {
+			return fmt.Errorf("%w: %w", errImageDeletionUnsupported, err)
+		}
+		if resp.StatusCode == http.StatusUnauthorized {
+			return fmt.Errorf("authentication failed for registry %s: %w", registry, err)
+		}
+		return err
 	}
 
 	tflog.Info(ctx, "Successfully deleted image from registry", map[string]interface{}{
@@ -0,0 +1,179 @@
+package compose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	ecrtypes "github.com/aws/aws-sdk-go-v2/service/ecr/types"
+	"github.com/distribution/reference"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	tfplugintypes "github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// Values of delete_image_scope: what delete_image removes from the registry when the resource is destroyed.
+const (
+	deleteScopeTag        = "tag"
+	deleteScopeDigest     = "digest"
+	deleteScopeRepository = "repository"
+)
+
+// deleteImageScopeAttribute returns the schema of the delete_image_scope attribute shared by the image resources.
+func deleteImageScopeAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		MarkdownDescription: "What `delete_image` removes from the registry when the resource is destroyed: " +
+			"`digest` deletes the manifest of the image, removing every tag pointing to it, " +
+			"`tag` only removes the pushed tag, leaving the image to other tags, " +
+			"and `repository` deletes the whole ECR repository with all its images (ECR only). " +
+			"Defaults to `digest`. An image pushed with `push_by_digest` has no tag and is deleted by digest.",
+		Optional: true,
+	}
+}
+
+// validateDeleteImageScope reports an unsupported delete_image_scope, and the repository scope with an image_uri
+// that is not in an ECR registry. imageURI is null when the image URIs are not known from the configuration.
+func validateDeleteImageScope(scope, imageURI tfplugintypes.String) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if scope.IsNull() || scope.IsUnknown() {
+		return diags
+	}
+	switch scope.ValueString() {
+	case deleteScopeTag, deleteScopeDigest:
+	case deleteScopeRepository:
+		if imageURI.IsNull() || imageURI.IsUnknown() {
+			return diags
+		}
+		host, _, _, err := registryclient.ParseImageReference(imageURI.ValueString())
+		if err != nil {
+			return diags
+		}
+		if _, ok := registryclient.ParseECRHost(host); !ok {
+			diags.AddAttributeError(
+				path.Root("delete_image_scope"),
+				"Repository deletion not supported",
+				fmt.Sprintf("delete_image_scope = %q only deletes ECR repositories, but image_uri is in %s.", deleteScopeRepository, host),
+			)
+		}
+	default:
+		diags.AddAttributeError(
+			path.Root("delete_image_scope"),
+			"Invalid delete_image_scope",
+			fmt.Sprintf("delete_image_scope must be %q, %q or %q.", deleteScopeTag, deleteScopeDigest, deleteScopeRepository),
+		)
+	}
+	return diags
+}
+
+// deleteImageInScope deletes the image of model from the registry in the scope of delete_image_scope.
+// Errors wrap errImageAlreadyDeleted and errImageDeletionUnsupported as deleteImageFromRegistry does.
+func (r *ComposeResource) deleteImageInScope(ctx context.Context, model *ComposeResourceModel) error {
+	switch model.DeleteImageScope.ValueString() {
+	case deleteScopeTag:
+		return r.deleteTagFromRegistry(ctx, model)
+	case deleteScopeRepository:
+		return deleteECRRepository(ctx, registryImageURI(model))
+	default:
+		return r.deleteImageFromRegistry(ctx, model)
+	}
+}
+
+// deleteTagFromRegistry removes the pushed tag of model from the registry, leaving the image to its other tags.
+// ECR tags are removed with the ECR API, which deletes the image along with its last tag.
+// An image pushed by digest has no tag and is deleted by digest.
+func (r *ComposeResource) deleteTagFromRegistry(ctx context.Context, model *ComposeResourceModel) error {
+	imageURI := registryImageURI(model)
+	named, err := reference.ParseNormalizedNamed(imageURI)
+	if err != nil {
+		return fmt.Errorf("invalid image URI format: %w", err)
+	}
+	if _, ok := named.(reference.Canonical); ok {
+		return r.deleteImageFromRegistry(ctx, model)
+	}
+	host, repository, tag, err := registryclient.ParseImageReference(imageURI)
+	if err != nil {
+		return err
+	}
+	tflog.Info(ctx, "Deleting tag from registry", map[string]interface{}{
+		"image_uri": imageURI,
+	})
+
+	if registry, ok := registryclient.ParseECRHost(host); ok {
+		client, _, _, err := newECRClient(ctx, imageURI, "delete_image")
+		if err != nil {
+			return err
+		}
+		out, err := client.BatchDeleteImage(ctx, &ecr.BatchDeleteImageInput{
+			RegistryId:     aws.String(registry.Account),
+			RepositoryName: aws.String(repository),
+			ImageIds:       []ecrtypes.ImageIdentifier{{ImageTag: aws.String(tag)}},
+		})
+		var notFound *ecrtypes.RepositoryNotFoundException
+		if errors.As(err, &notFound) {
+			return fmt.Errorf("%w: %w", errImageAlreadyDeleted, err)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to delete tag %s: %w", imageURI, err)
+		}
+		for _, failure := range out.Failures {
+			if failure.FailureCode == ecrtypes.ImageFailureCodeImageNotFound {
+				return fmt.Errorf("%w: %s", errImageAlreadyDeleted, aws.ToString(failure.FailureReason))
+			}
+			return fmt.Errorf("failed to delete tag %s: %s: %s", imageURI, failure.FailureCode, aws.ToString(failure.FailureReason))
+		}
+		return nil
+	}
+
+	c, err := registryclient.New(r.providerConfig, host)
+	if err != nil {
+		return err
+	}
+	if err := c.DeleteManifest(ctx, repository, tag); err != nil {
+		if registryclient.IsNotFound(err) {
+			return fmt.Errorf("%w: %w", errImageAlreadyDeleted, err)
+		}
+		// Distribution only deletes manifests by digest and rejects a tag with DIGEST_INVALID
+		if registryclient.IsUnsupported(err) || isDigestInvalid(err) {
+			return fmt.Errorf("%w: %w", errImageDeletionUnsupported, err)
+		}
+		return fmt.Errorf("failed to delete tag %s: %w", imageURI, err)
+	}
+	return nil
+}
+
+// isDigestInvalid reports whether err is a 400 DIGEST_INVALID response of the registry.
+func isDigestInvalid(err error) bool {
+	respErr, ok := registryclient.AsResponseError(err)
+	return ok && respErr.StatusCode == http.StatusBadRequest && respErr.HasCode("DIGEST_INVALID")
+}
+
+// deleteECRRepository deletes the ECR repository of imageURI with all its images.
+func deleteECRRepository(ctx context.Context, imageURI string) error {
+	client, registry, repository, err := newECRClient(ctx, imageURI, fmt.Sprintf("delete_image_scope = %q", deleteScopeRepository))
+	if err != nil {
+		return err
+	}
+	tflog.Info(ctx, "Deleting ECR repository", map[string]interface{}{
+		"repository": repository,
+	})
+	_, err = client.DeleteRepository(ctx, &ecr.DeleteRepositoryInput{
+		RegistryId:     aws.String(registry.Account),
+		RepositoryName: aws.String(repository),
+		Force:          true,
+	})
+	var notFound *ecrtypes.RepositoryNotFoundException
+	if errors.As(err, &notFound) {
+		return fmt.Errorf("%w: %w", errImageAlreadyDeleted, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete ECR repository %s: %w", repository, err)
+	}
+	return nil
+}
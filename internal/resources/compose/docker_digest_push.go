@@ -0,0 +1,112 @@
+package compose
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/distribution/reference"
+	"github.com/docker/docker/client"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	tfplugintypes "github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// throwawayTagPrefix prefixes the temporary tag with which push_by_digest pushes a local image.
+const throwawayTagPrefix = "push-"
+
+// pushByDigestAttribute returns the schema of the push_by_digest attribute shared by the image resources.
+func pushByDigestAttribute() schema.BoolAttribute {
+	return schema.BoolAttribute{
+		MarkdownDescription: "Whether to push the image to the repository of `image_uri` by digest only, without leaving the tag of `image_uri` " +
+			"in the registry, for pipelines promoting images by digest. Refer to the image as `<repository>@<sha256_digest>`. " +
+			"Images in the Docker daemon are pushed with a temporary tag, which is removed from the registry after the push " +
+			"(and left in place when the registry does not support deleting tags). " +
+			"Tags can still be applied with `additional_tags`.",
+		Optional: true,
+	}
+}
+
+// validatePushByDigest reports the attributes that cannot be used with push_by_digest, as they push image_uri with its tag.
+func validatePushByDigest(pushByDigest tfplugintypes.Bool, builder tfplugintypes.String, directPush tfplugintypes.Bool) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if !pushByDigest.ValueBool() {
+		return diags
+	}
+	if builder.ValueString() == builderKaniko {
+		diags.AddAttributeError(
+			path.Root("push_by_digest"),
+			"Push by digest not supported",
+			"push_by_digest cannot be used with builder = \"kaniko\", which pushes image_uri with its tag.",
+		)
+	}
+	if directPush.ValueBool() {
+		diags.AddAttributeError(
+			path.Root("push_by_digest"),
+			"Push by digest not supported",
+			"push_by_digest cannot be used with direct_push, with which BuildKit pushes image_uri with its tag.",
+		)
+	}
+	return diags
+}
+
+// pushImageByDigest pushes the local image tagged as image_uri with a temporary tag in the same repository,
+// records the pushed manifest digest into model, and removes the temporary tag from the registry and the daemon.
+func (r *ComposeResource) pushImageByDigest(ctx context.Context, dockerClient *client.Client, model *ComposeResourceModel, metrics *buildMetrics) error {
+	named, err := reference.ParseNormalizedNamed(model.ImageURI.ValueString())
+	if err != nil {
+		return fmt.Errorf("invalid image URI format: %w", err)
+	}
+	tagged, err := reference.WithTag(reference.TrimNamed(named), throwawayTagPrefix+generateUUID())
+	if err != nil {
+		return err
+	}
+	throwaway := tagged.String()
+	digest, err := r.pushLocalImageAs(ctx, dockerClient, model, throwaway, metrics)
+	if err != nil {
+		return err
+	}
+	model.SHA256Digest = tfplugintypes.StringValue(digest)
+	if err := r.deleteThrowawayTag(ctx, throwaway, digest); err != nil {
+		return err
+	}
+	tflog.Info(ctx, "Pushed image by digest", map[string]interface{}{
+		"image_uri": model.ImageURI.ValueString(),
+		"digest":    digest,
+	})
+	return r.recordAttestations(ctx, model)
+}
+
+// deleteThrowawayTag removes the temporary tag throwaway from the registry, and verifies that the image digest is still there,
+// as a few registries delete the manifest along with the tag.
+// The tag is kept with a warning when the registry does not support deleting tags.
+func (r *ComposeResource) deleteThrowawayTag(ctx context.Context, throwaway, digest string) error {
+	host, repository, tag, err := registryclient.ParseImageReference(throwaway)
+	if err != nil {
+		return err
+	}
+	c, err := registryclient.New(r.providerConfig, host)
+	if err != nil {
+		return err
+	}
+	if err := c.DeleteManifest(ctx, repository, tag); err != nil {
+		if !registryclient.IsUnsupported(err) {
+			return fmt.Errorf("failed to delete temporary tag %s: %w", throwaway, err)
+		}
+		tflog.Warn(ctx, "The registry does not support deleting tags: leaving the temporary tag", map[string]interface{}{
+			"tag":   throwaway,
+			"error": err.Error(),
+		})
+		return nil
+	}
+	if _, err := c.GetManifest(ctx, repository, digest); err != nil {
+		if registryclient.IsNotFound(err) {
+			return fmt.Errorf("the registry deleted the image %s along with the temporary tag %s: push_by_digest cannot be used with this registry", digest, throwaway)
+		}
+		return fmt.Errorf("failed to confirm the image after deleting the temporary tag: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package compose
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	tfplugintypes "github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// bakeDigestKey is the key of the pushed manifest digest in the build result metadata written by buildx bake.
+const bakeDigestKey = "containerimage.digest"
+
+// directPushAttribute returns the schema of the direct_push attribute shared by the image resources.
+func directPushAttribute() schema.BoolAttribute {
+	return schema.BoolAttribute{
+		MarkdownDescription: "Whether BuildKit pushes the image to the registry itself (`--output type=registry`), " +
+			"instead of loading it into the Docker daemon and pushing it from there. " +
+			"This saves the round trip through the daemon, and `sha256_digest` is taken from the build result. " +
+			"The image is not kept in the local Docker daemon, so `export`, `load_into` and `squash` cannot be used. " +
+			"Requires BuildKit with the buildx plugin.",
+		Optional: true,
+	}
+}
+
+// validateDirectPush reports the attributes that cannot be used with direct_push, as they need the image in the Docker daemon
+// or a builder other than BuildKit.
+func validateDirectPush(directPush tfplugintypes.Bool, builder tfplugintypes.String, squash tfplugintypes.Bool) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if !directPush.ValueBool() {
+		return diags
+	}
+	if b := builder.ValueString(); b == builderClassic || b == builderPodman || b == builderKaniko {
+		diags.AddAttributeError(
+			path.Root("direct_push"),
+			"Direct push requires BuildKit",
+			fmt.Sprintf("direct_push cannot be used with builder = %q.", b),
+		)
+	}
+	if squash.ValueBool() {
+		diags.AddAttributeError(
+			path.Root("direct_push"),
+			"Local image required",
+			"squash merges the layers of the image in the Docker daemon and cannot be used with direct_push.",
+		)
+	}
+	return diags
+}
+
+// directPushWrapper returns the buildx bake wrapper for a build pushed by BuildKit: the build result metadata is kept
+// for the pushed digest, and bake authenticates to the registries with the provider registry_auth credentials.
+func (r *ComposeResource) directPushWrapper(allow []string) *bakeWrapper {
+	wrapper := &bakeWrapper{allow: allow, captureMetadata: true}
+	if r.providerConfig != nil {
+		wrapper.registryAuth = r.providerConfig.RegistryAuth
+	}
+	return wrapper
+}
+
+// recordDirectPushDigest records into model the digest of the manifest BuildKit pushed, read from the build result metadata
+// in metadataFile, or looked up in the registry when the metadata has no digest.
+func (r *ComposeResource) recordDirectPushDigest(ctx context.Context, model *ComposeResourceModel, metadataFile string) error {
+	digest, err := readBakeDigest(metadataFile)
+	if err != nil {
+		tflog.Debug(ctx, "Could not read the pushed digest from the build result", map[string]interface{}{
+			"error": err.Error(),
+		})
+		imageInfo, err := r.getImageInfoFromRegistry(ctx, model)
+		if err != nil {
+			return fmt.Errorf("failed to get image digest after push: %w", err)
+		}
+		digest = imageInfo.ManifestDigest
+	} else if err := r.verifyRegistryDigest(ctx, model.ImageURI.ValueString(), digest); err != nil {
+		return err
+	}
+	if digest == "" {
+		return errors.New("manifest digest is empty")
+	}
+	model.SHA256Digest = tfplugintypes.StringValue(digest)
+	tflog.Info(ctx, "Successfully built and pushed image with BuildKit", map[string]interface{}{
+		"image_uri": model.ImageURI.ValueString(),
+		"digest":    digest,
+	})
+	return r.recordAttestations(ctx, model)
+}
+
+// readBakeDigest returns the pushed manifest digest in the build result metadata of buildx bake,
+// which has an entry per target; the provider builds a single target.
+func readBakeDigest(metadataFile string) (string, error) {
+	body, err := os.ReadFile(metadataFile)
+	if err != nil {
+		return "", err
+	}
+	var metadata map[string]json.RawMessage
+	if err := json.Unmarshal(body, &metadata); err != nil {
+		return "", fmt.Errorf("failed to decode build result metadata: %w", err)
+	}
+	for _, target := range metadata {
+		var result map[string]json.RawMessage
+		if err := json.Unmarshal(target, &result); err != nil {
+			// Entries such as buildx.build.warnings are not targets
+			continue
+		}
+		var digest string
+		if err := json.Unmarshal(result[bakeDigestKey], &digest); err == nil && digest != "" {
+			return digest, nil
+		}
+	}
+	return "", fmt.Errorf("no %s in build result metadata", bakeDigestKey)
+}
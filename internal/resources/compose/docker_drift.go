@@ -0,0 +1,150 @@
+package compose
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	tfplugintypes "github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Values of on_drift: what to do when a refresh finds the tag of the image overwritten out of band.
+const (
+	driftIgnore  = "ignore"
+	driftRebuild = "rebuild"
+	driftFail    = "fail"
+)
+
+// driftPrivateKey is the key of the private state recording the images found overwritten by the last refresh
+// with on_drift = "rebuild", for ModifyPlan to plan a rebuild.
+const driftPrivateKey = "drifted_images"
+
+// imageDrift is an image whose tag the registry resolves to another digest than the one the resource pushed.
+type imageDrift struct {
+	ImageURI string `json:"image_uri"`
+	Recorded string `json:"recorded"`
+	Current  string `json:"current"`
+}
+
+// privateState is the private state of a resource (resp.Private of Create, Read and Update).
+type privateState interface {
+	GetKey(ctx context.Context, key string) ([]byte, diag.Diagnostics)
+	SetKey(ctx context.Context, key string, value []byte) diag.Diagnostics
+}
+
+// onDriftAttribute returns the schema of the on_drift attribute shared by the image resources.
+func onDriftAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		MarkdownDescription: "What to do when a refresh finds the tag of the pushed image pointing to another image (e.g. overwritten by another pipeline): " +
+			"`ignore` records the digest of the image in the registry in `sha256_digest`, " +
+			"`rebuild` keeps the pushed digest and plans to build and push the image again, and `fail` fails the refresh with a diagnostic. " +
+			"Defaults to `ignore`.",
+		Optional: true,
+	}
+}
+
+// validateOnDrift reports an on_drift attribute with an unsupported value.
+func validateOnDrift(onDrift tfplugintypes.String) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if onDrift.IsNull() || onDrift.IsUnknown() {
+		return diags
+	}
+	switch onDrift.ValueString() {
+	case driftIgnore, driftRebuild, driftFail:
+	default:
+		diags.AddAttributeError(
+			path.Root("on_drift"),
+			"Invalid on_drift",
+			fmt.Sprintf("on_drift must be %q, %q or %q.", driftIgnore, driftRebuild, driftFail),
+		)
+	}
+	return diags
+}
+
+// detectDrift returns the drift of the image at imageURI when the registry has current instead of the recorded digest.
+// Nothing is compared before a digest is recorded, e.g. right after an import.
+func detectDrift(imageURI string, recorded tfplugintypes.String, current string) (imageDrift, bool) {
+	if recorded.IsNull() || recorded.IsUnknown() || recorded.ValueString() == "" || current == "" || recorded.ValueString() == current {
+		return imageDrift{}, false
+	}
+	return imageDrift{
+		ImageURI: imageURI,
+		Recorded: recorded.ValueString(),
+		Current:  current,
+	}, true
+}
+
+// applyOnDrift handles the drifts found by a refresh as on_drift tells, and reports whether the refresh may record
+// the digests in the registry. With "rebuild" the drifts are kept in the private state for ModifyPlan,
+// and with "fail" an error is reported; both keep the recorded digests.
+func applyOnDrift(ctx context.Context, onDrift tfplugintypes.String, drifts []imageDrift, private privateState) (bool, diag.Diagnostics) {
+	// Drifts recorded by an earlier refresh are resolved or reported again below
+	diags := private.SetKey(ctx, driftPrivateKey, nil)
+	if len(drifts) == 0 || diags.HasError() {
+		return true, diags
+	}
+	for _, drift := range drifts {
+		tflog.Warn(ctx, "Image tag overwritten out of band", map[string]interface{}{
+			"image_uri": drift.ImageURI,
+			"recorded":  drift.Recorded,
+			"current":   drift.Current,
+			"on_drift":  onDrift.ValueString(),
+		})
+	}
+	switch onDrift.ValueString() {
+	case driftFail:
+		diags.AddError("Image tag overwritten", describeDrifts(drifts)+
+			"\n\nSet on_drift = \"rebuild\" to push the image again, or on_drift = \"ignore\" to accept the image in the registry.")
+		return false, diags
+	case driftRebuild:
+		body, err := json.Marshal(drifts)
+		if err != nil {
+			diags.AddError("Error recording drift", err.Error())
+			return false, diags
+		}
+		diags.Append(private.SetKey(ctx, driftPrivateKey, body)...)
+		return false, diags
+	default:
+		return true, diags
+	}
+}
+
+// planDriftRebuild plans a rebuild when the last refresh found the tag overwritten and on_drift is still "rebuild",
+// marking the attributes written by the build unknown.
+func planDriftRebuild(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse, rebuilt map[string]attr.Value) {
+	body, diags := req.Private.GetKey(ctx, driftPrivateKey)
+	resp.Diagnostics.Append(diags...)
+	if len(body) == 0 || resp.Diagnostics.HasError() {
+		return
+	}
+	var onDrift tfplugintypes.String
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("on_drift"), &onDrift)...)
+	if resp.Diagnostics.HasError() || onDrift.ValueString() != driftRebuild {
+		return
+	}
+	var drifts []imageDrift
+	if err := json.Unmarshal(body, &drifts); err != nil {
+		resp.Diagnostics.AddError("Error reading drift", err.Error())
+		return
+	}
+	resp.Diagnostics.AddWarning("Image tag overwritten", describeDrifts(drifts)+"\n\nThe image is built and pushed again (on_drift = \"rebuild\").")
+	for name, unknown := range rebuilt {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root(name), unknown)...)
+	}
+}
+
+// describeDrifts describes drifts for diagnostics.
+func describeDrifts(drifts []imageDrift) string {
+	lines := make([]string, 0, len(drifts))
+	for _, drift := range drifts {
+		lines = append(lines, fmt.Sprintf("%s points to %s instead of the pushed %s.", drift.ImageURI, drift.Current, drift.Recorded))
+	}
+	return strings.Join(lines, "\n")
+}
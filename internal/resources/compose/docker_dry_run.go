@@ -0,0 +1,36 @@
+package compose
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	tfplugintypes "github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// dryRunAttribute returns the schema of the dry_run attribute shared by the image resources.
+func dryRunAttribute() schema.BoolAttribute {
+	return schema.BoolAttribute{
+		MarkdownDescription: "Whether to only build the image, validating the Dockerfile and the build context, without pushing it, " +
+			"e.g. in pull request pipelines that must not publish artifacts. The registry is not consulted at all: " +
+			"`sha256_digest` is the ID of the local image, and the attributes acting on the pushed image " +
+			"(`additional_tags`, `create_repository`, `wait_for_*`, `on_push`, `delete_image`) are ignored. " +
+			"Cannot be used with `builder = \"kaniko\"` or `direct_push`, which push the image while building.",
+		Optional: true,
+	}
+}
+
+// validateDryRun reports the attributes that cannot be used with dry_run, as they push the image while building.
+func validateDryRun(dryRun tfplugintypes.Bool, builder tfplugintypes.String, directPush tfplugintypes.Bool) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if !dryRun.ValueBool() {
+		return diags
+	}
+	if builder.ValueString() == builderKaniko || directPush.ValueBool() {
+		diags.AddAttributeError(
+			path.Root("dry_run"),
+			"Dry run not supported",
+			"dry_run cannot be used with builder = \"kaniko\" or direct_push, which push the image while building.",
+		)
+	}
+	return diags
+}
@@ -0,0 +1,30 @@
+package compose
+
+import (
+	"context"
+	"fmt"
+
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// newECRClient returns an ECR API client for the registry of imageURI, with the AWS credentials and configuration
+// of the environment, along with the registry and the repository of imageURI.
+// attribute names the setting that calls the ECR API, for errors.
+func newECRClient(ctx context.Context, imageURI, attribute string) (*ecr.Client, registryclient.ECRRegistry, string, error) {
+	host, repository, _, err := registryclient.ParseImageReference(imageURI)
+	if err != nil {
+		return nil, registryclient.ECRRegistry{}, "", err
+	}
+	registry, ok := registryclient.ParseECRHost(host)
+	if !ok {
+		return nil, registryclient.ECRRegistry{}, "", fmt.Errorf("%s requires an ECR repository, but image_uri is in %s", attribute, host)
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(registry.Region))
+	if err != nil {
+		return nil, registryclient.ECRRegistry{}, "", fmt.Errorf("failed to load AWS configuration for %s: %w", attribute, err)
+	}
+	return ecr.NewFromConfig(cfg), registry, repository, nil
+}
@@ -0,0 +1,157 @@
+package compose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	ecrtypes "github.com/aws/aws-sdk-go-v2/service/ecr/types"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	tfplugintypes "github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+const (
+	// replicationPollInterval is the interval between checks of the replicated repositories.
+	replicationPollInterval = 10 * time.Second
+	// replicationTimeout is how long to wait for the pushed image to be replicated to all the regions of wait_for_replication.
+	replicationTimeout = 30 * time.Minute
+)
+
+// waitForReplicationAttribute returns the schema of the wait_for_replication attribute shared by the image resources.
+func waitForReplicationAttribute() schema.ListAttribute {
+	return schema.ListAttribute{
+		MarkdownDescription: "AWS regions (e.g. `eu-west-1`) the ECR registry of `image_uri` replicates the repository to. " +
+			"After the push, the apply waits until the pushed image is available in the repository of the same name in each region " +
+			"(for up to 30 minutes, and within `timeouts`), so that deployments in those regions in the same apply do not race the replication. " +
+			"The repositories are read with the AWS SDK, using the AWS credentials and configuration of the environment. " +
+			"Requires an ECR `image_uri`.",
+		Optional:    true,
+		ElementType: tfplugintypes.StringType,
+	}
+}
+
+// validateWaitForReplication reports empty regions, and an image_uri that is not in an ECR registry
+// or whose region is listed in waitForReplication.
+func validateWaitForReplication(ctx context.Context, waitForReplication tfplugintypes.List, imageURI tfplugintypes.String) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if waitForReplication.IsNull() || waitForReplication.IsUnknown() {
+		return diags
+	}
+	var regions []tfplugintypes.String
+	diags.Append(waitForReplication.ElementsAs(ctx, &regions, false)...)
+	if diags.HasError() {
+		return diags
+	}
+	for i, region := range regions {
+		if !region.IsUnknown() && region.ValueString() == "" {
+			diags.AddAttributeError(
+				path.Root("wait_for_replication").AtListIndex(i),
+				"Invalid region",
+				"wait_for_replication must not contain empty regions.",
+			)
+		}
+	}
+	if imageURI.IsNull() || imageURI.IsUnknown() {
+		return diags
+	}
+	host, _, _, err := registryclient.ParseImageReference(imageURI.ValueString())
+	if err != nil {
+		return diags
+	}
+	registry, ok := registryclient.ParseECRHost(host)
+	if !ok {
+		diags.AddAttributeError(
+			path.Root("wait_for_replication"),
+			"Replication not supported",
+			fmt.Sprintf("wait_for_replication waits for ECR replication, but image_uri is in %s.", host),
+		)
+		return diags
+	}
+	for i, region := range regions {
+		if region.ValueString() == registry.Region {
+			diags.AddAttributeError(
+				path.Root("wait_for_replication").AtListIndex(i),
+				"Invalid region",
+				fmt.Sprintf("%s is the region of image_uri, which the image is pushed to.", registry.Region),
+			)
+		}
+	}
+	return diags
+}
+
+// waitForReplication waits until the image pushed as sha256_digest of model is available in the repository of image_uri
+// in each region of wait_for_replication.
+func (r *ComposeResource) waitForReplication(ctx context.Context, model *ComposeResourceModel) error {
+	if model.WaitForReplication.IsNull() || model.WaitForReplication.IsUnknown() {
+		return nil
+	}
+	var regions []string
+	if diags := model.WaitForReplication.ElementsAs(ctx, &regions, false); diags.HasError() {
+		return errors.New("failed to read wait_for_replication")
+	}
+	if len(regions) == 0 {
+		return nil
+	}
+	client, registry, repository, err := newECRClient(ctx, model.ImageURI.ValueString(), "wait_for_replication")
+	if err != nil {
+		return err
+	}
+	digest := model.SHA256Digest.ValueString()
+
+	ctx, cancel := context.WithTimeout(ctx, replicationTimeout)
+	defer cancel()
+	for _, region := range regions {
+		replica := ecr.New(client.Options(), func(o *ecr.Options) {
+			o.Region = region
+		})
+		if err := waitForReplicatedImage(ctx, replica, registry.Account, repository, digest); err != nil {
+			return fmt.Errorf("image %s was not replicated to %s: %w", digest, region, err)
+		}
+		tflog.Info(ctx, "Image replicated", map[string]interface{}{
+			"repository": repository,
+			"region":     region,
+			"digest":     digest,
+		})
+	}
+	return nil
+}
+
+// waitForReplicatedImage polls the repository of client until it has the image digest.
+func waitForReplicatedImage(ctx context.Context, client *ecr.Client, account, repository, digest string) error {
+	for {
+		_, err := client.DescribeImages(ctx, &ecr.DescribeImagesInput{
+			RegistryId:     aws.String(account),
+			RepositoryName: aws.String(repository),
+			ImageIds:       []ecrtypes.ImageIdentifier{{ImageDigest: aws.String(digest)}},
+		})
+		if err == nil {
+			return nil
+		}
+		// Replication creates the repository along with the first replicated image
+		var imageNotFound *ecrtypes.ImageNotFoundException
+		var repositoryNotFound *ecrtypes.RepositoryNotFoundException
+		if !errors.As(err, &imageNotFound) && !errors.As(err, &repositoryNotFound) {
+			return err
+		}
+		tflog.Debug(ctx, "Waiting for the image to be replicated", map[string]interface{}{
+			"region": client.Options().Region,
+			"digest": digest,
+		})
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("not available in time (waiting up to %s, within timeouts): check the replication configuration of the registry", replicationTimeout)
+			}
+			return ctx.Err()
+		case <-time.After(replicationPollInterval):
+		}
+	}
+}
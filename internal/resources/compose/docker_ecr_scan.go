@@ -0,0 +1,217 @@
+package compose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	ecrtypes "github.com/aws/aws-sdk-go-v2/service/ecr/types"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	tfplugintypes "github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+const (
+	// scanPollInterval is the interval between checks of the status of an ECR image scan.
+	scanPollInterval = 5 * time.Second
+	// scanStartTimeout is how long to wait for ECR to start scanning the pushed image before failing.
+	scanStartTimeout = 2 * time.Minute
+)
+
+// scanSeverities lists the ECR finding severities that fail_on_severity accepts, from the lowest to the highest.
+var scanSeverities = []string{
+	string(ecrtypes.FindingSeverityInformational),
+	string(ecrtypes.FindingSeverityLow),
+	string(ecrtypes.FindingSeverityMedium),
+	string(ecrtypes.FindingSeverityHigh),
+	string(ecrtypes.FindingSeverityCritical),
+}
+
+// waitForScanAttribute returns the schema of the wait_for_scan attribute shared by the image resources.
+func waitForScanAttribute() schema.BoolAttribute {
+	return schema.BoolAttribute{
+		MarkdownDescription: "Whether to wait, after the push, for the ECR image scan of the pushed image to complete, " +
+			"and record the number of findings by severity in `scan_findings`. " +
+			"The repository must scan images on push (basic scan on push or enhanced scanning). " +
+			"The scan is read with the AWS SDK, using the AWS credentials and configuration of the environment. " +
+			"The wait is limited by `timeouts`. Requires an ECR `image_uri`.",
+		Optional: true,
+	}
+}
+
+// failOnSeverityAttribute returns the schema of the fail_on_severity attribute shared by the image resources.
+func failOnSeverityAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		MarkdownDescription: "Fail the apply when the ECR image scan reports findings of this severity or higher: " +
+			"`INFORMATIONAL`, `LOW`, `MEDIUM`, `HIGH` or `CRITICAL`. " +
+			"The pushed image is recorded with its `scan_findings` and the apply fails, tainting a created resource. Implies `wait_for_scan`.",
+		Optional: true,
+	}
+}
+
+// scanFindingsAttribute returns the schema of the scan_findings attribute shared by the image resources.
+func scanFindingsAttribute() schema.MapAttribute {
+	return schema.MapAttribute{
+		MarkdownDescription: "Number of findings of the ECR image scan of the pushed image, keyed by severity (e.g. `HIGH`), " +
+			"summed over the platforms of multi-platform images. Null unless `wait_for_scan` or `fail_on_severity` is set.",
+		Computed:    true,
+		ElementType: tfplugintypes.Int64Type,
+	}
+}
+
+// validateScan reports a fail_on_severity that is not an ECR severity, and an image_uri that is not in an ECR registry.
+func validateScan(waitForScan tfplugintypes.Bool, failOnSeverity tfplugintypes.String, imageURI tfplugintypes.String) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if !failOnSeverity.IsNull() && !failOnSeverity.IsUnknown() && !slices.Contains(scanSeverities, failOnSeverity.ValueString()) {
+		diags.AddAttributeError(
+			path.Root("fail_on_severity"),
+			"Invalid fail_on_severity",
+			fmt.Sprintf("fail_on_severity must be one of %v.", scanSeverities),
+		)
+	}
+	if !waitsForScan(waitForScan, failOnSeverity) || imageURI.IsNull() || imageURI.IsUnknown() {
+		return diags
+	}
+	host, _, _, err := registryclient.ParseImageReference(imageURI.ValueString())
+	if err != nil {
+		return diags
+	}
+	if _, ok := registryclient.ParseECRHost(host); !ok {
+		diags.AddAttributeError(
+			path.Root("wait_for_scan"),
+			"Image scan not supported",
+			fmt.Sprintf("wait_for_scan and fail_on_severity read ECR image scans, but image_uri is in %s.", host),
+		)
+	}
+	return diags
+}
+
+// waitsForScan reports whether the ECR image scan is waited for.
+func waitsForScan(waitForScan tfplugintypes.Bool, failOnSeverity tfplugintypes.String) bool {
+	return waitForScan.ValueBool() || failOnSeverity.ValueString() != ""
+}
+
+// waitForImageScan waits for the ECR image scan of the image pushed as sha256_digest of model,
+// records the findings into scan_findings, and fails when they reach fail_on_severity.
+// The platform images of an image index are scanned one by one, and their findings are summed.
+func (r *ComposeResource) waitForImageScan(ctx context.Context, model *ComposeResourceModel) error {
+	if !waitsForScan(model.WaitForScan, model.FailOnSeverity) {
+		return nil
+	}
+	client, registry, repository, err := newECRClient(ctx, model.ImageURI.ValueString(), "wait_for_scan")
+	if err != nil {
+		return err
+	}
+	digests, err := r.scannedDigests(ctx, model)
+	if err != nil {
+		return err
+	}
+
+	findings := map[string]int64{}
+	for _, digest := range digests {
+		counts, err := waitForScanFindings(ctx, client, registry.Account, repository, digest)
+		if err != nil {
+			return err
+		}
+		for severity, count := range counts {
+			findings[severity] += int64(count)
+		}
+	}
+	value, diags := tfplugintypes.MapValueFrom(ctx, tfplugintypes.Int64Type, findings)
+	if diags.HasError() {
+		return errors.New("failed to set scan_findings")
+	}
+	model.ScanFindings = value
+	tflog.Info(ctx, "ECR image scan completed", map[string]interface{}{
+		"image_uri": model.ImageURI.ValueString(),
+		"findings":  findings,
+	})
+
+	threshold := slices.Index(scanSeverities, model.FailOnSeverity.ValueString())
+	if threshold < 0 {
+		return nil
+	}
+	for _, severity := range scanSeverities[threshold:] {
+		if findings[severity] > 0 {
+			return fmt.Errorf("the image scan of %s reported %d %s findings, at or above fail_on_severity = %q (findings: %v)",
+				model.ImageURI.ValueString(), findings[severity], severity, model.FailOnSeverity.ValueString(), findings)
+		}
+	}
+	return nil
+}
+
+// scannedDigests returns the digests of the images ECR scans for the image pushed as sha256_digest of model:
+// the pushed manifest, or the platform manifests of an image index.
+func (r *ComposeResource) scannedDigests(ctx context.Context, model *ComposeResourceModel) ([]string, error) {
+	host, repository, _, err := registryclient.ParseImageReference(model.ImageURI.ValueString())
+	if err != nil {
+		return nil, err
+	}
+	c, err := registryclient.New(r.providerConfig, host)
+	if err != nil {
+		return nil, err
+	}
+	manifest, platforms, err := c.ListPlatforms(ctx, repository, model.SHA256Digest.ValueString())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pushed manifest: %w", err)
+	}
+	if !manifest.IsIndex() {
+		return []string{manifest.Digest}, nil
+	}
+	digests := make([]string, 0, len(platforms))
+	for _, platform := range platforms {
+		digests = append(digests, platform.Digest)
+	}
+	return digests, nil
+}
+
+// waitForScanFindings polls the ECR image scan of digest in repository until it completes,
+// and returns the number of findings by severity.
+func waitForScanFindings(ctx context.Context, client *ecr.Client, account, repository, digest string) (map[string]int32, error) {
+	started := time.Now()
+	for {
+		output, err := client.DescribeImageScanFindings(ctx, &ecr.DescribeImageScanFindingsInput{
+			RegistryId:     aws.String(account),
+			RepositoryName: aws.String(repository),
+			ImageId:        &ecrtypes.ImageIdentifier{ImageDigest: aws.String(digest)},
+			MaxResults:     aws.Int32(1),
+		})
+		var notFound *ecrtypes.ScanNotFoundException
+		switch {
+		case errors.As(err, &notFound):
+			// Scan on push starts shortly after the push
+			if time.Since(started) > scanStartTimeout {
+				return nil, fmt.Errorf("ECR did not scan image %s within %s: enable scan on push for repository %s", digest, scanStartTimeout, repository)
+			}
+		case err != nil:
+			return nil, fmt.Errorf("failed to get the image scan of %s: %w", digest, err)
+		case output.ImageScanStatus != nil:
+			switch status := output.ImageScanStatus.Status; status {
+			case ecrtypes.ScanStatusComplete, ecrtypes.ScanStatusActive:
+				if output.ImageScanFindings == nil {
+					return map[string]int32{}, nil
+				}
+				return output.ImageScanFindings.FindingSeverityCounts, nil
+			case ecrtypes.ScanStatusInProgress, ecrtypes.ScanStatusPending:
+			default:
+				return nil, fmt.Errorf("the image scan of %s ended with status %s: %s", digest, status, aws.ToString(output.ImageScanStatus.Description))
+			}
+		}
+		tflog.Debug(ctx, "Waiting for the ECR image scan", map[string]interface{}{
+			"digest": digest,
+		})
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("canceled while waiting for the image scan of %s: %w", digest, ctx.Err())
+		case <-time.After(scanPollInterval):
+		}
+	}
+}
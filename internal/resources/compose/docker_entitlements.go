@@ -0,0 +1,57 @@
+package compose
+
+import (
+	"fmt"
+	"slices"
+
+	composetypes "github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/cli/cli/command"
+)
+
+const (
+	// entitlementNetworkHost allows RUN --network=host and build.network = "host".
+	entitlementNetworkHost = "network.host"
+	// entitlementSecurityInsecure allows RUN --security=insecure, i.e. privileged build steps.
+	entitlementSecurityInsecure = "security.insecure"
+)
+
+// checkEntitlements verifies that build.entitlements only lists the entitlements that the builds can be granted.
+func checkEntitlements(buildSpec *composetypes.BuildConfig) error {
+	for _, entitlement := range buildSpec.Entitlements {
+		if entitlement != entitlementNetworkHost && entitlement != entitlementSecurityInsecure {
+			return fmt.Errorf("unsupported build.entitlements %q: must be %q or %q", entitlement, entitlementNetworkHost, entitlementSecurityInsecure)
+		}
+	}
+	return nil
+}
+
+// bakeEntitlements returns the entitlements that buildx bake requests for buildSpec,
+// including those implied by build.network = "host" and build.privileged.
+func bakeEntitlements(buildSpec *composetypes.BuildConfig) []string {
+	entitlements := slices.Clone(buildSpec.Entitlements)
+	if buildSpec.Network == "host" {
+		entitlements = append(entitlements, entitlementNetworkHost)
+	}
+	if buildSpec.Privileged {
+		entitlements = append(entitlements, entitlementSecurityInsecure)
+	}
+	slices.Sort(entitlements)
+	return slices.Compact(entitlements)
+}
+
+// bakeAllowedEntitlements returns the entitlements that the buildx plugin wrapper grants to buildx bake run by compose
+// for buildSpec. Compose passes --allow for security.insecure only, and bake refuses builds requesting other entitlements
+// with "additional privileges requested".
+func bakeAllowedEntitlements(dockerCli command.Cli, buildSpec *composetypes.BuildConfig) ([]string, error) {
+	if !slices.Contains(bakeEntitlements(buildSpec), entitlementNetworkHost) {
+		return nil, nil
+	}
+	buildkit, err := dockerCli.BuildKitEnabled()
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine whether BuildKit is enabled: %w", err)
+	}
+	if !buildkit {
+		return nil, nil
+	}
+	return []string{entitlementNetworkHost}, nil
+}
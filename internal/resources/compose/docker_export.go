@@ -0,0 +1,38 @@
+package compose
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker/client"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/ocilayout"
+)
+
+// skipPush reports whether the image is never pushed to the registry: only built with dry_run, or only exported to disk.
+func skipPush(model *ComposeResourceModel) bool {
+	return model.DryRun.ValueBool() || (model.Export != nil && model.Export.SkipPush.ValueBool())
+}
+
+// exportImage writes the local image tagged as image_uri to the path configured in the export block.
+func (r *ComposeResource) exportImage(ctx context.Context, dockerClient *client.Client, model *ComposeResourceModel) error {
+	exportType := model.Export.Type.ValueString()
+	exportPath := model.Export.Path.ValueString()
+	tflog.Info(ctx, "Exporting image", map[string]interface{}{
+		"image_uri": model.ImageURI.ValueString(),
+		"type":      exportType,
+		"path":      exportPath,
+	})
+
+	saved, err := dockerClient.ImageSave(ctx, []string{model.ImageURI.ValueString()})
+	if err != nil {
+		return fmt.Errorf("failed to save image: %w", err)
+	}
+	defer saved.Close()
+
+	if err := ocilayout.ExportArchive(saved, exportType, exportPath, model.ImageURI.ValueString()); err != nil {
+		return fmt.Errorf("failed to export image to %s: %w", exportPath, err)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package compose
+
+import (
+	"fmt"
+
+	composetypes "github.com/compose-spec/compose-go/v2/types"
+	"github.com/distribution/reference"
+	"github.com/docker/cli/cli/command"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// buildkitSyntaxArg is the build argument with which BuildKit overrides the frontend selected by the # syntax directive.
+const buildkitSyntaxArg = "BUILDKIT_SYNTAX"
+
+// frontendImageAttribute returns the schema of the frontend_image attribute shared by the image resources.
+func frontendImageAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		MarkdownDescription: "Dockerfile frontend image used for the build (e.g. `docker/dockerfile:1.12@sha256:...`), " +
+			"overriding the `# syntax` directive of the Dockerfile, so that the same Dockerfile syntax is available on every build host. " +
+			"Pin it by digest for reproducible builds. Requires BuildKit.",
+		Optional: true,
+	}
+}
+
+// validateFrontendImage reports a frontend_image attribute that is not an image reference.
+func validateFrontendImage(frontendImage types.String) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if frontendImage.IsNull() || frontendImage.IsUnknown() {
+		return diags
+	}
+	if _, err := reference.ParseNormalizedNamed(frontendImage.ValueString()); err != nil {
+		diags.AddAttributeError(path.Root("frontend_image"), "Invalid frontend image", err.Error())
+	}
+	return diags
+}
+
+// applyFrontendImage selects frontendImage as the Dockerfile frontend of buildSpec through the BUILDKIT_SYNTAX build argument.
+// It requires BuildKit, as the classic builder has no frontends and ignores the build argument.
+func applyFrontendImage(dockerCli command.Cli, buildSpec *composetypes.BuildConfig, frontendImage string) error {
+	if frontendImage == "" {
+		return nil
+	}
+	buildkit, err := dockerCli.BuildKitEnabled()
+	if err != nil {
+		return fmt.Errorf("failed to determine whether BuildKit is enabled: %w", err)
+	}
+	if !buildkit {
+		return fmt.Errorf("frontend_image requires BuildKit, but the classic builder is used: set builder = \"buildkit\"")
+	}
+	if value, ok := buildSpec.Args[buildkitSyntaxArg]; ok && value != nil && *value != frontendImage {
+		return fmt.Errorf("frontend_image and the %s build argument cannot be specified together", buildkitSyntaxArg)
+	}
+	if buildSpec.Args == nil {
+		buildSpec.Args = composetypes.MappingWithEquals{}
+	}
+	buildSpec.Args[buildkitSyntaxArg] = &frontendImage
+	return nil
+}
@@ -0,0 +1,131 @@
+package compose
+
+import (
+	"context"
+	"net/url"
+	"os/exec"
+	"strings"
+	"time"
+
+	composetypes "github.com/compose-spec/compose-go/v2/types"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// OCI image annotation keys injected as labels by oci_labels.
+// See: https://github.com/opencontainers/image-spec/blob/main/annotations.md
+const (
+	ociLabelSource   = "org.opencontainers.image.source"
+	ociLabelRevision = "org.opencontainers.image.revision"
+	ociLabelCreated  = "org.opencontainers.image.created"
+	ociLabelVersion  = "org.opencontainers.image.version"
+)
+
+// ociLabelKeys lists the labels injected by oci_labels.
+var ociLabelKeys = []string{ociLabelSource, ociLabelRevision, ociLabelCreated, ociLabelVersion}
+
+// ociLabelsAttribute returns the schema of the oci_labels attribute shared by the image resources.
+func ociLabelsAttribute() schema.BoolAttribute {
+	return schema.BoolAttribute{
+		MarkdownDescription: "Add the OCI standard labels `org.opencontainers.image.source` and `org.opencontainers.image.revision` " +
+			"(the remote URL and commit of the git repository containing the build context), `org.opencontainers.image.created` (the build time) " +
+			"and `org.opencontainers.image.version` (the tag of `image_uri`). Labels set in `labels` take precedence. " +
+			"The added labels are not reflected in `labels`. Default is false.",
+		Optional: true,
+	}
+}
+
+// ociLabels returns the OCI standard labels for an image built from contextDir and pushed as imageURI:
+// the remote URL and commit of the git repository containing contextDir, the build time,
+// and the tag of imageURI. Values that cannot be determined (e.g. outside a git repository) are omitted.
+func ociLabels(ctx context.Context, contextDir, imageURI string) map[string]string {
+	labels := map[string]string{
+		ociLabelCreated: time.Now().UTC().Format(time.RFC3339),
+	}
+	if _, _, ref, err := registryclient.ParseImageReference(imageURI); err == nil && !strings.Contains(ref, ":") {
+		labels[ociLabelVersion] = ref
+	}
+
+	if contextDir == "" || isRemoteContext(contextDir) {
+		return labels
+	}
+	if revision, err := gitOutput(ctx, contextDir, "rev-parse", "HEAD"); err == nil {
+		labels[ociLabelRevision] = revision
+	} else {
+		tflog.Debug(ctx, "Cannot determine git revision for oci_labels: ignored", map[string]interface{}{
+			"context": contextDir,
+			"error":   err.Error(),
+		})
+	}
+	if remote, err := gitOutput(ctx, contextDir, "config", "--get", "remote.origin.url"); err == nil && remote != "" {
+		labels[ociLabelSource] = sourceURL(remote)
+	}
+	return labels
+}
+
+// gitOutput runs git in dir and returns its trimmed standard output.
+func gitOutput(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// sourceURL converts a git remote URL to a browsable https URL without credentials,
+// e.g. "git@github.com:owner/repo.git" to "https://github.com/owner/repo".
+func sourceURL(remote string) string {
+	remote = strings.TrimSuffix(remote, ".git")
+	if !strings.Contains(remote, "://") {
+		// scp-like syntax: [user@]host:path
+		if at := strings.Index(remote, "@"); at >= 0 {
+			remote = remote[at+1:]
+		}
+		if host, path, ok := strings.Cut(remote, ":"); ok {
+			return "https://" + host + "/" + strings.TrimPrefix(path, "/")
+		}
+		return remote
+	}
+	u, err := url.Parse(remote)
+	if err != nil {
+		return remote
+	}
+	u.User = nil
+	if u.Scheme == "ssh" || u.Scheme == "git" {
+		u.Scheme = "https"
+		u.Host = u.Hostname()
+	}
+	return u.String()
+}
+
+// removeInjectedLabels removes from registry labels the labels injected by oci_labels that are not
+// configured in labels, so that they do not show up as a difference from the configuration.
+func removeInjectedLabels(registry, configured map[string]string) map[string]string {
+	filtered := make(map[string]string, len(registry))
+	for k, v := range registry {
+		filtered[k] = v
+	}
+	for _, k := range ociLabelKeys {
+		if _, ok := configured[k]; !ok {
+			delete(filtered, k)
+		}
+	}
+	return filtered
+}
+
+// buildLabels returns the labels to set on the built image: the labels attribute,
+// plus the OCI standard labels when oci_labels is set and the labels attribute does not override them.
+func (r *ComposeResource) buildLabels(ctx context.Context, buildSpec *composetypes.BuildConfig, model *ComposeResourceModel) map[string]string {
+	labels := r.extractLabels(model)
+	if model.OCILabels.ValueBool() {
+		for key, value := range ociLabels(ctx, buildSpec.Context, model.ImageURI.ValueString()) {
+			if _, ok := labels[key]; !ok {
+				labels[key] = value
+			}
+		}
+	}
+	return labels
+}
@@ -0,0 +1,68 @@
+package compose
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Local cluster types accepted by load_into.type.
+const (
+	clusterTypeKind     = "kind"
+	clusterTypeK3d      = "k3d"
+	clusterTypeMinikube = "minikube"
+)
+
+// loadCommand returns the command line that loads image into the local cluster of clusterType named cluster.
+// An empty cluster uses the default cluster of the tool.
+func loadCommand(clusterType, cluster, image string) ([]string, error) {
+	switch clusterType {
+	case clusterTypeKind:
+		args := []string{"kind", "load", "docker-image", image}
+		if cluster != "" {
+			args = append(args, "--name", cluster)
+		}
+		return args, nil
+	case clusterTypeK3d:
+		args := []string{"k3d", "image", "import", image}
+		if cluster != "" {
+			args = append(args, "--cluster", cluster)
+		}
+		return args, nil
+	case clusterTypeMinikube:
+		args := []string{"minikube", "image", "load", image}
+		if cluster != "" {
+			args = append(args, "--profile", cluster)
+		}
+		return args, nil
+	default:
+		return nil, fmt.Errorf("unsupported load_into.type %q: must be %s, %s or %s", clusterType, clusterTypeKind, clusterTypeK3d, clusterTypeMinikube)
+	}
+}
+
+// loadIntoCluster loads the local image tagged as image_uri into the cluster configured in load_into,
+// using the CLI of the cluster tool.
+func (r *ComposeResource) loadIntoCluster(ctx context.Context, model *ComposeResourceModel) error {
+	args, err := loadCommand(model.LoadInto.Type.ValueString(), model.LoadInto.Cluster.ValueString(), model.ImageURI.ValueString())
+	if err != nil {
+		return err
+	}
+
+	tflog.Info(ctx, "Loading image into local cluster", map[string]interface{}{
+		"image_uri": model.ImageURI.ValueString(),
+		"type":      model.LoadInto.Type.ValueString(),
+		"cluster":   model.LoadInto.Cluster.ValueString(),
+	})
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to load image into %s cluster: %s failed: %w: %s", model.LoadInto.Type.ValueString(), strings.Join(args[:3], " "), err, strings.TrimSpace(output.String()))
+	}
+	return nil
+}
@@ -12,7 +12,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	ocidigest "github.com/opencontainers/go-digest"
 
-	"github.com/ikedam/terraform-provider-containerregistry/internal/logging"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
 )
 
 // ImageInfo represents the minimal information retrieved from the container registry
@@ -69,8 +69,8 @@ func (r *ComposeResource) getImageInfoFromRegistry(ctx context.Context, model *C
 		return nil, fmt.Errorf("failed to get authentication configuration: %w", err)
 	}
 
-	// Create HTTP client to interact with the Registry API, using Terraform logging transport.
-	client := logging.NewHTTPLoggingClient()
+	// Create HTTP client to interact with the Registry API (logs requests and retries on 429).
+	client := registryclient.NewHTTPClient()
 
 	// First, we need to get the manifest for the image
 	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", registry, repository, tag)
@@ -100,6 +100,8 @@ func (r *ComposeResource) getImageInfoFromRegistry(ctx context.Context, model *C
 	if err != nil {
 		return nil, fmt.Errorf("failed to head manifest: %w", err)
 	}
+	// HEAD responses have no body, so the status code is all we get here
+	headErr := registryclient.CheckResponse(headResp, "head manifest")
 	err = headResp.Body.Close()
 	if err != nil {
 		tflog.Warn(ctx, "Failed to close head manifest body: ignored", map[string]any{
@@ -107,13 +109,13 @@ func (r *ComposeResource) getImageInfoFromRegistry(ctx context.Context, model *C
 		})
 	}
 	if headResp.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("image not found: %s", imageURI)
+		return nil, fmt.Errorf("image not found: %s: %w", imageURI, headErr)
 	}
 	if headResp.StatusCode == http.StatusUnauthorized {
-		return nil, fmt.Errorf("authentication failed for registry: %s", registry)
+		return nil, fmt.Errorf("authentication failed for registry %s: %w", registry, headErr)
 	}
-	if headResp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to head manifest, status: %d", headResp.StatusCode)
+	if headErr != nil {
+		return nil, headErr
 	}
 	manifestDigest = headResp.Header.Get("Docker-Content-Digest")
 
@@ -146,14 +148,14 @@ func (r *ComposeResource) getImageInfoFromRegistry(ctx context.Context, model *C
 	defer resp.Body.Close()
 
 	// Check for errors
-	if resp.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("image not found: %s", imageURI)
-	}
-	if resp.StatusCode == http.StatusUnauthorized {
-		return nil, fmt.Errorf("authentication failed for registry: %s", registry)
-	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to get manifest, status: %d", resp.StatusCode)
+	if err := registryclient.CheckResponse(resp, "get manifest"); err != nil {
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("image not found: %s: %w", imageURI, err)
+		}
+		if resp.StatusCode == http.StatusUnauthorized {
+			return nil, fmt.Errorf("authentication failed for registry %s: %w", registry, err)
+		}
+		return nil, err
 	}
 
 	// Prefer Docker-Content-Digest from GET if it exists; otherwise compute digest from the response body bytes.
@@ -252,8 +254,8 @@ func (r *ComposeResource) getImageInfoFromRegistry(ctx context.Context, model *C
 		defer actualResp.Body.Close()
 
 		// Check for errors
-		if actualResp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("failed to get actual manifest, status: %d", actualResp.StatusCode)
+		if err := registryclient.CheckResponse(actualResp, "get actual manifest"); err != nil {
+			return nil, err
 		}
 
 		// Parse the actual manifest
@@ -287,8 +289,8 @@ func (r *ComposeResource) getImageInfoFromRegistry(ctx context.Context, model *C
 	defer configResp.Body.Close()
 
 	// Check for errors
-	if configResp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to get config, status: %d", configResp.StatusCode)
+	if err := registryclient.CheckResponse(configResp, "get config"); err != nil {
+		return nil, err
 	}
 
 	// Parse the config blob to get the labels
@@ -0,0 +1,127 @@
+package compose
+
+import (
+	"context"
+	"fmt"
+
+	composetypes "github.com/compose-spec/compose-go/v2/types"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	tfplugintypes "github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// Values of media_type: the media types of the pushed manifest.
+const (
+	mediaTypeOCI    = "oci"
+	mediaTypeDocker = "docker"
+)
+
+// mediaTypeAttribute returns the schema of the media_type attribute shared by the image resources.
+func mediaTypeAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		MarkdownDescription: "Media types of the pushed manifest (and image index for multi-platform images): " +
+			"`oci` for the OCI image spec, or `docker` for Docker distribution v2 (schema 2), " +
+			"for registries and scanners that accept only one of them. Defaults to what BuildKit produces. " +
+			"BuildKit sets the media types while building, which requires the buildx plugin, " +
+			"and `oci` requires `direct_push` or a Docker daemon with the containerd image store. " +
+			"`docker` cannot be used with `option.provenance`, `option.sbom` or `annotations`, which only exist with OCI media types, " +
+			"and disables the provenance attestation buildx adds by default. " +
+			"Cannot be used with `builder = \"classic\"`, `\"kaniko\"` or `\"podman\"`, `squash`, or an existing image.",
+		Optional: true,
+	}
+}
+
+// validateMediaType reports a media_type attribute with an unknown value, and the attributes that cannot be used with it.
+func validateMediaType(mediaType, builder tfplugintypes.String, squash tfplugintypes.Bool, annotations tfplugintypes.Map, option *OptionModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if mediaType.IsNull() || mediaType.IsUnknown() {
+		return diags
+	}
+	switch mediaType.ValueString() {
+	case mediaTypeOCI:
+	case mediaTypeDocker:
+		if attestationsRequested(option) {
+			diags.AddAttributeError(
+				path.Root("media_type"),
+				"Attestations require OCI media types",
+				"option.provenance and option.sbom push the attestations in an OCI image index and cannot be used with media_type = \"docker\".",
+			)
+		}
+		if len(annotations.Elements()) > 0 {
+			diags.AddAttributeError(
+				path.Root("media_type"),
+				"Annotations require OCI media types",
+				"Docker distribution v2 manifests have no annotations: annotations cannot be used with media_type = \"docker\".",
+			)
+		}
+	default:
+		diags.AddAttributeError(
+			path.Root("media_type"),
+			"Invalid media_type",
+			fmt.Sprintf("media_type must be %q or %q.", mediaTypeOCI, mediaTypeDocker),
+		)
+		return diags
+	}
+	if b := builder.ValueString(); b == builderClassic || b == builderPodman || b == builderKaniko {
+		diags.AddAttributeError(
+			path.Root("media_type"),
+			"media_type requires BuildKit",
+			fmt.Sprintf("media_type cannot be used with builder = %q.", b),
+		)
+	}
+	if squash.ValueBool() {
+		diags.AddAttributeError(
+			path.Root("media_type"),
+			"media_type not supported",
+			"squash rebuilds the image manifest and cannot be used with media_type.",
+		)
+	}
+	return diags
+}
+
+// bakeOutput returns the output of buildx bake compose uses for buildSpec, pushing the image when push is set,
+// with the OCI media types enabled or disabled as mediaType requires.
+func bakeOutput(buildSpec *composetypes.BuildConfig, push bool, mediaType string) string {
+	var output string
+	switch {
+	case len(buildSpec.Platforms) > 1:
+		output = fmt.Sprintf("type=image,push=%t", push)
+	case push:
+		output = "type=registry"
+	default:
+		output = "type=docker"
+	}
+	return fmt.Sprintf("%s,oci-mediatypes=%t", output, mediaType == mediaTypeOCI)
+}
+
+// verifyMediaType fails unless the manifest pushed as sha256_digest of model has the media types of media_type,
+// which BuildKit switches to OCI by itself for content that Docker distribution v2 cannot describe.
+func (r *ComposeResource) verifyMediaType(ctx context.Context, model *ComposeResourceModel) error {
+	host, repository, _, err := registryclient.ParseImageReference(model.ImageURI.ValueString())
+	if err != nil {
+		return err
+	}
+	c, err := registryclient.New(r.providerConfig, host)
+	if err != nil {
+		return err
+	}
+	digest := model.SHA256Digest.ValueString()
+	manifest, err := c.GetManifest(ctx, repository, digest)
+	if err != nil {
+		return fmt.Errorf("failed to get pushed manifest %s: %w", digest, err)
+	}
+	oci := manifest.MediaType == registryclient.MediaTypeOCIManifest || manifest.MediaType == registryclient.MediaTypeOCIIndex
+	if oci != (model.MediaType.ValueString() == mediaTypeOCI) {
+		return fmt.Errorf("the pushed manifest %s has media type %s, which is not media_type = %q: "+
+			"the builder may add attestations or annotations, which require OCI media types", digest, manifest.MediaType, model.MediaType.ValueString())
+	}
+	tflog.Debug(ctx, "Pushed manifest has the configured media type", map[string]interface{}{
+		"digest":     digest,
+		"media_type": manifest.MediaType,
+	})
+	return nil
+}
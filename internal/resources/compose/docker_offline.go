@@ -0,0 +1,222 @@
+package compose
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	composetypes "github.com/compose-spec/compose-go/v2/types"
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/docker/docker/client"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	tfplugintypes "github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+)
+
+// offlineAttribute returns the schema of the offline attribute shared by the image resources.
+func offlineAttribute() schema.BoolAttribute {
+	return schema.BoolAttribute{
+		MarkdownDescription: "Whether to build without pulling anything, for disconnected (air-gapped) environments. " +
+			"Base images, images of `build.additional_contexts` and the Dockerfile frontend must be present in the local Docker daemon: " +
+			"the build fails before starting when one is missing, instead of waiting for registry timeouts. " +
+			"Cannot be used with `option.pull`, `cache_from_previous`, `builder = \"kaniko\"`, or a remote builder.",
+		Optional: true,
+	}
+}
+
+// validateOffline reports the attributes that cannot be used with offline, as they pull from registries.
+func validateOffline(offline tfplugintypes.Bool, builder tfplugintypes.String, cacheFromPrevious tfplugintypes.Bool, option *OptionModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if !offline.ValueBool() {
+		return diags
+	}
+	if option != nil && option.Pull.ValueBool() {
+		diags.AddAttributeError(
+			path.Root("option").AtName("pull"),
+			"Pull in offline mode",
+			"option.pull pulls the base images and cannot be used with offline.",
+		)
+	}
+	if cacheFromPrevious.ValueBool() {
+		diags.AddAttributeError(
+			path.Root("cache_from_previous"),
+			"Cache in offline mode",
+			"cache_from_previous reads the cache from the registry and cannot be used with offline.",
+		)
+	}
+	if builder.ValueString() == builderKaniko {
+		diags.AddAttributeError(
+			path.Root("offline"),
+			"Offline build not supported",
+			"offline cannot be used with builder = \"kaniko\", which pulls the base images from their registries.",
+		)
+	}
+	return diags
+}
+
+// checkOfflineImages verifies, for an offline build, that the images the build of buildSpec reads are present
+// in the local Docker daemon: the base images in FROM and the images in COPY / ADD --from of the Dockerfile,
+// the images of build.additional_contexts, and the Dockerfile frontend (frontend_image or the # syntax directive).
+// References with variables that cannot be resolved from the build arguments are not checked.
+func checkOfflineImages(ctx context.Context, dockerClient *client.Client, buildSpec *composetypes.BuildConfig, frontendImage string) error {
+	if isRemoteContext(buildSpec.Context) {
+		return errors.New("offline requires a local build.context")
+	}
+	dockerfile, err := readDockerfile(buildSpec)
+	if err != nil {
+		return err
+	}
+	images, err := dockerfileImages(dockerfile, buildSpec)
+	if err != nil {
+		return err
+	}
+	if frontendImage == "" {
+		if syntax, _, _, ok := parser.DetectSyntax(dockerfile); ok {
+			frontendImage = syntax
+		}
+	}
+	if frontendImage != "" {
+		images = append(images, frontendImage)
+	}
+
+	var missing []string
+	for _, image := range images {
+		if _, err := dockerClient.ImageInspect(ctx, image); err != nil {
+			if !cerrdefs.IsNotFound(err) {
+				return fmt.Errorf("failed to inspect image %s: %w", image, err)
+			}
+			missing = append(missing, image)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("offline build requires images missing in the local Docker daemon: %s: "+
+			"load them (docker load) or pull them before building offline", strings.Join(missing, ", "))
+	}
+	tflog.Debug(ctx, "All images of the offline build are present locally", map[string]interface{}{
+		"images": images,
+	})
+	return nil
+}
+
+// readDockerfile returns the Dockerfile of buildSpec: build.dockerfile_inline, or build.dockerfile relative to build.context.
+func readDockerfile(buildSpec *composetypes.BuildConfig) ([]byte, error) {
+	if buildSpec.DockerfileInline != "" {
+		return []byte(buildSpec.DockerfileInline), nil
+	}
+	dockerfile, err := os.ReadFile(dockerfilePath(buildSpec))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Dockerfile: %w", err)
+	}
+	return dockerfile, nil
+}
+
+// dockerfilePath returns the path of the Dockerfile of buildSpec, relative to the build context unless absolute.
+func dockerfilePath(buildSpec *composetypes.BuildConfig) string {
+	dockerfile := buildSpec.Dockerfile
+	if dockerfile == "" {
+		dockerfile = "Dockerfile"
+	}
+	if filepath.IsAbs(dockerfile) {
+		return dockerfile
+	}
+	contextDir := buildSpec.Context
+	if contextDir == "" {
+		contextDir = "."
+	}
+	return filepath.Join(contextDir, dockerfile)
+}
+
+// dockerfileImages returns the images read by the build of dockerfile with buildSpec: the base images in FROM,
+// the images in COPY / ADD --from, and the images of build.additional_contexts, without build stages and scratch.
+// Variables are expanded with the build arguments and the defaults of the ARG instructions before the first FROM.
+func dockerfileImages(dockerfile []byte, buildSpec *composetypes.BuildConfig) ([]string, error) {
+	result, err := parser.Parse(bytes.NewReader(dockerfile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Dockerfile: %w", err)
+	}
+	args := map[string]string{}
+	contexts := map[string]string{}
+	for name, value := range buildSpec.AdditionalContexts {
+		contexts[name] = value
+	}
+	stages := map[string]bool{"scratch": true}
+	var images []string
+	add := func(ref string) {
+		// A build argument without a value leaves the reference unresolved
+		expanded, resolved := expandBuildArgs(ref, args)
+		if !resolved || expanded == "" || stages[strings.ToLower(expanded)] {
+			return
+		}
+		if value, ok := contexts[expanded]; ok {
+			// Only image contexts are pulled; local directories and other contexts are read as they are
+			if image, ok := strings.CutPrefix(value, dockerImageContextPrefix); ok && !slices.Contains(images, image) {
+				images = append(images, image)
+			}
+			return
+		}
+		if !slices.Contains(images, expanded) {
+			images = append(images, expanded)
+		}
+	}
+
+	seenFrom := false
+	for _, node := range result.AST.Children {
+		var nodeArgs []string
+		for next := node.Next; next != nil; next = next.Next {
+			nodeArgs = append(nodeArgs, next.Value)
+		}
+		switch strings.ToUpper(node.Value) {
+		case "ARG":
+			if seenFrom {
+				continue
+			}
+			for _, arg := range nodeArgs {
+				name, value, _ := strings.Cut(arg, "=")
+				if override, ok := buildSpec.Args[name]; ok && override != nil {
+					value = *override
+				}
+				args[name] = value
+			}
+		case "FROM":
+			seenFrom = true
+			if len(nodeArgs) == 0 {
+				continue
+			}
+			add(nodeArgs[0])
+			if len(nodeArgs) == 3 && strings.EqualFold(nodeArgs[1], "AS") {
+				stages[strings.ToLower(nodeArgs[2])] = true
+			}
+		case "COPY", "ADD":
+			for _, flag := range node.Flags {
+				if from, ok := strings.CutPrefix(flag, "--from="); ok {
+					// Numeric references are build stages by index
+					if strings.Trim(from, "0123456789") != "" {
+						add(from)
+					}
+				}
+			}
+		}
+	}
+	return images, nil
+}
+
+// expandBuildArgs expands $NAME and ${NAME} in s with args, and reports whether every variable had a value.
+func expandBuildArgs(s string, args map[string]string) (string, bool) {
+	resolved := true
+	expanded := os.Expand(s, func(name string) string {
+		value, ok := args[name]
+		if !ok || value == "" {
+			resolved = false
+		}
+		return value
+	})
+	return expanded, resolved
+}
@@ -0,0 +1,203 @@
+package compose
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	tfplugintypes "github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/logging"
+)
+
+// onPushTimeout limits each on_push hook, so that an unresponsive endpoint or command does not hang the apply.
+const onPushTimeout = 5 * time.Minute
+
+// OnPushModel represents the on_push block of the image resources
+type OnPushModel struct {
+	URL     tfplugintypes.String `tfsdk:"url"`
+	Headers tfplugintypes.Map    `tfsdk:"headers"`
+	Payload tfplugintypes.String `tfsdk:"payload"`
+	Command tfplugintypes.List   `tfsdk:"command"`
+}
+
+// onPushBlock returns the schema of the on_push block shared by the image resources.
+func onPushBlock() schema.SingleNestedBlock {
+	return schema.SingleNestedBlock{
+		MarkdownDescription: "Notify downstream systems (deploy bots, cache invalidation) each time the resource pushes the image, " +
+			"after the push and the waits (`wait_for_scan`, `wait_for_available`, `wait_for_replication`) succeed: " +
+			"POST a payload to `url`, run `command`, or both. " +
+			"When a hook fails, the pushed image is recorded and the apply fails, tainting a created resource.",
+		Attributes: map[string]schema.Attribute{
+			"url": schema.StringAttribute{
+				MarkdownDescription: "HTTP(S) URL of the webhook the payload is POSTed to.",
+				Optional:            true,
+			},
+			"headers": schema.MapAttribute{
+				MarkdownDescription: "HTTP headers of the webhook request (e.g. `Authorization`). `Content-Type` defaults to `application/json`.",
+				Optional:            true,
+				Sensitive:           true,
+				ElementType:         tfplugintypes.StringType,
+			},
+			"payload": schema.StringAttribute{
+				MarkdownDescription: "Body of the webhook request as a Go template, with `{{.image_uri}}` (`pushed_image_uri`), " +
+					"`{{.image_ref}}` (the pushed image pinned by digest, e.g. `registry/repository@sha256:...`) and `{{.digest}}`. " +
+					"Defaults to a JSON object with `image_uri`, `image_ref` and `digest`.",
+				Optional: true,
+			},
+			"command": schema.ListAttribute{
+				MarkdownDescription: "Local command run after the push (e.g. `[\"./notify.sh\"]`), " +
+					"with `IMAGE_URI`, `IMAGE_REF` and `IMAGE_DIGEST` set in its environment.",
+				Optional:    true,
+				ElementType: tfplugintypes.StringType,
+			},
+		},
+	}
+}
+
+// validateOnPush reports an on_push block without hooks, an invalid url or payload template, and webhook settings without url.
+func validateOnPush(onPush *OnPushModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if onPush == nil {
+		return diags
+	}
+	if onPush.URL.IsNull() && onPush.Command.IsNull() {
+		diags.AddAttributeError(path.Root("on_push"), "No hook", "on_push requires url, command or both.")
+	}
+	if hookURL := onPush.URL; !hookURL.IsNull() && !hookURL.IsUnknown() {
+		if u, err := url.Parse(hookURL.ValueString()); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			diags.AddAttributeError(path.Root("on_push").AtName("url"), "Invalid url", "on_push.url must be an http or https URL.")
+		}
+	}
+	if onPush.URL.IsNull() && !onPush.Headers.IsNull() {
+		diags.AddAttributeError(path.Root("on_push").AtName("headers"), "Webhook required", "on_push.headers requires on_push.url.")
+	}
+	if onPush.URL.IsNull() && !onPush.Payload.IsNull() {
+		diags.AddAttributeError(path.Root("on_push").AtName("payload"), "Webhook required", "on_push.payload requires on_push.url.")
+	}
+	if payload := onPush.Payload; !payload.IsNull() && !payload.IsUnknown() {
+		if _, err := template.New("payload").Option("missingkey=error").Parse(payload.ValueString()); err != nil {
+			diags.AddAttributeError(path.Root("on_push").AtName("payload"), "Invalid payload", err.Error())
+		}
+	}
+	if command := onPush.Command; !command.IsNull() && !command.IsUnknown() && len(command.Elements()) == 0 {
+		diags.AddAttributeError(path.Root("on_push").AtName("command"), "Invalid command", "on_push.command must not be empty.")
+	}
+	return diags
+}
+
+// runOnPushHooks notifies the webhook and runs the command of the on_push block of model about the pushed image.
+func (r *ComposeResource) runOnPushHooks(ctx context.Context, model *ComposeResourceModel) error {
+	onPush := model.OnPush
+	if onPush == nil {
+		return nil
+	}
+	imageURI := registryImageURI(model)
+	digest := model.SHA256Digest.ValueString()
+	values := map[string]string{
+		"image_uri": imageURI,
+		"image_ref": previousImageRef(imageURI, digest),
+		"digest":    digest,
+	}
+	ctx, cancel := context.WithTimeout(ctx, onPushTimeout)
+	defer cancel()
+	if !onPush.URL.IsNull() {
+		if err := notifyWebhook(ctx, onPush, values); err != nil {
+			return fmt.Errorf("on_push webhook failed after pushing %s: %w", values["image_ref"], err)
+		}
+	}
+	if !onPush.Command.IsNull() {
+		if err := runOnPushCommand(ctx, onPush, values); err != nil {
+			return fmt.Errorf("on_push command failed after pushing %s: %w", values["image_ref"], err)
+		}
+	}
+	return nil
+}
+
+// notifyWebhook POSTs the payload of onPush, rendered with values, to onPush.url, and fails unless it responds with 2xx.
+func notifyWebhook(ctx context.Context, onPush *OnPushModel, values map[string]string) error {
+	var body []byte
+	if onPush.Payload.IsNull() {
+		var err error
+		if body, err = json.Marshal(values); err != nil {
+			return err
+		}
+	} else {
+		payload, err := template.New("payload").Option("missingkey=error").Parse(onPush.Payload.ValueString())
+		if err != nil {
+			return fmt.Errorf("invalid payload: %w", err)
+		}
+		var rendered bytes.Buffer
+		if err := payload.Execute(&rendered, values); err != nil {
+			return fmt.Errorf("failed to render payload: %w", err)
+		}
+		body = rendered.Bytes()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, onPush.URL.ValueString(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	headers := map[string]string{}
+	if diags := onPush.Headers.ElementsAs(ctx, &headers, false); diags.HasError() {
+		return errors.New("failed to read on_push.headers")
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := logging.NewHTTPLoggingClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s responded with %s: %s", req.URL.Redacted(), resp.Status, strings.TrimSpace(string(message)))
+	}
+	tflog.Info(ctx, "Notified on_push webhook", map[string]interface{}{
+		"url":       req.URL.Redacted(),
+		"image_ref": values["image_ref"],
+	})
+	return nil
+}
+
+// runOnPushCommand runs onPush.command with the pushed image in its environment, and fails unless it exits with 0.
+func runOnPushCommand(ctx context.Context, onPush *OnPushModel, values map[string]string) error {
+	var args []string
+	if diags := onPush.Command.ElementsAs(ctx, &args, false); diags.HasError() || len(args) == 0 {
+		return errors.New("failed to read on_push.command")
+	}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = append(os.Environ(),
+		"IMAGE_URI="+values["image_uri"],
+		"IMAGE_REF="+values["image_ref"],
+		"IMAGE_DIGEST="+values["digest"],
+	)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w: %s", args[0], err, strings.TrimSpace(output.String()))
+	}
+	tflog.Info(ctx, "Ran on_push command", map[string]interface{}{
+		"command":   args[0],
+		"image_ref": values["image_ref"],
+		"output":    strings.TrimSpace(output.String()),
+	})
+	return nil
+}
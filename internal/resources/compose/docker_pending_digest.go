@@ -0,0 +1,65 @@
+package compose
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// errDigestPending is returned when the image was pushed, but its digest could not be read from the registry afterwards.
+var errDigestPending = errors.New("the image was pushed, but its digest could not be read from the registry")
+
+// pendingDigestPrivateKey is the key of the private state recording an image pushed without its digest,
+// for the next refresh to read the digest rather than to rebuild the image.
+const pendingDigestPrivateKey = "pending_digest"
+
+// pendingDigest is an image pushed by the last apply whose digest is not recorded yet.
+type pendingDigest struct {
+	ImageURI string `json:"image_uri"`
+}
+
+// recordPendingDigest records in the private state that the image at imageURI was pushed without its digest,
+// and warns that the digest is read by the next refresh. err is the error reading the digest.
+func recordPendingDigest(ctx context.Context, private privateState, imageURI string, err error) diag.Diagnostics {
+	var diags diag.Diagnostics
+	body, marshalErr := json.Marshal(pendingDigest{ImageURI: imageURI})
+	if marshalErr != nil {
+		diags.AddError("Error recording pending digest", marshalErr.Error())
+		return diags
+	}
+	diags.Append(private.SetKey(ctx, pendingDigestPrivateKey, body)...)
+	tflog.Warn(ctx, "Image pushed without its digest", map[string]interface{}{
+		"image_uri": imageURI,
+		"error":     err.Error(),
+	})
+	diags.AddWarning(
+		"Image digest not recorded",
+		fmt.Sprintf("Image %s was pushed, but its digest could not be read from the registry: %s\n\n"+
+			"sha256_digest is null until the next refresh reads the digest; the image is not built again. "+
+			"Steps after the push (e.g. additional_tags, wait_for_scan and on_push) were skipped.", imageURI, err),
+	)
+	return diags
+}
+
+// resolvePendingDigest removes the record of recordPendingDigest once a refresh has read the digest of the image.
+func resolvePendingDigest(ctx context.Context, private privateState, digest string) diag.Diagnostics {
+	body, diags := private.GetKey(ctx, pendingDigestPrivateKey)
+	if len(body) == 0 || diags.HasError() {
+		return diags
+	}
+	var pending pendingDigest
+	if err := json.Unmarshal(body, &pending); err != nil {
+		diags.AddError("Error reading pending digest", err.Error())
+		return diags
+	}
+	tflog.Info(ctx, "Read the digest of the image pushed by the last apply", map[string]interface{}{
+		"image_uri": pending.ImageURI,
+		"digest":    digest,
+	})
+	diags.Append(private.SetKey(ctx, pendingDigestPrivateKey, nil)...)
+	return diags
+}
@@ -0,0 +1,30 @@
+package compose
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// pingRegistry checks that the registry of image_uri is reachable and accepts the configured credentials.
+// This is done before building so that problems are reported without waiting for a long build.
+func (r *ComposeResource) pingRegistry(ctx context.Context, model *ComposeResourceModel) error {
+	imageURI := model.ImageURI.ValueString()
+	host, err := registryHostFromImageURI(imageURI)
+	if err != nil {
+		return err
+	}
+
+	authConfig, err := r.getAuthConfig(ctx, imageURI)
+	if err != nil {
+		return fmt.Errorf("failed to get authentication configuration: %w", err)
+	}
+
+	tflog.Debug(ctx, "Checking registry availability", map[string]interface{}{
+		"registry_host": host,
+	})
+	return registryclient.Ping(ctx, registryclient.NewHTTPClient(), host, r.GetHTTPAuthHeader(ctx, authConfig))
+}
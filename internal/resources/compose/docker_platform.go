@@ -0,0 +1,90 @@
+package compose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	composetypes "github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/docker/client"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// containerdSnapshotterDriverType is the storage driver type reported by `docker info`
+// when the daemon uses the containerd image store, which is required to hold multi-platform images.
+const containerdSnapshotterDriverType = "io.containerd.snapshotter.v1"
+
+// checkBuildPlatforms verifies that build.platforms can be honored by the build and push path.
+// Compose forwards build.platforms to BuildKit, but the classic builder ignores it entirely and
+// a multi-platform image can only be loaded into (and pushed from) a daemon using the containerd image store.
+func (r *ComposeResource) checkBuildPlatforms(
+	ctx context.Context,
+	dockerCli command.Cli,
+	dockerClient *client.Client,
+	buildSpec *composetypes.BuildConfig,
+) error {
+	if len(buildSpec.Platforms) == 0 {
+		return nil
+	}
+
+	buildkit, err := dockerCli.BuildKitEnabled()
+	if err != nil {
+		return fmt.Errorf("failed to determine whether BuildKit is enabled: %w", err)
+	}
+	if !buildkit {
+		return errors.New("build.platforms requires BuildKit, but BuildKit is disabled (DOCKER_BUILDKIT=0)")
+	}
+
+	if len(buildSpec.Platforms) == 1 {
+		return nil
+	}
+
+	containerd, err := usesContainerdImageStore(ctx, dockerClient)
+	if err != nil {
+		return err
+	}
+	if containerd {
+		tflog.Debug(ctx, "Building multi-platform image", map[string]interface{}{
+			"platforms": strings.Join(buildSpec.Platforms, ","),
+		})
+		return nil
+	}
+	return fmt.Errorf(
+		"build.platforms specifies multiple platforms (%s), which requires the Docker daemon to use the containerd image store",
+		strings.Join(buildSpec.Platforms, ", "),
+	)
+}
+
+// checkBuiltManifest verifies that the manifest BuildKit builds for model reaches the registry as built, for attribute,
+// which sets it: the classic image store of the Docker daemon rebuilds the manifest when the image is loaded,
+// unless BuildKit pushes the image with direct_push.
+func checkBuiltManifest(ctx context.Context, dockerClient *client.Client, model *ComposeResourceModel, attribute string) error {
+	if model.DirectPush.ValueBool() {
+		return nil
+	}
+	containerd, err := usesContainerdImageStore(ctx, dockerClient)
+	if err != nil {
+		return err
+	}
+	if !containerd {
+		return fmt.Errorf("%s requires the manifest built by BuildKit, which the classic image store of the Docker daemon does not keep: "+
+			"enable the containerd image store, or set direct_push", attribute)
+	}
+	return nil
+}
+
+// usesContainerdImageStore reports whether the Docker daemon uses the containerd image store.
+func usesContainerdImageStore(ctx context.Context, dockerClient *client.Client) (bool, error) {
+	info, err := dockerClient.Info(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get Docker daemon info: %w", err)
+	}
+	for _, status := range info.DriverStatus {
+		if status[0] == "driver-type" && status[1] == containerdSnapshotterDriverType {
+			return true, nil
+		}
+	}
+	return false, nil
+}
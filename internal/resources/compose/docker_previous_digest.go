@@ -0,0 +1,29 @@
+package compose
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	tfplugintypes "github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// previousDigestAttribute returns the schema of the previous_digest attribute shared by the image resources.
+func previousDigestAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		MarkdownDescription: "`sha256_digest` of the image pushed before the last rebuild that pushed another image, " +
+			"e.g. to roll back to the last known-good image or for blue-green deployments. " +
+			"Kept when a rebuild pushes the same image again. Null until the resource pushes a second image.",
+		Computed: true,
+	}
+}
+
+// previousDigestValue returns previous_digest after an update pushing the image with digest current:
+// the digest pushed by the previous apply (prior) when it differs, or the previous_digest recorded so far.
+// pushed is false when either image is not in the registry, e.g. with dry_run, and keeps previous_digest.
+func previousDigestValue(prior, previous, current tfplugintypes.String, pushed bool) tfplugintypes.String {
+	if pushed && prior.ValueString() != "" && !prior.IsUnknown() && prior.ValueString() != current.ValueString() {
+		return prior
+	}
+	if previous.IsUnknown() {
+		return tfplugintypes.StringNull()
+	}
+	return previous
+}
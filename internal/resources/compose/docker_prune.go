@@ -0,0 +1,35 @@
+package compose
+
+import (
+	"context"
+
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/client"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// removeLocalImage removes the locally built image from the Docker daemon.
+// Untagged parent images are pruned as well, like `docker rmi` does by default, only with prune_local_parents:
+// otherwise they are kept as cache for the next builds.
+// Failures are only logged because the image has already been pushed successfully.
+func (r *ComposeResource) removeLocalImage(ctx context.Context, dockerClient *client.Client, model *ComposeResourceModel) {
+	tflog.Info(ctx, "Removing local image", map[string]interface{}{
+		"image_uri": model.ImageURI.ValueString(),
+	})
+
+	removed, err := dockerClient.ImageRemove(ctx, model.ImageURI.ValueString(), image.RemoveOptions{
+		PruneChildren: model.PruneLocalParents.ValueBool(),
+	})
+	if err != nil {
+		tflog.Warn(ctx, "Failed to remove local image: ignored", map[string]interface{}{
+			"image_uri": model.ImageURI.ValueString(),
+			"error":     err.Error(),
+		})
+		return
+	}
+
+	tflog.Debug(ctx, "Removed local image", map[string]interface{}{
+		"image_uri": model.ImageURI.ValueString(),
+		"removed":   len(removed),
+	})
+}
@@ -8,10 +8,11 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"time"
 
 	composetypes "github.com/compose-spec/compose-go/v2/types"
+	cerrdefs "github.com/containerd/errdefs"
 	"github.com/docker/cli/cli/command"
-	"github.com/docker/cli/cli/flags"
 	"github.com/docker/compose/v5/pkg/api"
 	"github.com/docker/compose/v5/pkg/compose"
 	"github.com/docker/docker/api/types/image"
@@ -24,8 +25,23 @@ import (
 	"github.com/ikedam/terraform-provider-containerregistry/internal/logging"
 )
 
+// pushStats summarizes the layers reported in the Docker push stream.
+type pushStats struct {
+	// LayersPushed is the number of layers uploaded to the registry.
+	LayersPushed int
+	// LayersExisting is the number of layers the registry already had.
+	LayersExisting int
+	// PushedBytes is the total size of the uploaded layers.
+	PushedBytes int64
+	// Skipped is set when the registry already had the image, which was not pushed.
+	Skipped bool
+}
+
+// pushProgressInterval is the interval at which the overall progress of a push is logged.
+const pushProgressInterval = 30 * time.Second
+
 // pushDockerImage pushes a Docker image to the registry
-func (r *ComposeResource) pushDockerImage(ctx context.Context, dockerClient *client.Client, model *ComposeResourceModel) error {
+func (r *ComposeResource) pushDockerImage(ctx context.Context, dockerClient *client.Client, model *ComposeResourceModel) (*pushStats, error) {
 	tflog.Info(ctx, "Pushing Docker image to registry", map[string]interface{}{
 		"image_uri": model.ImageURI.ValueString(),
 	})
@@ -33,7 +49,7 @@ func (r *ComposeResource) pushDockerImage(ctx context.Context, dockerClient *cli
 	// Get authentication configuration
 	authConfig, err := r.getAuthConfig(ctx, model.ImageURI.ValueString())
 	if err != nil {
-		return fmt.Errorf("failed to get authentication configuration: %w", err)
+		return nil, fmt.Errorf("failed to get authentication configuration: %w", err)
 	}
 
 	// Create encoded authentication string for Docker API
@@ -41,7 +57,7 @@ func (r *ComposeResource) pushDockerImage(ctx context.Context, dockerClient *cli
 	if authConfig != nil {
 		encodedAuth, err = r.GetEncodedAuthConfig(ctx, authConfig)
 		if err != nil {
-			return fmt.Errorf("failed to encode auth config: %w", err)
+			return nil, fmt.Errorf("failed to encode auth config: %w", err)
 		}
 		tflog.Debug(ctx, "Using authentication for pushing image")
 	} else {
@@ -55,49 +71,118 @@ func (r *ComposeResource) pushDockerImage(ctx context.Context, dockerClient *cli
 
 	pushResponse, err := dockerClient.ImagePush(ctx, model.ImageURI.ValueString(), pushOptions)
 	if err != nil {
-		return fmt.Errorf("failed to push image: %w", err)
+		return nil, fmt.Errorf("failed to push image: %w", err)
 	}
 	defer pushResponse.Close()
+	// Close the stream on cancellation, so that a push the daemon no longer reports on is abandoned at once,
+	// and the daemon aborts the upload when the connection is closed
+	stop := context.AfterFunc(ctx, func() { _ = pushResponse.Close() })
+	defer stop()
 
 	// Docker Registry API returns HTTP 200 even on push failure; errors are sent
 	// in the JSON stream (error/errorDetail). We must parse the stream to detect failures.
-	err = parsePushResponse(pushResponse)
+	stats, err := parsePushResponse(ctx, pushResponse)
 	if err != nil {
-		return fmt.Errorf("push failed: %w", err)
+		if ctx.Err() != nil {
+			// Reading the closed stream failed; report the cancellation, which is not retried
+			return nil, fmt.Errorf("push canceled: %w", ctx.Err())
+		}
+		return nil, fmt.Errorf("push failed: %w", err)
 	}
 
 	tflog.Info(ctx, "Successfully pushed Docker image to registry", map[string]interface{}{
-		"image_uri": model.ImageURI.ValueString(),
+		"image_uri":       model.ImageURI.ValueString(),
+		"layers_pushed":   stats.LayersPushed,
+		"layers_existing": stats.LayersExisting,
+		"pushed_bytes":    stats.PushedBytes,
 	})
 
-	return nil
+	return stats, nil
 }
 
 // parsePushResponse reads the Docker push JSON stream and returns an error
 // if any line contains "error" or "errorDetail". The Registry API returns HTTP 200
 // even on failure and signals errors only in the stream body.
-func parsePushResponse(r io.Reader) error {
+// It also collects per-layer statistics from the progress messages, logs each layer when it is done,
+// and logs the overall progress every pushProgressInterval, so that a long upload can be told from a stuck push.
+func parsePushResponse(ctx context.Context, r io.Reader) (*pushStats, error) {
+	stats := &pushStats{}
+	layers := map[string]bool{}
+	layerSizes := map[string]int64{}
+	layerUploaded := map[string]int64{}
+	lastReport := time.Now()
 	dec := json.NewDecoder(r)
 	for {
 		var jm jsonmessage.JSONMessage
 		if err := dec.Decode(&jm); err != nil {
 			if err == io.EOF {
-				return nil
+				return stats, nil
 			}
-			return fmt.Errorf("failed to parse push response: %w", err)
+			return nil, fmt.Errorf("failed to parse push response: %w", err)
 		}
-		if jm.Error != nil {
-			return jm.Error
+		if err := jsonMessageError(&jm); err != nil {
+			return nil, err
+		}
+		switch {
+		case jm.Status == "Preparing" && jm.ID != "":
+			layers[jm.ID] = true
+		case jm.Status == "Pushing" && jm.Progress != nil && jm.ID != "":
+			if jm.Progress.Total > layerSizes[jm.ID] {
+				layerSizes[jm.ID] = jm.Progress.Total
+			}
+			layerUploaded[jm.ID] = jm.Progress.Current
+		case jm.Status == "Pushed":
+			stats.LayersPushed++
+			stats.PushedBytes += layerSizes[jm.ID]
+			layerUploaded[jm.ID] = layerSizes[jm.ID]
+			tflog.Info(ctx, "Pushed layer", map[string]interface{}{
+				"layer": jm.ID,
+				"bytes": layerSizes[jm.ID],
+			})
+		case jm.Status == "Layer already exists":
+			stats.LayersExisting++
+			tflog.Info(ctx, "Layer already exists in the registry", map[string]interface{}{
+				"layer": jm.ID,
+			})
+		}
+
+		if time.Since(lastReport) >= pushProgressInterval {
+			lastReport = time.Now()
+			var uploaded, total int64
+			for id, size := range layerSizes {
+				total += size
+				uploaded += layerUploaded[id]
+			}
+			tflog.Info(ctx, "Push in progress", map[string]interface{}{
+				"layers":          len(layers),
+				"layers_pushed":   stats.LayersPushed,
+				"layers_existing": stats.LayersExisting,
+				"uploaded_bytes":  uploaded,
+				"uploading_bytes": total,
+			})
 		}
 	}
 }
 
+// jsonMessageError returns the error reported by a message of a Docker Engine JSON stream, or nil.
+// Besides errorDetail, the error field alone is checked, as some daemons and proxies only set that one.
+func jsonMessageError(jm *jsonmessage.JSONMessage) error {
+	if jm.Error != nil {
+		return jm.Error
+	}
+	if jm.ErrorMessage != "" {
+		return errors.New(jm.ErrorMessage)
+	}
+	return nil
+}
+
 // buildDockerImageWithCompose builds a Docker image using Docker Compose SDK
 func (r *ComposeResource) buildDockerImageWithCompose(
 	ctx context.Context,
 	composeService api.Compose,
 	buildSpec *composetypes.BuildConfig,
 	model *ComposeResourceModel,
+	builder string,
 	out io.Writer,
 ) error {
 	tflog.Info(ctx, "Building Docker image using Docker Compose API", map[string]interface{}{
@@ -111,7 +196,7 @@ func (r *ComposeResource) buildDockerImageWithCompose(
 	if err != nil {
 		return fmt.Errorf("failed to get working directory: %w", err)
 	}
-	if !filepath.IsAbs(buildSpec.Context) {
+	if !filepath.IsAbs(buildSpec.Context) && !isRemoteContext(buildSpec.Context) {
 		buildSpec.Context = filepath.Join(cwd, buildSpec.Context)
 	}
 
@@ -131,8 +216,8 @@ func (r *ComposeResource) buildDockerImageWithCompose(
 		Build: buildSpec,
 	}
 
-	// Set labels from the model
-	labels := r.extractLabels(model)
+	// Set labels from the model, adding the OCI standard labels when requested
+	labels := r.buildLabels(ctx, buildSpec, model)
 	if len(labels) > 0 {
 		service.Build.Labels = composetypes.Labels{}
 		for key, value := range labels {
@@ -151,12 +236,17 @@ func (r *ComposeResource) buildDockerImageWithCompose(
 	buildOptions := api.BuildOptions{
 		Out:      out,
 		Services: []string{serviceName},
+		Builder:  builder,
 	}
 	if model.Option != nil {
 		buildOptions.Pull = model.Option.Pull.ValueBool()
 		buildOptions.NoCache = model.Option.NoCache.ValueBool()
 		buildOptions.Progress = model.Option.Progress.ValueString()
+		buildOptions.Provenance = model.Option.Provenance.ValueString()
+		buildOptions.SBOM = model.Option.SBOM.ValueString()
 	}
+	// With direct_push, bake outputs the image to the registry (type=registry) instead of the Docker daemon
+	buildOptions.Push = model.DirectPush.ValueBool()
 
 	// Execute the build
 	err = composeService.Build(ctx, project, buildOptions)
@@ -182,6 +272,8 @@ type buildLogConfig struct {
 	Timestamp bool
 	Lines     int
 	Log       string
+	Steps     bool
+	File      string
 }
 
 // getBuildLogConfig returns buildlog config. Uses schema defaults when buildlog block is absent.
@@ -197,19 +289,119 @@ func (r *ComposeResource) getBuildLogConfig(model *ComposeResourceModel) buildLo
 		if !model.BuildLog.Log.IsNull() {
 			cfg.Log = model.BuildLog.Log.ValueString()
 		}
+		cfg.Steps = model.BuildLog.Steps.ValueBool()
+		cfg.File = model.BuildLog.File.ValueString()
 	}
 	return cfg
 }
 
+// acquireBuildSlot waits for a free slot of the provider max_parallel_builds and returns the function releasing it.
+func (r *ComposeResource) acquireBuildSlot(ctx context.Context) (func(), error) {
+	if r.providerConfig == nil || r.providerConfig.BuildSlots == nil {
+		return func() {}, nil
+	}
+	slots := r.providerConfig.BuildSlots
+	select {
+	case slots <- struct{}{}:
+	default:
+		tflog.Info(ctx, "Waiting for another build to finish (max_parallel_builds)", map[string]interface{}{
+			"max_parallel_builds": cap(slots),
+		})
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("canceled while waiting for another build to finish (max_parallel_builds): %w", ctx.Err())
+		}
+	}
+	return func() { <-slots }, nil
+}
+
 // buildAndPushImage builds and pushes an image based on the provided model.
 // On build failure, it also returns the last N buffered build log lines
-func (r *ComposeResource) buildAndPushImage(ctx context.Context, model *ComposeResourceModel) ([]string, error) {
+// previousImage is the image pushed by the previous apply, used as cache source with cache_from_previous,
+// and empty when the resource is created.
+// Durations and push statistics are recorded into metrics.
+func (r *ComposeResource) buildAndPushImage(ctx context.Context, model *ComposeResourceModel, previousImage string, metrics *buildMetrics) ([]string, error) {
 	tflog.Debug(ctx, "Building and pushing image", map[string]interface{}{
 		"image_uri": model.ImageURI.ValueString(),
 	})
+	model.AttestationDigests = tfplugintypes.MapNull(tfplugintypes.StringType)
+	model.PushedImageURI = tfplugintypes.StringNull()
+	model.ImageRef = tfplugintypes.StringNull()
+	model.ScanFindings = tfplugintypes.MapNull(tfplugintypes.Int64Type)
+
+	// Fail before the build, rather than on the push, when the repository cannot be created
+	if !skipPush(model) {
+		if err := r.ensureRepository(ctx, model); err != nil {
+			return nil, err
+		}
+	}
 
-	// Install buildx plugin if provider is configured to do so and it is missing
-	if r.providerConfig != nil && r.providerConfig.BuildxInstallIfMissing {
+	release, err := r.acquireBuildSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	lastBuildLines, err := r.buildAndPublishImage(ctx, model, previousImage, metrics)
+	if errors.Is(err, errDigestPending) {
+		// The image is in the registry: it is recorded without its digest, which the next refresh reads
+		model.SHA256Digest = tfplugintypes.StringNull()
+		model.PushedImageURI = tfplugintypes.StringValue(registryImageURI(model))
+		return nil, err
+	}
+	if err != nil {
+		return lastBuildLines, err
+	}
+	if skipPush(model) {
+		return nil, nil
+	}
+	return nil, r.completePush(ctx, model, metrics)
+}
+
+// completePush records the pushed image in model, whose sha256_digest is set by the push,
+// and runs the steps following the push: checks, additional tags, waits and on_push hooks.
+func (r *ComposeResource) completePush(ctx context.Context, model *ComposeResourceModel, metrics *buildMetrics) error {
+	model.PushedImageURI = tfplugintypes.StringValue(registryImageURI(model))
+	model.ImageRef = imageRefValue(model)
+	// A skipped push keeps an image that was not built with media_type
+	if !model.MediaType.IsNull() && !metrics.Push.Skipped {
+		if err := r.verifyMediaType(ctx, model); err != nil {
+			return err
+		}
+	}
+	if err := r.waitForImageScan(ctx, model); err != nil {
+		return err
+	}
+	err := withPushTimeout(ctx, model.Timeouts, func(ctx context.Context) error {
+		return r.pushAdditionalTags(ctx, model)
+	})
+	if err != nil {
+		return err
+	}
+	if err := r.waitForAvailable(ctx, model); err != nil {
+		return err
+	}
+	if err := r.waitForReplication(ctx, model); err != nil {
+		return err
+	}
+	return r.runOnPushHooks(ctx, model)
+}
+
+// buildAndPublishImage builds the image, or takes the existing image, and pushes it as image_uri.
+func (r *ComposeResource) buildAndPublishImage(ctx context.Context, model *ComposeResourceModel, previousImage string, metrics *buildMetrics) ([]string, error) {
+	// Nothing to build when an existing image is pushed
+	if !model.SourceImage.IsNull() {
+		return nil, r.tagAndPushImage(ctx, model, metrics)
+	}
+	if !model.SourceOCILayout.IsNull() || !model.SourceTarball.IsNull() {
+		return nil, withPushTimeout(ctx, model.Timeouts, func(ctx context.Context) error {
+			return r.pushImageFromDisk(ctx, model, metrics)
+		})
+	}
+
+	// Install buildx plugin if provider is configured to do so and it is missing (never downloaded offline)
+	if r.providerConfig != nil && r.providerConfig.BuildxInstallIfMissing && !model.Offline.ValueBool() {
 		if err := buildx.EnsureInstalled(ctx, r.providerConfig.BuildxVersion, logging.NewHTTPLoggingClient()); err != nil {
 			return nil, fmt.Errorf("failed to install buildx plugin: %w", err)
 		}
@@ -221,8 +413,31 @@ func (r *ComposeResource) buildAndPushImage(ctx context.Context, model *ComposeR
 		return nil, fmt.Errorf("failed to parse build specification: %w", err)
 	}
 
+	if platform := model.Platform.ValueString(); platform != "" {
+		if len(buildSpec.Platforms) > 0 {
+			return nil, errors.New("platform and build.platforms cannot be specified together")
+		}
+		buildSpec.Platforms = composetypes.StringList{platform}
+	}
+
+	if err := prepareSSH(ctx, buildSpec); err != nil {
+		return nil, err
+	}
+
+	// Check the registry before building, which can take many minutes
+	if !skipPush(model) {
+		if err := r.pingRegistry(ctx, model); err != nil {
+			return nil, fmt.Errorf("registry preflight check failed: %w", err)
+		}
+	}
+
 	buildLogCfg := r.getBuildLogConfig(model)
-	capture := newBuildLogCapture(ctx, buildLogCfg.Timestamp, buildLogCfg.Lines, buildLogCfg.Log)
+	capture := newBuildLogCapture(ctx, buildLogCfg.Timestamp, buildLogCfg.Lines, buildLogCfg.Log, buildLogCfg.Steps)
+	if buildLogCfg.File != "" {
+		if err := capture.WriteToFile(buildLogCfg.File); err != nil {
+			return nil, err
+		}
+	}
 	defer func() {
 		_ = capture.Close()
 		capture.Wait()
@@ -234,8 +449,14 @@ func (r *ComposeResource) buildAndPushImage(ctx context.Context, model *ComposeR
 		return nil, fmt.Errorf("failed to create Docker CLI: %w", err)
 	}
 
-	clientOpts := &flags.ClientOptions{}
-	err = dockerCli.Initialize(clientOpts,
+	// builder = "podman" talks to the Podman API socket instead of the Docker daemon
+	var daemonHost string
+	if model.Builder.ValueString() == builderPodman {
+		if daemonHost, err = podmanHost(); err != nil {
+			return nil, err
+		}
+	}
+	err = dockerCli.Initialize(r.dockerCliOptions(daemonHost),
 		command.WithOutputStream(capture.Writer()),
 		command.WithErrorStream(capture.Writer()),
 	)
@@ -245,43 +466,262 @@ func (r *ComposeResource) buildAndPushImage(ctx context.Context, model *ComposeR
 
 	capture.Start(ctx)
 
+	// kaniko builds and pushes the image itself, without the Docker daemon
+	if model.Builder.ValueString() == builderKaniko {
+		err := r.buildWithKaniko(ctx, buildSpec, model, capture.Writer(), metrics)
+		if err != nil {
+			_ = capture.Close()
+			capture.Wait()
+			return capture.GetLastLines(), fmt.Errorf("failed to build image: %w", err)
+		}
+		return nil, nil
+	}
+
+	// Apply the builder attribute before anything asks whether BuildKit is enabled
+	buildCli, err := r.selectBuilder(dockerCli, model)
+	if err != nil {
+		return nil, err
+	}
+
+	dockerClient, err := r.newDockerClient(daemonHost)
+	if err != nil {
+		return nil, err
+	}
+	defer dockerClient.Close()
+
+	// Fail before building when build.platforms cannot be honored
+	if err := r.checkBuildPlatforms(ctx, buildCli, dockerClient, buildSpec); err != nil {
+		return nil, err
+	}
+	if err := prepareCacheBackends(buildCli, buildSpec, r.providerConfig); err != nil {
+		return nil, err
+	}
+	if err := checkAttestations(buildCli, model.Option); err != nil {
+		return nil, err
+	}
+	if err := checkBuildFields(buildCli, buildSpec); err != nil {
+		return nil, err
+	}
+	if err := checkEntitlements(buildSpec); err != nil {
+		return nil, err
+	}
+	if err := checkSquash(buildSpec, model); err != nil {
+		return nil, err
+	}
+	if model.Offline.ValueBool() {
+		if err := checkOfflineImages(ctx, dockerClient, buildSpec, model.FrontendImage.ValueString()); err != nil {
+			return nil, err
+		}
+	}
+	if err := applyFrontendImage(buildCli, buildSpec, model.FrontendImage.ValueString()); err != nil {
+		return nil, err
+	}
+	if model.CacheFromPrevious.ValueBool() {
+		if err := cacheFromPreviousImage(buildCli, buildSpec, previousImage); err != nil {
+			return nil, err
+		}
+	}
+	cleanupContext, err := prepareContextArchive(ctx, buildSpec)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanupContext()
+	cleanupAdditionalContexts, err := prepareClassicAdditionalContexts(ctx, buildCli, buildSpec)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanupAdditionalContexts()
+	cleanupDockerfile, err := prepareDockerfileInline(buildCli, buildSpec)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanupDockerfile()
+	allow, err := bakeAllowedEntitlements(buildCli, buildSpec)
+	if err != nil {
+		return nil, err
+	}
+	wrapper := &bakeWrapper{allow: allow}
+	if model.DirectPush.ValueBool() {
+		if err := checkBake(buildCli, "direct_push"); err != nil {
+			return nil, err
+		}
+		wrapper = r.directPushWrapper(allow)
+	}
+	if len(model.Annotations.Elements()) > 0 {
+		if err := checkBake(buildCli, "annotations"); err != nil {
+			return nil, err
+		}
+		if err := checkBuiltManifest(ctx, dockerClient, model, "annotations"); err != nil {
+			return nil, err
+		}
+		wrapper.annotations = bakeAnnotations(buildSpec, model)
+	}
+	if mediaType := model.MediaType.ValueString(); mediaType != "" {
+		if err := checkBake(buildCli, "media_type"); err != nil {
+			return nil, err
+		}
+		// The classic image store pushes Docker distribution v2 manifests whatever BuildKit builds
+		if mediaType == mediaTypeOCI {
+			if err := checkBuiltManifest(ctx, dockerClient, model, "media_type = \"oci\""); err != nil {
+				return nil, err
+			}
+		}
+		wrapper.output = bakeOutput(buildSpec, model.DirectPush.ValueBool(), mediaType)
+		wrapper.noProvenance = mediaType == mediaTypeDocker
+	}
+	cleanupWrapper, err := wrapper.install(buildCli)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanupWrapper()
+
 	// Initialize Docker Compose service with the CLI
-	composeService, err := compose.NewComposeService(dockerCli)
+	composeService, err := compose.NewComposeService(buildCli)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Docker Compose service: %w", err)
 	}
 
+	// Register the remote builder, if configured, before the build refers to it
+	builder, err := r.remoteBuilderName(ctx, buildCli)
+	if err != nil {
+		return nil, err
+	}
+	if builder != "" && model.Offline.ValueBool() {
+		return nil, fmt.Errorf("offline requires the builder of the Docker daemon, which uses the local images, but the remote builder %s is configured", builder)
+	}
+	if builder == "" && !model.Offline.ValueBool() {
+		// Without a remote builder, provision a temporary builder when the current one cannot export the cache
+		// (not offline, as the builder image would be pulled)
+		var removeBuilder func()
+		builder, removeBuilder, err = r.ephemeralBuilderName(ctx, buildCli, dockerClient, buildSpec)
+		if err != nil {
+			return nil, err
+		}
+		defer removeBuilder()
+	}
+
 	// Build the Docker image using Docker Compose API
-	err = r.buildDockerImageWithCompose(ctx, composeService, buildSpec, model, capture.Writer())
+	buildStart := time.Now()
+	err = r.buildDockerImageWithCompose(ctx, composeService, buildSpec, model, builder, capture.Writer())
+	metrics.BuildDuration = time.Since(buildStart)
 	if err != nil {
 		_ = capture.Close()
 		capture.Wait()
 		return capture.GetLastLines(), fmt.Errorf("failed to build Docker image: %w", err)
 	}
+	if model.DirectPush.ValueBool() {
+		// BuildKit pushed the image while building, so the push is included in the build duration
+		return nil, r.recordDirectPushDigest(ctx, model, wrapper.metadataFile)
+	}
+	if model.Squash.ValueBool() {
+		if err := r.squashImage(ctx, dockerClient, model); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.runSmokeTest(ctx, dockerClient, model); err != nil {
+		return nil, err
+	}
 
-	dockerClient, err := client.NewClientWithOpts(
-		client.FromEnv,
-		client.WithAPIVersionNegotiation(),
-		withLoggingHTTPClient,
-	)
+	// Export and push the image to the registry (all platforms when build.platforms lists several)
+	return nil, r.publishLocalImage(ctx, dockerClient, model, metrics)
+}
+
+// publishLocalImage exports the local image tagged as image_uri when an export block is configured,
+// pushes it to the registry unless export.skip_push is set, and loads it into the load_into cluster.
+// Without a push, sha256_digest records the ID of the local image instead of the registry digest.
+func (r *ComposeResource) publishLocalImage(ctx context.Context, dockerClient *client.Client, model *ComposeResourceModel, metrics *buildMetrics) error {
+	if model.Export != nil {
+		if err := r.exportImage(ctx, dockerClient, model); err != nil {
+			return err
+		}
+	}
+	if skipPush(model) {
+		inspect, err := dockerClient.ImageInspect(ctx, model.ImageURI.ValueString())
+		if err != nil {
+			return fmt.Errorf("failed to inspect image: %w", err)
+		}
+		model.SHA256Digest = tfplugintypes.StringValue(inspect.ID)
+	} else {
+		err := withPushTimeout(ctx, model.Timeouts, func(ctx context.Context) error {
+			return r.pushAndRecordDigest(ctx, dockerClient, model, metrics)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if model.LoadInto != nil {
+		if err := r.loadIntoCluster(ctx, model); err != nil {
+			return err
+		}
+	}
+
+	// Remove the local image only after the digest is captured and the image is loaded
+	if model.PruneLocal.ValueBool() {
+		r.removeLocalImage(ctx, dockerClient, model)
+	}
+	return nil
+}
+
+// pushAndRecordDigest pushes the local image tagged as image_uri and records the pushed manifest digest into model.
+// The push is skipped when the registry already has the image tagged as image_uri.
+// With push_by_digest, the image is pushed without the tag of image_uri.
+func (r *ComposeResource) pushAndRecordDigest(ctx context.Context, dockerClient *client.Client, model *ComposeResourceModel, metrics *buildMetrics) error {
+	if model.PushByDigest.ValueBool() {
+		return r.pushImageByDigest(ctx, dockerClient, model, metrics)
+	}
+	digest, err := r.unchangedRemoteDigest(ctx, dockerClient, model.ImageURI.ValueString())
 	if err != nil {
-		return nil, fmt.Errorf("failed to create Docker client: %w", err)
+		// The push itself reports registry errors that matter
+		tflog.Debug(ctx, "Could not compare the local image with the registry", map[string]interface{}{
+			"image_uri": model.ImageURI.ValueString(),
+			"error":     err.Error(),
+		})
+	} else if digest != "" {
+		tflog.Info(ctx, "Skipping push: the registry already has the image", map[string]interface{}{
+			"image_uri": model.ImageURI.ValueString(),
+			"digest":    digest,
+		})
+		metrics.Push.Skipped = true
+		model.SHA256Digest = tfplugintypes.StringValue(digest)
+		return r.recordAttestations(ctx, model)
 	}
-	defer dockerClient.Close()
 
-	// Push the image to the registry
-	err = r.pushDockerImage(ctx, dockerClient, model)
+	pushStart := time.Now()
+	var stats *pushStats
+	err = r.retryPush(ctx, model.ImageURI.ValueString(), func() error {
+		var err error
+		stats, err = r.pushDockerImage(ctx, dockerClient, model)
+		return err
+	})
+	metrics.PushDuration = time.Since(pushStart)
 	if err != nil {
-		return nil, fmt.Errorf("failed to push Docker image: %w", err)
+		if !isTagConflict(err) {
+			return fmt.Errorf("failed to push Docker image: %w", err)
+		}
+		inspect, inspectErr := dockerClient.ImageInspect(ctx, model.ImageURI.ValueString())
+		if inspectErr != nil {
+			return fmt.Errorf("failed to inspect image: %w", inspectErr)
+		}
+		err = r.resolveTagConflict(ctx, model, metrics, err, inspect.ID, func(imageURI string) (string, error) {
+			return r.pushLocalImageAs(ctx, dockerClient, model, imageURI, metrics)
+		})
+		if err != nil {
+			return err
+		}
+		return r.recordAttestations(ctx, model)
 	}
+	metrics.Push = *stats
 
 	// Get the image digest after pushing
 	imageInfo, err := r.getImageInfoFromRegistry(ctx, model)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get image digest after push: %w", err)
+		return fmt.Errorf("%w: %w", errDigestPending, err)
 	}
 	if imageInfo.ManifestDigest == "" {
-		return nil, errors.New("manifest digest is empty")
+		return fmt.Errorf("%w: manifest digest is empty", errDigestPending)
+	}
+	if err := r.verifyLocalImageDigest(ctx, dockerClient, model.ImageURI.ValueString(), model.ImageURI.ValueString(), imageInfo.ManifestDigest); err != nil {
+		return err
 	}
 
 	// Update the model with the SHA256 digest - prioritize the manifest digest for docker pull
@@ -291,5 +731,81 @@ func (r *ComposeResource) buildAndPushImage(ctx context.Context, model *ComposeR
 		"digest":    imageInfo.ManifestDigest,
 	})
 
-	return nil, nil
+	return r.recordAttestations(ctx, model)
+}
+
+// pushLocalImageAs tags the local image tagged as image_uri as imageURI, pushes it and returns the pushed manifest digest.
+// The local tag imageURI is removed afterwards, as image_uri still refers to the image.
+func (r *ComposeResource) pushLocalImageAs(ctx context.Context, dockerClient *client.Client, model *ComposeResourceModel, imageURI string, metrics *buildMetrics) (string, error) {
+	if err := dockerClient.ImageTag(ctx, model.ImageURI.ValueString(), imageURI); err != nil {
+		return "", fmt.Errorf("failed to tag image as %s: %w", imageURI, err)
+	}
+	defer func() {
+		if _, err := dockerClient.ImageRemove(ctx, imageURI, image.RemoveOptions{}); err != nil {
+			tflog.Debug(ctx, "Failed to remove temporary tag", map[string]interface{}{
+				"tag":   imageURI,
+				"error": err.Error(),
+			})
+		}
+	}()
+
+	pushModel := *model
+	pushModel.ImageURI = tfplugintypes.StringValue(imageURI)
+	pushStart := time.Now()
+	var stats *pushStats
+	err := r.retryPush(ctx, imageURI, func() error {
+		var err error
+		stats, err = r.pushDockerImage(ctx, dockerClient, &pushModel)
+		return err
+	})
+	metrics.PushDuration += time.Since(pushStart)
+	if err != nil {
+		return "", fmt.Errorf("failed to push Docker image: %w", err)
+	}
+	metrics.Push = *stats
+
+	imageInfo, err := r.getImageInfoFromRegistry(ctx, &pushModel)
+	if err != nil {
+		return "", fmt.Errorf("failed to get image digest after push: %w", err)
+	}
+	if imageInfo.ManifestDigest == "" {
+		return "", errors.New("manifest digest is empty")
+	}
+	if err := r.verifyLocalImageDigest(ctx, dockerClient, model.ImageURI.ValueString(), imageURI, imageInfo.ManifestDigest); err != nil {
+		return "", err
+	}
+	return imageInfo.ManifestDigest, nil
+}
+
+// tagAndPushImage tags the existing local image source_image as image_uri and pushes it, without building.
+func (r *ComposeResource) tagAndPushImage(ctx context.Context, model *ComposeResourceModel, metrics *buildMetrics) error {
+	sourceImage := model.SourceImage.ValueString()
+	tflog.Info(ctx, "Tagging local image for push", map[string]interface{}{
+		"source_image": sourceImage,
+		"image_uri":    model.ImageURI.ValueString(),
+	})
+
+	if !skipPush(model) {
+		if err := r.pingRegistry(ctx, model); err != nil {
+			return fmt.Errorf("registry preflight check failed: %w", err)
+		}
+	}
+
+	dockerClient, err := r.newDockerClient("")
+	if err != nil {
+		return err
+	}
+	defer dockerClient.Close()
+
+	if _, err := dockerClient.ImageInspect(ctx, sourceImage); err != nil {
+		if cerrdefs.IsNotFound(err) {
+			return fmt.Errorf("source image %s is not present in the local Docker daemon", sourceImage)
+		}
+		return fmt.Errorf("failed to inspect source image %s: %w", sourceImage, err)
+	}
+	if err := dockerClient.ImageTag(ctx, sourceImage, model.ImageURI.ValueString()); err != nil {
+		return fmt.Errorf("failed to tag %s as %s: %w", sourceImage, model.ImageURI.ValueString(), err)
+	}
+
+	return r.publishLocalImage(ctx, dockerClient, model, metrics)
 }
@@ -0,0 +1,65 @@
+package compose
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	tfplugintypes "github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Values of rebuild: when to build and push the image again.
+const (
+	rebuildOnChange = "on_change"
+	rebuildAlways   = "always"
+)
+
+// rebuildAttribute returns the schema of the rebuild attribute shared by the image resources.
+func rebuildAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		MarkdownDescription: "When to build and push the image again: `on_change` rebuilds when the configuration, `triggers` or `context_hash` change, " +
+			"and `always` rebuilds on every apply, without passing `timestamp()` in `triggers`. " +
+			"The push is skipped when the registry already has the built image. Defaults to `on_change`.",
+		Optional: true,
+	}
+}
+
+// validateRebuild reports a rebuild attribute with an unsupported value.
+func validateRebuild(rebuild tfplugintypes.String) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if rebuild.IsNull() || rebuild.IsUnknown() {
+		return diags
+	}
+	switch rebuild.ValueString() {
+	case rebuildOnChange, rebuildAlways:
+	default:
+		diags.AddAttributeError(
+			path.Root("rebuild"),
+			"Invalid rebuild",
+			fmt.Sprintf("rebuild must be %q or %q.", rebuildOnChange, rebuildAlways),
+		)
+	}
+	return diags
+}
+
+// planAlwaysRebuild plans a rebuild of an existing resource with rebuild = "always",
+// marking the attributes written by the build unknown.
+func planAlwaysRebuild(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse, rebuilt map[string]attr.Value) {
+	if req.State.Raw.IsNull() {
+		return
+	}
+	var rebuild tfplugintypes.String
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("rebuild"), &rebuild)...)
+	if resp.Diagnostics.HasError() || rebuild.ValueString() != rebuildAlways {
+		return
+	}
+	tflog.Info(ctx, "Planning a rebuild (rebuild = \"always\")")
+	for name, unknown := range rebuilt {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root(name), unknown)...)
+	}
+}
@@ -0,0 +1,306 @@
+package compose
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	tfplugintypes "github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	ocidigest "github.com/opencontainers/go-digest"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// Values of on_label_change: how an update changing only labels is applied.
+const (
+	labelChangeRebuild = "rebuild"
+	labelChangeMutate  = "mutate"
+)
+
+// onLabelChangeAttribute returns the schema of the on_label_change attribute shared by the image resources.
+func onLabelChangeAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		MarkdownDescription: "How to apply an update changing only `labels`: `rebuild` builds and pushes the image again, " +
+			"and `mutate` rewrites the labels in the image configuration in the registry and pushes the new manifest, without building. " +
+			"`mutate` falls back to a rebuild when anything else changes, or when the image cannot be rewritten " +
+			"(e.g. with `oci_labels`, `export`, `load_into` or a tag that cannot be overwritten). Defaults to `rebuild`.",
+		Optional: true,
+	}
+}
+
+// validateOnLabelChange reports an on_label_change attribute with an unsupported value.
+func validateOnLabelChange(onLabelChange tfplugintypes.String) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if onLabelChange.IsNull() || onLabelChange.IsUnknown() {
+		return diags
+	}
+	switch onLabelChange.ValueString() {
+	case labelChangeRebuild, labelChangeMutate:
+	default:
+		diags.AddAttributeError(
+			path.Root("on_label_change"),
+			"Invalid on_label_change",
+			fmt.Sprintf("on_label_change must be %q or %q.", labelChangeRebuild, labelChangeMutate),
+		)
+	}
+	return diags
+}
+
+// relabelOrBuild applies the update from previous to model: with on_label_change = "mutate", an update changing
+// only labels rewrites the labels of the pushed image, and any other update builds and pushes the image again.
+func (r *ComposeResource) relabelOrBuild(ctx context.Context, req resource.UpdateRequest, previous, model *ComposeResourceModel, metrics *buildMetrics) ([]string, error) {
+	if reason := relabelBlocker(ctx, req, previous, model); reason != "" {
+		if model.OnLabelChange.ValueString() == labelChangeMutate {
+			tflog.Info(ctx, "Rebuilding the image instead of rewriting its labels", map[string]interface{}{
+				"image_uri": model.ImageURI.ValueString(),
+				"reason":    reason,
+			})
+		}
+	} else {
+		err := r.relabelImage(ctx, previous, model, metrics)
+		if !isTagConflict(err) {
+			return nil, err
+		}
+		// A rebuild pushes the image as on_tag_conflict tells
+		tflog.Info(ctx, "Rebuilding the image instead of rewriting its labels", map[string]interface{}{
+			"image_uri": model.ImageURI.ValueString(),
+			"reason":    err.Error(),
+		})
+		*metrics = buildMetrics{}
+	}
+	return r.buildAndPushImage(ctx, model, previousImageRef(previous.ImageURI.ValueString(), previous.SHA256Digest.ValueString()), metrics)
+}
+
+// relabelBlocker returns why the update from previous to model cannot rewrite the labels of the pushed image,
+// or an empty string when it changes only labels of an image that can be rewritten.
+func relabelBlocker(ctx context.Context, req resource.UpdateRequest, previous, model *ComposeResourceModel) string {
+	if model.OnLabelChange.ValueString() != labelChangeMutate {
+		return "on_label_change is not mutate"
+	}
+	if model.Rebuild.ValueString() == rebuildAlways {
+		return "rebuild is always"
+	}
+	if previous.SHA256Digest.ValueString() == "" {
+		return "the digest of the pushed image is not recorded"
+	}
+	if skipPush(previous) || skipPush(model) {
+		return "the image is not pushed"
+	}
+	if model.OCILabels.ValueBool() || model.Export != nil || model.LoadInto != nil {
+		return "oci_labels, export and load_into require a build"
+	}
+	if !model.PushByDigest.ValueBool() && previous.PushedImageURI.ValueString() != "" && previous.PushedImageURI.ValueString() != previous.ImageURI.ValueString() {
+		return "the image is pushed with another tag than image_uri"
+	}
+	if body, diags := req.Private.GetKey(ctx, driftPrivateKey); len(body) > 0 || diags.HasError() {
+		return "the tag was overwritten out of band"
+	}
+	ok, err := unbuiltChange(req.State.Raw, req.Plan.Raw, rebuiltImageAttributes(), "labels")
+	if err != nil {
+		return err.Error()
+	}
+	if !ok {
+		return "attributes other than labels changed"
+	}
+	return ""
+}
+
+// relabelImage rewrites the labels of the image pushed by the previous apply to the labels of model,
+// pushes the new manifest as image_uri, and runs the steps following a push.
+// Image indexes get new manifests for every platform; attestations are kept and refer to the new manifests.
+func (r *ComposeResource) relabelImage(ctx context.Context, previous, model *ComposeResourceModel, metrics *buildMetrics) error {
+	host, repository, _, err := registryclient.ParseImageReference(model.ImageURI.ValueString())
+	if err != nil {
+		return err
+	}
+	c, err := registryclient.New(r.providerConfig, host)
+	if err != nil {
+		return err
+	}
+	tflog.Info(ctx, "Rewriting the labels of the pushed image", map[string]interface{}{
+		"image_uri": model.ImageURI.ValueString(),
+		"digest":    previous.SHA256Digest.ValueString(),
+	})
+	pushStart := time.Now()
+	manifest, err := c.GetManifest(ctx, repository, previous.SHA256Digest.ValueString())
+	if err != nil {
+		return fmt.Errorf("failed to get pushed manifest %s: %w", previous.SHA256Digest.ValueString(), err)
+	}
+	relabel := &labelRewriter{
+		client:     c,
+		repository: repository,
+		previous:   r.extractLabels(previous),
+		labels:     r.extractLabels(model),
+	}
+	var body []byte
+	if manifest.IsIndex() {
+		body, err = relabel.index(ctx, manifest.Body)
+	} else {
+		body, err = relabel.manifest(ctx, manifest.Body)
+	}
+	if err != nil {
+		return err
+	}
+
+	ref := ocidigest.FromBytes(body).String()
+	if !model.PushByDigest.ValueBool() {
+		_, _, ref, err = registryclient.ParseImageReference(model.ImageURI.ValueString())
+		if err != nil {
+			return err
+		}
+	}
+	digest, err := c.PutManifest(ctx, repository, ref, manifest.MediaType, body)
+	if err != nil {
+		return fmt.Errorf("failed to push relabeled manifest: %w", err)
+	}
+	metrics.PushDuration = time.Since(pushStart)
+	metrics.Push.PushedBytes = relabel.uploaded
+
+	model.SHA256Digest = tfplugintypes.StringValue(digest)
+	model.PushedImageURI = tfplugintypes.StringNull()
+	model.ImageRef = tfplugintypes.StringNull()
+	model.AttestationDigests = previous.AttestationDigests
+	model.ScanFindings = tfplugintypes.MapNull(tfplugintypes.Int64Type)
+	return r.completePush(ctx, model, metrics)
+}
+
+// labelRewriter rewrites the labels of image manifests of a repository.
+type labelRewriter struct {
+	client     *registryclient.Client
+	repository string
+	// previous are the labels of the previous apply: those missing from labels are removed.
+	previous map[string]string
+	labels   map[string]string
+	// uploaded is the total size of the uploaded image configurations.
+	uploaded int64
+}
+
+// index rewrites the labels of every image manifest of the image index body, pushing them by digest,
+// and returns the new image index. Attestation manifests are kept, referring to the new image manifests.
+func (l *labelRewriter) index(ctx context.Context, body []byte) ([]byte, error) {
+	var index map[string]any
+	if err := decodeJSON(body, &index); err != nil {
+		return nil, fmt.Errorf("failed to decode image index: %w", err)
+	}
+	manifests, _ := index["manifests"].([]any)
+	replaced := map[string]string{}
+	for _, entry := range manifests {
+		descriptor, ok := entry.(map[string]any)
+		if !ok || isAttestationDescriptor(descriptor) {
+			continue
+		}
+		digest, _ := descriptor["digest"].(string)
+		manifest, err := l.client.GetManifest(ctx, l.repository, digest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get manifest %s: %w", digest, err)
+		}
+		relabeled, err := l.manifest(ctx, manifest.Body)
+		if err != nil {
+			return nil, err
+		}
+		newDigest, err := l.client.PutManifest(ctx, l.repository, ocidigest.FromBytes(relabeled).String(), manifest.MediaType, relabeled)
+		if err != nil {
+			return nil, fmt.Errorf("failed to push relabeled manifest: %w", err)
+		}
+		descriptor["digest"] = newDigest
+		descriptor["size"] = len(relabeled)
+		replaced[digest] = newDigest
+	}
+	for _, entry := range manifests {
+		descriptor, ok := entry.(map[string]any)
+		if !ok || !isAttestationDescriptor(descriptor) {
+			continue
+		}
+		annotations, _ := descriptor["annotations"].(map[string]any)
+		subject, _ := annotations["vnd.docker.reference.digest"].(string)
+		if newDigest, ok := replaced[subject]; ok {
+			annotations["vnd.docker.reference.digest"] = newDigest
+		}
+	}
+	return encodeJSON(index)
+}
+
+// manifest uploads the image configuration of the image manifest body with the new labels,
+// and returns the image manifest referring to it.
+func (l *labelRewriter) manifest(ctx context.Context, body []byte) ([]byte, error) {
+	var manifest map[string]any
+	if err := decodeJSON(body, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest: %w", err)
+	}
+	descriptor, ok := manifest["config"].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("the manifest has no image configuration")
+	}
+	digest, _ := descriptor["digest"].(string)
+	mediaType, _ := descriptor["mediaType"].(string)
+	blob, err := l.client.GetBlob(ctx, l.repository, digest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get image configuration %s: %w", digest, err)
+	}
+	var config map[string]any
+	if err := decodeJSON(blob, &config); err != nil {
+		return nil, fmt.Errorf("failed to decode image configuration: %w", err)
+	}
+	containerConfig, ok := config["config"].(map[string]any)
+	if !ok {
+		containerConfig = map[string]any{}
+		config["config"] = containerConfig
+	}
+	labels, ok := containerConfig["Labels"].(map[string]any)
+	if !ok {
+		labels = map[string]any{}
+	}
+	for k := range l.previous {
+		if _, ok := l.labels[k]; !ok {
+			delete(labels, k)
+		}
+	}
+	for k, v := range l.labels {
+		labels[k] = v
+	}
+	containerConfig["Labels"] = labels
+
+	relabeled, err := encodeJSON(config)
+	if err != nil {
+		return nil, err
+	}
+	uploaded, err := l.client.UploadBlob(ctx, l.repository, mediaType, relabeled)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload image configuration: %w", err)
+	}
+	l.uploaded += uploaded.Size
+	descriptor["digest"] = uploaded.Digest
+	descriptor["size"] = uploaded.Size
+	return encodeJSON(manifest)
+}
+
+// isAttestationDescriptor reports whether the descriptor of an image index refers to an attestation manifest.
+func isAttestationDescriptor(descriptor map[string]any) bool {
+	annotations, _ := descriptor["annotations"].(map[string]any)
+	return annotations["vnd.docker.reference.type"] == "attestation-manifest"
+}
+
+// decodeJSON decodes body into v, keeping numbers as written.
+func decodeJSON(body []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// encodeJSON encodes v without escaping HTML characters, which image configurations keep as is.
+func encodeJSON(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,56 @@
+package compose
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	// pushRetryBackoff is the wait before the first push retry, doubled for each following retry.
+	pushRetryBackoff = 5 * time.Second
+	// maxPushRetryBackoff caps the wait between push retries.
+	maxPushRetryBackoff = 60 * time.Second
+)
+
+// pushRetries returns the number of times a failed push is retried (the provider push_retries).
+func (r *ComposeResource) pushRetries() int {
+	if r.providerConfig == nil {
+		return 0
+	}
+	return r.providerConfig.PushRetries
+}
+
+// retryPush runs push, running it again up to the provider push_retries times when it fails with anything
+// but a cancellation or a tag conflict, which fails the same way on every attempt. push must authenticate to the registry on each call, so that credentials and registry tokens
+// that expired during a long push are renewed. Layer uploads are idempotent: the layers that reached the registry
+// before a failure are found there and not uploaded again.
+func (r *ComposeResource) retryPush(ctx context.Context, imageURI string, push func() error) error {
+	retries := r.pushRetries()
+	for attempt := 0; ; attempt++ {
+		err := push()
+		if err == nil || attempt >= retries || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || isTagConflict(err) {
+			return err
+		}
+
+		wait := pushRetryBackoff << attempt
+		if wait > maxPushRetryBackoff {
+			wait = maxPushRetryBackoff
+		}
+		tflog.Warn(ctx, "Push failed, retrying", map[string]interface{}{
+			"image_uri": imageURI,
+			"attempt":   attempt + 1,
+			"wait":      wait.String(),
+			"error":     err.Error(),
+		})
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package compose
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	tfplugintypes "github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	// smokeTestDefaultTimeout is the time limit of the test block when test.timeout is omitted.
+	smokeTestDefaultTimeout = 5 * time.Minute
+	// smokeTestOutputLines is the number of last output lines of a failed test reported in the error.
+	smokeTestOutputLines = 20
+)
+
+// SmokeTestModel represents the test block of the image resources
+type SmokeTestModel struct {
+	Command tfplugintypes.List   `tfsdk:"command"`
+	Timeout tfplugintypes.String `tfsdk:"timeout"`
+	Env     tfplugintypes.Map    `tfsdk:"env"`
+}
+
+// smokeTestBlock returns the schema of the test block shared by the image resources.
+func smokeTestBlock() schema.SingleNestedBlock {
+	return schema.SingleNestedBlock{
+		MarkdownDescription: "Run the built image in a container of the local Docker daemon before pushing it (as `docker run --rm`), " +
+			"and abort the push when the container exits with a non-zero status, so that obviously broken images never reach the registry. " +
+			"Not available with `builder = \"kaniko\"`, `direct_push` or an existing image.",
+		Attributes: map[string]schema.Attribute{
+			"command": schema.ListAttribute{
+				MarkdownDescription: "Command run in the container, replacing the `CMD` of the image (e.g. `[\"app\", \"--version\"]`). " +
+					"Omit to run the `CMD` of the image.",
+				Optional:    true,
+				ElementType: tfplugintypes.StringType,
+			},
+			"timeout": schema.StringAttribute{
+				MarkdownDescription: "Maximum duration of the test as a Go duration (e.g. `30s`). The container is killed and the test fails when it expires. " +
+					"Defaults to `5m`.",
+				Optional: true,
+			},
+			"env": schema.MapAttribute{
+				MarkdownDescription: "Environment variables set in the container.",
+				Optional:            true,
+				ElementType:         tfplugintypes.StringType,
+			},
+		},
+	}
+}
+
+// validateSmokeTest reports a test block with an invalid timeout or with a builder that does not keep the image in the Docker daemon.
+func validateSmokeTest(test *SmokeTestModel, builder tfplugintypes.String, directPush tfplugintypes.Bool) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if test == nil {
+		return diags
+	}
+	if _, err := parseTimeout(test.Timeout); err != nil {
+		diags.AddAttributeError(path.Root("test").AtName("timeout"), "Invalid timeout", err.Error())
+	}
+	if builder.ValueString() == builderKaniko || directPush.ValueBool() {
+		diags.AddAttributeError(
+			path.Root("test"),
+			"Local image required",
+			"test runs the image in the local Docker daemon and cannot be used with builder = \"kaniko\" or direct_push, which push the image while building.",
+		)
+	}
+	return diags
+}
+
+// runSmokeTest runs the local image tagged as image_uri with the test block of model, and fails unless the container exits with 0.
+// The container is removed afterwards.
+func (r *ComposeResource) runSmokeTest(ctx context.Context, dockerClient *client.Client, model *ComposeResourceModel) error {
+	test := model.Test
+	if test == nil {
+		return nil
+	}
+	timeout, err := parseTimeout(test.Timeout)
+	if err != nil {
+		return err
+	}
+	if timeout == 0 {
+		timeout = smokeTestDefaultTimeout
+	}
+	config := &container.Config{Image: model.ImageURI.ValueString()}
+	if !test.Command.IsNull() && !test.Command.IsUnknown() {
+		if diags := test.Command.ElementsAs(ctx, &config.Cmd, false); diags.HasError() {
+			return errors.New("failed to read test.command")
+		}
+	}
+	if !test.Env.IsNull() && !test.Env.IsUnknown() {
+		env := map[string]string{}
+		if diags := test.Env.ElementsAs(ctx, &env, false); diags.HasError() {
+			return errors.New("failed to read test.env")
+		}
+		for name, value := range env {
+			config.Env = append(config.Env, name+"="+value)
+		}
+		sort.Strings(config.Env)
+	}
+
+	created, err := dockerClient.ContainerCreate(ctx, config, nil, nil, nil, "")
+	if err != nil {
+		return fmt.Errorf("failed to create test container: %w", err)
+	}
+	defer func() {
+		// Removed even when ctx is canceled, so that test containers do not pile up
+		if err := dockerClient.ContainerRemove(context.WithoutCancel(ctx), created.ID, container.RemoveOptions{Force: true}); err != nil {
+			tflog.Warn(ctx, "Failed to remove test container", map[string]interface{}{
+				"container": created.ID,
+				"error":     err.Error(),
+			})
+		}
+	}()
+
+	testCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	// Wait before starting, so that the exit of a short-lived container is not missed
+	waitC, waitErrC := dockerClient.ContainerWait(testCtx, created.ID, container.WaitConditionNextExit)
+	if err := dockerClient.ContainerStart(testCtx, created.ID, container.StartOptions{}); err != nil {
+		return fmt.Errorf("failed to start test container: %w", err)
+	}
+	tflog.Info(ctx, "Running test container", map[string]interface{}{
+		"image_uri": model.ImageURI.ValueString(),
+		"command":   config.Cmd,
+	})
+	var exitCode int64
+	select {
+	case result := <-waitC:
+		if result.Error != nil {
+			return fmt.Errorf("failed to wait for test container: %s", result.Error.Message)
+		}
+		exitCode = result.StatusCode
+	case err := <-waitErrC:
+		if ctx.Err() == nil && errors.Is(testCtx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("test did not finish within %s%s", timeout, smokeTestOutput(ctx, dockerClient, created.ID))
+		}
+		return fmt.Errorf("failed to wait for test container: %w", err)
+	}
+
+	if exitCode != 0 {
+		return fmt.Errorf("test exited with status %d: the image is not pushed%s", exitCode, smokeTestOutput(ctx, dockerClient, created.ID))
+	}
+	tflog.Info(ctx, "Test passed", map[string]interface{}{
+		"image_uri": model.ImageURI.ValueString(),
+	})
+	return nil
+}
+
+// smokeTestOutput returns the last lines of the output of the test container id, formatted to be appended to an error.
+func smokeTestOutput(ctx context.Context, dockerClient *client.Client, id string) string {
+	logs, err := dockerClient.ContainerLogs(context.WithoutCancel(ctx), id, container.LogsOptions{ShowStdout: true, ShowStderr: true})
+	if err != nil {
+		return ""
+	}
+	defer logs.Close()
+	var output bytes.Buffer
+	if _, err := stdcopy.StdCopy(&output, &output, logs); err != nil && output.Len() == 0 {
+		return ""
+	}
+	lines := strings.Split(strings.TrimRight(output.String(), "\n"), "\n")
+	if len(lines) > smokeTestOutputLines {
+		lines = lines[len(lines)-smokeTestOutputLines:]
+	}
+	if len(lines) == 1 && lines[0] == "" {
+		return ""
+	}
+	return "\n\nLast test output lines:\n" + strings.Join(lines, "\n")
+}
@@ -0,0 +1,113 @@
+package compose
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	composetypes "github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/ocilayout"
+)
+
+// squashAttribute returns the schema of the squash attribute shared by the image resources.
+func squashAttribute() schema.BoolAttribute {
+	return schema.BoolAttribute{
+		MarkdownDescription: "Whether to merge the layers of the built image into a single layer before pushing, " +
+			"for registries limiting the number of layers and for runtimes pulling many small layers slowly. " +
+			"Files deleted or overwritten by later steps are dropped. " +
+			"The image no longer shares layers with its base image, so every push and pull transfers the whole image.",
+		Optional: true,
+	}
+}
+
+// checkSquash verifies that the built image can be squashed: only single-platform images without attestations,
+// which the provider saves from the daemon and loads back, can be.
+func checkSquash(buildSpec *composetypes.BuildConfig, model *ComposeResourceModel) error {
+	if !model.Squash.ValueBool() {
+		return nil
+	}
+	if len(buildSpec.Platforms) > 1 {
+		return errors.New("squash cannot be used with several build.platforms")
+	}
+	if model.Option != nil && (model.Option.Provenance.ValueString() != "" || model.Option.SBOM.ValueString() != "") {
+		return errors.New("squash cannot be used with option.provenance and option.sbom, as the attestations describe the layers before squashing")
+	}
+	return nil
+}
+
+// squashImage replaces the local image tagged as image_uri with the same image whose layers are merged into one,
+// and removes the original image unless other tags still refer to it.
+func (r *ComposeResource) squashImage(ctx context.Context, dockerClient *client.Client, model *ComposeResourceModel) error {
+	imageURI := model.ImageURI.ValueString()
+	inspect, err := dockerClient.ImageInspect(ctx, imageURI)
+	if err != nil {
+		return fmt.Errorf("failed to inspect image: %w", err)
+	}
+
+	saved, err := dockerClient.ImageSave(ctx, []string{imageURI})
+	if err != nil {
+		return fmt.Errorf("failed to save image: %w", err)
+	}
+	defer saved.Close()
+	squashed, err := os.CreateTemp("", "containerregistry-squashed-*.tar")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(squashed.Name())
+	defer squashed.Close()
+	layers, err := ocilayout.SquashArchive(saved, squashed, imageURI)
+	if err != nil {
+		return fmt.Errorf("failed to squash image: %w", err)
+	}
+	if _, err := squashed.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to squash image: %w", err)
+	}
+
+	loaded, err := dockerClient.ImageLoad(ctx, squashed, client.ImageLoadWithQuiet(true))
+	if err != nil {
+		return fmt.Errorf("failed to load squashed image: %w", err)
+	}
+	defer loaded.Body.Close()
+	if err := parseLoadResponse(loaded.Body); err != nil {
+		return fmt.Errorf("failed to load squashed image: %w", err)
+	}
+	tflog.Info(ctx, "Squashed image layers", map[string]interface{}{
+		"image_uri": imageURI,
+		"layers":    layers,
+	})
+
+	// The original image is left dangling by the tag moving to the squashed image
+	if _, err := dockerClient.ImageRemove(ctx, inspect.ID, image.RemoveOptions{}); err != nil {
+		tflog.Debug(ctx, "Keeping image before squashing", map[string]interface{}{
+			"id":    inspect.ID,
+			"error": err.Error(),
+		})
+	}
+	return nil
+}
+
+// parseLoadResponse reads the JSON stream of the image load API and returns the error it reports, if any.
+func parseLoadResponse(r io.Reader) error {
+	dec := json.NewDecoder(r)
+	for {
+		var jm jsonmessage.JSONMessage
+		if err := dec.Decode(&jm); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("failed to parse load response: %w", err)
+		}
+		if err := jsonMessageError(&jm); err != nil {
+			return err
+		}
+	}
+}
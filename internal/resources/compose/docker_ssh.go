@@ -0,0 +1,41 @@
+package compose
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+
+	composetypes "github.com/compose-spec/compose-go/v2/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// envBakeEntitlementsFS controls the filesystem entitlement checks of buildx bake.
+const envBakeEntitlementsFS = "BUILDX_BAKE_ENTITLEMENTS_FS"
+
+// sshEntitlementsOnce disables the bake filesystem entitlement checks at most once per process.
+var sshEntitlementsOnce sync.Once
+
+// prepareSSH checks that the SSH agent socket is available for build.ssh entries using it, and lets bake use
+// the SSH agent and key files. Compose only grants bake read access to the build contexts, so without this
+// bake stops at an entitlement prompt that cannot be answered from Terraform.
+// An explicit BUILDX_BAKE_ENTITLEMENTS_FS in the environment is left untouched.
+func prepareSSH(ctx context.Context, buildSpec *composetypes.BuildConfig) error {
+	if len(buildSpec.SSH) == 0 {
+		return nil
+	}
+	for _, key := range buildSpec.SSH {
+		if key.Path == "" && os.Getenv("SSH_AUTH_SOCK") == "" {
+			return errors.New("build.ssh uses the SSH agent (" + key.ID + "), but SSH_AUTH_SOCK is not set; start an SSH agent or specify key files as id=path")
+		}
+	}
+
+	sshEntitlementsOnce.Do(func() {
+		if _, ok := os.LookupEnv(envBakeEntitlementsFS); ok {
+			return
+		}
+		tflog.Debug(ctx, "Disabling bake filesystem entitlement checks for build.ssh")
+		_ = os.Setenv(envBakeEntitlementsFS, "0")
+	})
+	return nil
+}
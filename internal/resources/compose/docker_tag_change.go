@@ -0,0 +1,159 @@
+package compose
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/distribution/reference"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	tfplugintypes "github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// sameRepository reports whether previous and current are references by tag (latest when omitted) to the same repository,
+// so that changing from one to the other only changes the tag.
+func sameRepository(previous, current string) bool {
+	previousRef, err := reference.ParseNormalizedNamed(previous)
+	if err != nil {
+		return false
+	}
+	currentRef, err := reference.ParseNormalizedNamed(current)
+	if err != nil {
+		return false
+	}
+	for _, ref := range []reference.Named{previousRef, currentRef} {
+		if _, ok := ref.(reference.Canonical); ok {
+			return false
+		}
+	}
+	return previousRef.Name() == currentRef.Name()
+}
+
+// imageURIRequiresReplace returns the plan modifier of image_uri, replacing the resource when the repository changes.
+// Another tag of the same repository is pushed by an in-place update.
+func imageURIRequiresReplace() planmodifier.String {
+	return stringplanmodifier.RequiresReplaceIf(
+		func(ctx context.Context, req planmodifier.StringRequest, resp *stringplanmodifier.RequiresReplaceIfFuncResponse) {
+			resp.RequiresReplace = req.PlanValue.IsUnknown() || !sameRepository(req.StateValue.ValueString(), req.PlanValue.ValueString())
+		},
+		"Replaces the resource when the repository of the image changes; a change of the tag only updates it in place.",
+		"Replaces the resource when the repository of the image changes; a change of the tag only updates it in place.",
+	)
+}
+
+// planTagChangeID plans id to follow image_uri when only the tag changes, for resources whose id is their image_uri
+// as set by Create. Other ids, e.g. the UUID of an imported resource, are kept.
+func planTagChangeID(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.State.Raw.IsNull() {
+		return
+	}
+	var id, stateImageURI, planImageURI tfplugintypes.String
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("id"), &id)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("image_uri"), &stateImageURI)...)
+	resp.Diagnostics.Append(resp.Plan.GetAttribute(ctx, path.Root("image_uri"), &planImageURI)...)
+	if resp.Diagnostics.HasError() || id.ValueString() != stateImageURI.ValueString() || planImageURI.IsUnknown() ||
+		planImageURI.ValueString() == stateImageURI.ValueString() {
+		return
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("id"), planImageURI)...)
+}
+
+// servicesRequiresReplace returns the plan modifier of services of containerregistry_compose_project, replacing the resource
+// when services are added or removed, or when the repository of an image changes.
+func servicesRequiresReplace() planmodifier.Map {
+	return mapplanmodifier.RequiresReplaceIf(
+		func(ctx context.Context, req planmodifier.MapRequest, resp *mapplanmodifier.RequiresReplaceIfFuncResponse) {
+			resp.RequiresReplace = true
+			if req.PlanValue.IsUnknown() {
+				return
+			}
+			previous := map[string]string{}
+			current := map[string]string{}
+			if req.StateValue.ElementsAs(ctx, &previous, false).HasError() || req.PlanValue.ElementsAs(ctx, &current, false).HasError() {
+				return
+			}
+			if len(previous) != len(current) {
+				return
+			}
+			for name, imageURI := range current {
+				previousImageURI, ok := previous[name]
+				if !ok || (imageURI != previousImageURI && !sameRepository(previousImageURI, imageURI)) {
+					return
+				}
+			}
+			resp.RequiresReplace = false
+		},
+		"Replaces the resource when services are added or removed or the repository of an image changes; "+
+			"a change of tags only updates it in place.",
+		"Replaces the resource when services are added or removed or the repository of an image changes; "+
+			"a change of tags only updates it in place.",
+	)
+}
+
+// deletePreviousTagAttribute returns the schema of the delete_previous_tag attribute shared by the image resources.
+func deletePreviousTagAttribute() schema.BoolAttribute {
+	return schema.BoolAttribute{
+		MarkdownDescription: "Whether to delete the previous tag from the registry when the tag of `image_uri` changes " +
+			"(e.g. `:v1.2.3` to `:v1.2.4`), which updates the resource in place by building and pushing the new tag. " +
+			"Defaults to keeping the previous tag. Registries that do not support deleting tags keep it with a warning.",
+		Optional: true,
+	}
+}
+
+// previousTag returns the tag pushed by the previous apply to delete with delete_previous_tag, when pushed is another tag
+// of the same repository, or an empty string.
+func previousTag(deletePreviousTag tfplugintypes.Bool, previous, pushed string) string {
+	if !deletePreviousTag.ValueBool() || !sameRepository(previous, pushed) {
+		return ""
+	}
+	previousRef, _ := reference.ParseNormalizedNamed(previous)
+	pushedRef, _ := reference.ParseNormalizedNamed(pushed)
+	if reference.TagNameOnly(previousRef).String() == reference.TagNameOnly(pushedRef).String() {
+		return ""
+	}
+	return previous
+}
+
+// deletePreviousTag removes the previous tag from the registry, and verifies that the pushed image digest is still there,
+// as a few registries delete the manifest along with the tag.
+// The tag is kept with a warning when the registry does not support deleting tags.
+func (r *ComposeResource) deletePreviousTag(ctx context.Context, previous, digest string) error {
+	host, repository, tag, err := registryclient.ParseImageReference(previous)
+	if err != nil {
+		return err
+	}
+	c, err := registryclient.New(r.providerConfig, host)
+	if err != nil {
+		return err
+	}
+	if err := c.DeleteManifest(ctx, repository, tag); err != nil {
+		if registryclient.IsNotFound(err) {
+			return nil
+		}
+		if !registryclient.IsUnsupported(err) {
+			return fmt.Errorf("failed to delete previous tag %s: %w", previous, err)
+		}
+		tflog.Warn(ctx, "The registry does not support deleting tags: leaving the previous tag", map[string]interface{}{
+			"tag":   previous,
+			"error": err.Error(),
+		})
+		return nil
+	}
+	if _, err := c.GetManifest(ctx, repository, digest); err != nil {
+		if registryclient.IsNotFound(err) {
+			return fmt.Errorf("the registry deleted the pushed image %s along with the previous tag %s: apply again to push it, with delete_previous_tag = false", digest, previous)
+		}
+		return fmt.Errorf("failed to confirm the image after deleting the previous tag: %w", err)
+	}
+	tflog.Info(ctx, "Deleted previous tag", map[string]interface{}{
+		"tag": previous,
+	})
+	return nil
+}
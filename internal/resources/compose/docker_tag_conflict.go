@@ -0,0 +1,205 @@
+package compose
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/distribution/reference"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	tfplugintypes "github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// Values of on_tag_conflict: what to do when the tag of image_uri exists in a repository with immutable tags.
+const (
+	tagConflictError  = "error"
+	tagConflictSuffix = "suffix"
+	tagConflictSkip   = "skip"
+)
+
+// tagConflictSuffixLength is the number of hex digits of the image digest appended to the tag with on_tag_conflict = "suffix".
+const tagConflictSuffixLength = 12
+
+// onTagConflictAttribute returns the schema of the on_tag_conflict attribute shared by the image resources.
+func onTagConflictAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		MarkdownDescription: "What to do when the tag of `image_uri` already exists in a repository with immutable tags " +
+			"(ECR tag immutability, Artifact Registry immutable tags): " +
+			"`error` fails with a diagnostic, `suffix` pushes the image with the tag suffixed by its digest (`<tag>-<12 hex digits>`) " +
+			"and records it in `pushed_image_uri`, and `skip` keeps the image in the registry and records its digest in `sha256_digest`. " +
+			"Defaults to `error`. `suffix` and `skip` cannot be used with `builder = \"kaniko\"` or `direct_push`, which push the image while building.",
+		Optional: true,
+	}
+}
+
+// pushedImageURIAttribute returns the schema of the pushed_image_uri attribute shared by the image resources.
+func pushedImageURIAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		MarkdownDescription: "Reference of the pushed image in the registry: `image_uri`, the suffixed tag with `on_tag_conflict = \"suffix\"`, " +
+			"or `<repository>@<sha256_digest>` with `push_by_digest`. Null when the image is not pushed.",
+		Computed: true,
+	}
+}
+
+// imageRefAttribute returns the schema of the image_ref attribute shared by the image resources.
+func imageRefAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		MarkdownDescription: "Reference of the pushed image pinned by its digest (`<repository>@<sha256_digest>`), " +
+			"for deployments that must not follow later pushes to the tag. Unknown in the plan when the image is rebuilt. " +
+			"Null when the image is not pushed.",
+		Computed: true,
+	}
+}
+
+// imageRefValue returns the image_ref of model: the pushed image pinned by sha256_digest, or null without a digest.
+func imageRefValue(model *ComposeResourceModel) tfplugintypes.String {
+	digest := model.SHA256Digest.ValueString()
+	if digest == "" {
+		return tfplugintypes.StringNull()
+	}
+	return tfplugintypes.StringValue(previousImageRef(registryImageURI(model), digest))
+}
+
+// validateOnTagConflict reports an on_tag_conflict attribute with an unknown value or a fallback that cannot be used.
+func validateOnTagConflict(onTagConflict, builder tfplugintypes.String, directPush tfplugintypes.Bool) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if onTagConflict.IsNull() || onTagConflict.IsUnknown() {
+		return diags
+	}
+	switch onTagConflict.ValueString() {
+	case tagConflictError:
+	case tagConflictSuffix, tagConflictSkip:
+		if builder.ValueString() == builderKaniko || directPush.ValueBool() {
+			diags.AddAttributeError(
+				path.Root("on_tag_conflict"),
+				"Tag conflict fallback not supported",
+				fmt.Sprintf("on_tag_conflict = %q cannot be used with builder = \"kaniko\" or direct_push, which push the image while building.", onTagConflict.ValueString()),
+			)
+		}
+	default:
+		diags.AddAttributeError(
+			path.Root("on_tag_conflict"),
+			"Invalid on_tag_conflict",
+			fmt.Sprintf("on_tag_conflict must be %q, %q or %q.", tagConflictError, tagConflictSuffix, tagConflictSkip),
+		)
+	}
+	return diags
+}
+
+// isTagConflict reports whether err tells that the pushed tag already exists in a repository with immutable tags.
+// ECR reports TAG_INVALID ("The image tag '...' already exists in the '...' repository and cannot be overwritten
+// because the repository is immutable"), and Artifact Registry rejects the push of an immutable tag.
+func isTagConflict(err error) bool {
+	if err == nil {
+		return false
+	}
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "imagetagalreadyexists") ||
+		(strings.Contains(message, "immutable") && strings.Contains(message, "tag"))
+}
+
+// newTagConflictError returns the error reported for a push rejected with a tag conflict when on_tag_conflict does not fall back.
+func newTagConflictError(imageURI string, err error) error {
+	return fmt.Errorf("the tag of %s already exists in the registry and the repository does not allow overwriting tags: "+
+		"change the tag of image_uri, or set on_tag_conflict = %q or %q: %w", imageURI, tagConflictSuffix, tagConflictSkip, err)
+}
+
+// suffixedImageURI returns imageURI with its tag suffixed by the first hex digits of digest.
+func suffixedImageURI(imageURI, digest string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(imageURI)
+	if err != nil {
+		return "", fmt.Errorf("invalid image URI format: %w", err)
+	}
+	tag := "latest"
+	if tagged, ok := named.(reference.Tagged); ok {
+		tag = tagged.Tag()
+	}
+	_, encoded, _ := strings.Cut(digest, ":")
+	if len(encoded) < tagConflictSuffixLength {
+		return "", fmt.Errorf("invalid digest %q", digest)
+	}
+	suffix := "-" + encoded[:tagConflictSuffixLength]
+	// Tags are limited to 128 characters
+	if max := 128 - len(suffix); len(tag) > max {
+		tag = tag[:max]
+	}
+	suffixed, err := reference.WithTag(reference.TrimNamed(named), tag+suffix)
+	if err != nil {
+		return "", err
+	}
+	return suffixed.String(), nil
+}
+
+// resolveTagConflict handles the push of image_uri rejected with pushErr according to on_tag_conflict:
+// it returns the error with a diagnostic, records the digest of the image already tagged as image_uri,
+// or pushes the image with the tag suffixed by digest (the digest of the image to push) with pushAs,
+// which returns the pushed manifest digest.
+func (r *ComposeResource) resolveTagConflict(ctx context.Context, model *ComposeResourceModel, metrics *buildMetrics, pushErr error, digest string, pushAs func(imageURI string) (string, error)) error {
+	imageURI := model.ImageURI.ValueString()
+	switch model.OnTagConflict.ValueString() {
+	case tagConflictSkip:
+		host, repository, ref, err := registryclient.ParseImageReference(imageURI)
+		if err != nil {
+			return err
+		}
+		c, err := registryclient.New(r.providerConfig, host)
+		if err != nil {
+			return err
+		}
+		existing, err := c.ResolveDigest(ctx, repository, ref)
+		if err != nil {
+			return fmt.Errorf("failed to get the digest of the existing image %s: %w", imageURI, err)
+		}
+		tflog.Warn(ctx, "The tag already exists in the registry: keeping the existing image instead of the built image", map[string]interface{}{
+			"image_uri": imageURI,
+			"digest":    existing,
+		})
+		metrics.Push.Skipped = true
+		model.SHA256Digest = tfplugintypes.StringValue(existing)
+		return nil
+	case tagConflictSuffix:
+		suffixed, err := suffixedImageURI(imageURI, digest)
+		if err != nil {
+			return err
+		}
+		tflog.Warn(ctx, "The tag already exists in the registry: pushing with a suffixed tag", map[string]interface{}{
+			"image_uri":        imageURI,
+			"pushed_image_uri": suffixed,
+		})
+		pushed, err := pushAs(suffixed)
+		if err != nil {
+			if isTagConflict(err) {
+				return newTagConflictError(suffixed, err)
+			}
+			return fmt.Errorf("failed to push image as %s: %w", suffixed, err)
+		}
+		model.SHA256Digest = tfplugintypes.StringValue(pushed)
+		model.PushedImageURI = tfplugintypes.StringValue(suffixed)
+		return nil
+	default:
+		return newTagConflictError(imageURI, pushErr)
+	}
+}
+
+// registryImageURI returns the reference of the image of model in the registry: pushed_image_uri when recorded,
+// the repository of image_uri with sha256_digest for images pushed by digest, and image_uri otherwise.
+func registryImageURI(model *ComposeResourceModel) string {
+	if pushed := model.PushedImageURI.ValueString(); pushed != "" {
+		return pushed
+	}
+	if model.PushByDigest.ValueBool() {
+		return previousImageRef(model.ImageURI.ValueString(), model.SHA256Digest.ValueString())
+	}
+	return model.ImageURI.ValueString()
+}
+
+// imagePushed reports whether pushedImageURI records an image pushed by the apply: it is set right after the push,
+// so that an image is recorded even when a step following the push fails.
+func imagePushed(pushedImageURI tfplugintypes.String) bool {
+	return !pushedImageURI.IsNull() && !pushedImageURI.IsUnknown()
+}
@@ -0,0 +1,96 @@
+package compose
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/distribution/reference"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// additionalTagsAttribute returns the schema of the additional_tags attribute shared by the image resources.
+func additionalTagsAttribute() schema.ListAttribute {
+	return schema.ListAttribute{
+		MarkdownDescription: "Additional tags (e.g. `latest`, a git commit SHA or semver aliases) for the pushed image in the repository of `image_uri`. " +
+			"The manifest pushed as `image_uri` is tagged in the registry without pushing the layers again, " +
+			"and each tag is checked to resolve to `sha256_digest`.",
+		Optional:    true,
+		ElementType: types.StringType,
+	}
+}
+
+// validateAdditionalTags reports additional_tags elements that are not valid tags.
+func validateAdditionalTags(ctx context.Context, additionalTags types.List) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if additionalTags.IsNull() || additionalTags.IsUnknown() {
+		return diags
+	}
+	var tags []types.String
+	diags.Append(additionalTags.ElementsAs(ctx, &tags, false)...)
+	if diags.HasError() {
+		return diags
+	}
+	repository, _ := reference.ParseNormalizedNamed("image")
+	for i, tag := range tags {
+		if tag.IsUnknown() {
+			continue
+		}
+		if _, err := reference.WithTag(repository, tag.ValueString()); err != nil {
+			diags.AddAttributeError(path.Root("additional_tags").AtListIndex(i), "Invalid tag", fmt.Sprintf("%q is not a valid tag: %s", tag.ValueString(), err))
+		}
+	}
+	return diags
+}
+
+// pushAdditionalTags tags the manifest pushed as image_uri (sha256_digest) with additional_tags in the same repository,
+// and verifies that every tag resolves to it.
+func (r *ComposeResource) pushAdditionalTags(ctx context.Context, model *ComposeResourceModel) error {
+	if model.AdditionalTags.IsNull() || len(model.AdditionalTags.Elements()) == 0 {
+		return nil
+	}
+	var tags []string
+	if diags := model.AdditionalTags.ElementsAs(ctx, &tags, false); diags.HasError() {
+		return fmt.Errorf("failed to read additional_tags")
+	}
+	host, repository, _, err := registryclient.ParseImageReference(model.ImageURI.ValueString())
+	if err != nil {
+		return err
+	}
+	c, err := registryclient.New(r.providerConfig, host)
+	if err != nil {
+		return err
+	}
+	digest := model.SHA256Digest.ValueString()
+	manifest, err := c.GetManifest(ctx, repository, digest)
+	if err != nil {
+		return fmt.Errorf("failed to get pushed manifest %s: %w", digest, err)
+	}
+
+	for _, tag := range tags {
+		tflog.Info(ctx, "Tagging pushed image", map[string]interface{}{
+			"image_uri": model.ImageURI.ValueString(),
+			"tag":       tag,
+			"digest":    digest,
+		})
+		if _, err := c.PutManifest(ctx, repository, tag, manifest.MediaType, manifest.Body); err != nil {
+			if isTagConflict(err) {
+				return fmt.Errorf("tag %s already exists in the registry and the repository does not allow overwriting tags: %w", tag, err)
+			}
+			return fmt.Errorf("failed to push tag %s: %w", tag, err)
+		}
+		tagged, err := c.ResolveDigest(ctx, repository, tag)
+		if err != nil {
+			return fmt.Errorf("failed to confirm tag %s: %w", tag, err)
+		}
+		if tagged != digest {
+			return fmt.Errorf("tag %s resolves to %s instead of the pushed image %s", tag, tagged, digest)
+		}
+	}
+	return nil
+}
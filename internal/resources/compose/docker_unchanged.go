@@ -0,0 +1,49 @@
+package compose
+
+import (
+	"context"
+
+	"github.com/docker/docker/client"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// unchangedRemoteDigest returns the digest of the manifest tagged as image_uri in the registry when it holds
+// the local image tagged as image_uri, so that pushing the image again can be skipped, and an empty string otherwise.
+// The local image ID is the digest of the manifest (or image index) with the containerd image store,
+// and the digest of the image config with the classic image store.
+func (r *ComposeResource) unchangedRemoteDigest(ctx context.Context, dockerClient *client.Client, imageURI string) (string, error) {
+	inspect, err := dockerClient.ImageInspect(ctx, imageURI)
+	if err != nil {
+		return "", err
+	}
+	host, repository, ref, err := registryclient.ParseImageReference(imageURI)
+	if err != nil {
+		return "", err
+	}
+	c, err := registryclient.New(r.providerConfig, host)
+	if err != nil {
+		return "", err
+	}
+	manifest, err := c.GetManifest(ctx, repository, ref)
+	if err != nil {
+		if registryclient.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	if manifest.Digest == inspect.ID {
+		return manifest.Digest, nil
+	}
+	if manifest.IsIndex() {
+		return "", nil
+	}
+	content, err := manifest.Content()
+	if err != nil {
+		return "", err
+	}
+	if content.Config.Digest == inspect.ID {
+		return manifest.Digest, nil
+	}
+	return "", nil
+}
@@ -0,0 +1,86 @@
+package compose
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/distribution/reference"
+	"github.com/docker/docker/client"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// verifyLocalImageDigest fails unless digest, the manifest digest read from the registry after pushing the local image
+// tagged as imageURI as pushedURI, is that local image. Another pipeline pushing the same tag at the same time
+// would otherwise make the state record an image that was not built here.
+// The image matches when the daemon recorded digest as a repository digest of the image after the push,
+// or when digest is the local image ID (the manifest or image index with the containerd image store)
+// or a manifest of the local image config (the classic image store).
+func (r *ComposeResource) verifyLocalImageDigest(ctx context.Context, dockerClient *client.Client, imageURI, pushedURI, digest string) error {
+	inspect, err := dockerClient.ImageInspect(ctx, imageURI)
+	if err != nil {
+		return fmt.Errorf("failed to inspect image: %w", err)
+	}
+	if inspect.ID == digest {
+		return nil
+	}
+	pushed, err := reference.ParseNormalizedNamed(pushedURI)
+	if err != nil {
+		return fmt.Errorf("invalid image URI format: %w", err)
+	}
+	for _, repoDigest := range inspect.RepoDigests {
+		named, err := reference.ParseNormalizedNamed(repoDigest)
+		if err != nil {
+			continue
+		}
+		if canonical, ok := named.(reference.Canonical); ok && named.Name() == pushed.Name() && canonical.Digest().String() == digest {
+			return nil
+		}
+	}
+
+	host, repository, _, err := registryclient.ParseImageReference(pushedURI)
+	if err != nil {
+		return err
+	}
+	c, err := registryclient.New(r.providerConfig, host)
+	if err != nil {
+		return err
+	}
+	manifest, err := c.GetManifest(ctx, repository, digest)
+	if err != nil {
+		return fmt.Errorf("failed to get pushed manifest %s: %w", digest, err)
+	}
+	if !manifest.IsIndex() {
+		content, err := manifest.Content()
+		if err != nil {
+			return err
+		}
+		if content.Config.Digest == inspect.ID {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s refers to %s in the registry, which is not the pushed image %s: "+
+		"another push to the same tag may have replaced it", pushedURI, digest, inspect.ID)
+}
+
+// verifyRegistryDigest fails unless imageURI resolves to digest, the manifest digest reported by the push, in the registry,
+// so that a tag replaced by another pipeline right after the push is not recorded as the pushed image.
+func (r *ComposeResource) verifyRegistryDigest(ctx context.Context, imageURI, digest string) error {
+	host, repository, ref, err := registryclient.ParseImageReference(imageURI)
+	if err != nil {
+		return err
+	}
+	c, err := registryclient.New(r.providerConfig, host)
+	if err != nil {
+		return err
+	}
+	remote, err := c.ResolveDigest(ctx, repository, ref)
+	if err != nil {
+		return fmt.Errorf("failed to confirm pushed image %s: %w", imageURI, err)
+	}
+	if remote != digest {
+		return fmt.Errorf("%s refers to %s in the registry, which is not the pushed image %s: "+
+			"another push to the same tag may have replaced it", imageURI, remote, digest)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package compose
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	tfplugintypes "github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/moby/patternmatcher"
+)
+
+// watchPathsAttribute returns the schema of the watch_paths attribute shared by the image resources.
+func watchPathsAttribute() schema.ListAttribute {
+	return schema.ListAttribute{
+		MarkdownDescription: "Files, directories or glob patterns (e.g. `src/*.go`, in the syntax of Go's `filepath.Match`) " +
+			"whose contents are hashed into `context_hash`, so that changing them rebuilds the image, " +
+			"instead of passing `filemd5()` results in `triggers`. Directories are hashed recursively. " +
+			"Relative paths are resolved from the working directory of Terraform. Patterns matching no files are allowed.",
+		Optional:    true,
+		ElementType: tfplugintypes.StringType,
+	}
+}
+
+// validateWatchPaths reports empty and malformed patterns in watch_paths.
+func validateWatchPaths(ctx context.Context, watchPaths tfplugintypes.List) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if watchPaths.IsNull() || watchPaths.IsUnknown() {
+		return diags
+	}
+	var patterns []tfplugintypes.String
+	diags.Append(watchPaths.ElementsAs(ctx, &patterns, false)...)
+	if diags.HasError() {
+		return diags
+	}
+	for i, pattern := range patterns {
+		if pattern.IsUnknown() {
+			continue
+		}
+		if pattern.ValueString() == "" {
+			diags.AddAttributeError(path.Root("watch_paths").AtListIndex(i), "Invalid watch_paths", "watch_paths must not contain empty paths.")
+			continue
+		}
+		if _, err := filepath.Match(pattern.ValueString(), ""); err != nil {
+			diags.AddAttributeError(path.Root("watch_paths").AtListIndex(i), "Invalid watch_paths", fmt.Sprintf("%q is not a valid pattern: %s", pattern.ValueString(), err))
+		}
+	}
+	return diags
+}
+
+// withWatchPaths returns the value of context_hash combining hash, the hash of the build context, with the hash of watchPaths.
+func withWatchPaths(ctx context.Context, hash string, watchPaths tfplugintypes.List) (tfplugintypes.String, error) {
+	if watchPaths.IsUnknown() {
+		return tfplugintypes.StringUnknown(), nil
+	}
+	if len(watchPaths.Elements()) == 0 {
+		return contextHashValue(hash), nil
+	}
+	var patterns []string
+	if diags := watchPaths.ElementsAs(ctx, &patterns, false); diags.HasError() {
+		return tfplugintypes.StringUnknown(), errors.New("failed to read watch_paths")
+	}
+	watched, err := watchPathsHash(patterns)
+	if err != nil {
+		return tfplugintypes.StringUnknown(), err
+	}
+	return contextHashValue(combineContextHashes(map[string]string{
+		"context":     hash,
+		"watch_paths": watched,
+	})), nil
+}
+
+// watchPathsHash returns the hash of the files matched by patterns, recursing into the matched directories.
+// Each pattern is hashed with the paths and contents of its matches, so that a file moving between patterns changes the hash.
+func watchPathsHash(patterns []string) (string, error) {
+	h := sha256.New()
+	noExcludes, err := patternmatcher.New(nil)
+	if err != nil {
+		return "", err
+	}
+	for _, pattern := range patterns {
+		// Glob returns the matches in lexical order
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return "", fmt.Errorf("invalid watch_paths pattern %q: %w", pattern, err)
+		}
+		_, _ = fmt.Fprintf(h, "%s\x00%d\x00", pattern, len(matches))
+		for _, match := range matches {
+			fi, err := os.Stat(match)
+			if err != nil {
+				return "", fmt.Errorf("failed to read %s of watch_paths: %w", match, err)
+			}
+			_, _ = fmt.Fprintf(h, "%s\x00", filepath.ToSlash(match))
+			if fi.IsDir() {
+				err = hashTree(h, match, noExcludes)
+			} else {
+				err = hashFile(h, match)
+			}
+			if err != nil {
+				return "", fmt.Errorf("failed to read %s of watch_paths: %w", match, err)
+			}
+		}
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,673 @@
+package compose
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	composetypes "github.com/compose-spec/compose-go/v2/types"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/logging"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/providerconfig"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ resource.Resource = &DockerfileImageResource{}
+var _ resource.ResourceWithConfigure = &DockerfileImageResource{}
+var _ resource.ResourceWithValidateConfig = &DockerfileImageResource{}
+var _ resource.ResourceWithModifyPlan = &DockerfileImageResource{}
+
+// NewDockerfileImageResource returns a new resource implementing the containerregistry_dockerfile_image resource type.
+func NewDockerfileImageResource() resource.Resource {
+	return &DockerfileImageResource{}
+}
+
+// DockerfileImageResource builds an image from an inline Dockerfile.
+// It generates a compose build specification and delegates to ComposeResource.
+type DockerfileImageResource struct {
+	compose ComposeResource
+}
+
+// Metadata returns the resource type name.
+func (r *DockerfileImageResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_dockerfile_image"
+}
+
+// Schema defines the schema for the resource.
+func (r *DockerfileImageResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Container registry image built from an inline Dockerfile",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Identifier of the image",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"image_uri": schema.StringAttribute{
+				MarkdownDescription: "URI of the image to build and push",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					imageURIRequiresReplace(),
+				},
+			},
+			"dockerfile_contents": schema.StringAttribute{
+				MarkdownDescription: "Contents of the Dockerfile",
+				Required:            true,
+			},
+			"context": schema.StringAttribute{
+				MarkdownDescription: "Build context: a directory, a tarball (.tar, .tar.gz) or an HTTP(S) / Git URL. Omit to build with an empty context.",
+				Optional:            true,
+			},
+			"target": schema.StringAttribute{
+				MarkdownDescription: "Build stage to build in a multi-stage Dockerfile (equivalent to --target). Omit to build the last stage.",
+				Optional:            true,
+			},
+			"builder":             builderAttribute(),
+			"platform":            platformAttribute(),
+			"frontend_image":      frontendImageAttribute(),
+			"cache_from_previous": cacheFromPreviousAttribute(),
+			"squash":              squashAttribute(),
+			"additional_tags":     additionalTagsAttribute(),
+			"direct_push":         directPushAttribute(),
+			"push_by_digest":      pushByDigestAttribute(),
+			"on_tag_conflict":     onTagConflictAttribute(),
+			"on_drift":            onDriftAttribute(),
+			"on_label_change":     onLabelChangeAttribute(),
+			"offline":             offlineAttribute(),
+			"dry_run":             dryRunAttribute(),
+			"build_args": schema.MapAttribute{
+				MarkdownDescription: "Build arguments (equivalent to --build-arg)",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"additional_contexts": schema.MapAttribute{
+				MarkdownDescription: "Named build contexts (equivalent to --build-context), referenced by `FROM name` and `COPY --from=name`. " +
+					"Values are local directories, `docker-image://` images, or with BuildKit, any context accepted by `docker buildx build --build-context`.",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"ssh": schema.ListAttribute{
+				MarkdownDescription: "SSH agent sockets or keys to expose to the build for `RUN --mount=type=ssh` (equivalent to --ssh). " +
+					"`default` forwards the agent in `SSH_AUTH_SOCK`; `id=path` forwards the agent socket or private key files at path. Requires BuildKit.",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"cache_from": schema.ListAttribute{
+				MarkdownDescription: "External cache sources (equivalent to --cache-from), e.g. `type=gha` or `type=registry,ref=...`. Requires BuildKit.",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"cache_to": schema.ListAttribute{
+				MarkdownDescription: "Cache export destinations (equivalent to --cache-to), e.g. `type=gha,mode=max`. Requires BuildKit.",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"entitlements": schema.ListAttribute{
+				MarkdownDescription: "Privileges to grant to the build (equivalent to --allow): `network.host` for `RUN --network=host`, " +
+					"`security.insecure` for `RUN --security=insecure`. The builder must also permit them. Requires BuildKit.",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"option": optionAttribute(),
+			"labels": schema.MapAttribute{
+				MarkdownDescription: "Labels for the image",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"oci_labels":           ociLabelsAttribute(),
+			"annotations":          annotationsAttribute(),
+			"media_type":           mediaTypeAttribute(),
+			"wait_for_scan":        waitForScanAttribute(),
+			"fail_on_severity":     failOnSeverityAttribute(),
+			"wait_for_replication": waitForReplicationAttribute(),
+			"wait_for_available":   waitForAvailableAttribute(),
+			"triggers": schema.MapAttribute{
+				MarkdownDescription: "Map of arbitrary strings that, when changed, will force the image to be rebuilt",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"watch_paths": watchPathsAttribute(),
+			"rebuild":     rebuildAttribute(),
+			"delete_image": schema.BoolAttribute{
+				MarkdownDescription: "Whether to delete the image when the resource is deleted",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"delete_previous_tag": deletePreviousTagAttribute(),
+			"delete_image_scope":  deleteImageScopeAttribute(),
+			"prune_local": schema.BoolAttribute{
+				MarkdownDescription: "Whether to remove the locally built image from the Docker daemon after a successful push",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"prune_local_parents": schema.BoolAttribute{
+				MarkdownDescription: "Whether `prune_local` also removes the untagged parent images of the image, like `docker rmi` does by default. " +
+					"Default is false, keeping the parents that later builds may reuse as cache",
+				Optional: true,
+				Computed: true,
+				Default:  booldefault.StaticBool(false),
+			},
+			"attestation_digests": schema.MapAttribute{
+				MarkdownDescription: "Digests of the attestation manifests (provenance, SBOM) pushed with the image, keyed by platform. " +
+					"Set when `option.provenance` or `option.sbom` requests attestations.",
+				Computed:    true,
+				ElementType: types.StringType,
+			},
+			"pushed_image_uri":       pushedImageURIAttribute(),
+			"image_ref":              imageRefAttribute(),
+			"scan_findings":          scanFindingsAttribute(),
+			"build_duration_seconds": buildDurationSecondsAttribute(),
+			"push_duration_seconds":  pushDurationSecondsAttribute(),
+			"pushed_bytes":           pushedBytesAttribute(),
+			"context_hash":           contextHashAttribute(),
+			"sha256_digest": schema.StringAttribute{
+				MarkdownDescription: "SHA256 digest of the image in the registry",
+				Computed:            true,
+			},
+			"previous_digest": previousDigestAttribute(),
+		},
+
+		Blocks: map[string]schema.Block{
+			"timeouts":          timeoutsBlock(),
+			"create_repository": createRepositoryBlock(),
+			"test":              smokeTestBlock(),
+			"on_push":           onPushBlock(),
+		},
+	}
+}
+
+// Configure adds the provider configured client to the resource.
+func (r *DockerfileImageResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	if cfg, ok := req.ProviderData.(*providerconfig.Config); ok {
+		r.compose.providerConfig = cfg
+	}
+}
+
+// ValidateConfig checks the platform attribute and the option and timeouts blocks.
+func (r *DockerfileImageResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config DockerfileImageResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(validateTimeouts(config.Timeouts)...)
+	resp.Diagnostics.Append(validateBuilder(config.Builder, config.PruneLocal.ValueBool())...)
+	resp.Diagnostics.Append(validateCreateRepository(config.CreateRepository, config.ImageURI)...)
+	resp.Diagnostics.Append(validateSmokeTest(config.Test, config.Builder, config.DirectPush)...)
+	resp.Diagnostics.Append(validateOnPush(config.OnPush)...)
+	resp.Diagnostics.Append(validateScan(config.WaitForScan, config.FailOnSeverity, config.ImageURI)...)
+	resp.Diagnostics.Append(validateWaitForReplication(ctx, config.WaitForReplication, config.ImageURI)...)
+	resp.Diagnostics.Append(validateWaitForAvailable(config.WaitForAvailable)...)
+	resp.Diagnostics.Append(validateOption(config.Option)...)
+	resp.Diagnostics.Append(validatePlatform(config.Platform)...)
+	resp.Diagnostics.Append(validateFrontendImage(config.FrontendImage)...)
+	resp.Diagnostics.Append(validateAdditionalTags(ctx, config.AdditionalTags)...)
+	resp.Diagnostics.Append(validateDirectPush(config.DirectPush, config.Builder, config.Squash)...)
+	resp.Diagnostics.Append(validatePushByDigest(config.PushByDigest, config.Builder, config.DirectPush)...)
+	resp.Diagnostics.Append(validateOnTagConflict(config.OnTagConflict, config.Builder, config.DirectPush)...)
+	resp.Diagnostics.Append(validateOnDrift(config.OnDrift)...)
+	resp.Diagnostics.Append(validateOnLabelChange(config.OnLabelChange)...)
+	resp.Diagnostics.Append(validateDeleteImageScope(config.DeleteImageScope, config.ImageURI)...)
+	resp.Diagnostics.Append(validateOffline(config.Offline, config.Builder, config.CacheFromPrevious, config.Option)...)
+	resp.Diagnostics.Append(validateDryRun(config.DryRun, config.Builder, config.DirectPush)...)
+	resp.Diagnostics.Append(validateWatchPaths(ctx, config.WatchPaths)...)
+	resp.Diagnostics.Append(validateRebuild(config.Rebuild)...)
+	resp.Diagnostics.Append(validateAnnotations(config.Annotations, config.Builder, config.Squash)...)
+	resp.Diagnostics.Append(validateMediaType(config.MediaType, config.Builder, config.Squash, config.Annotations, config.Option)...)
+}
+
+// buildContextHash returns the context_hash of model: the hash of context, dockerfile_contents and watch_paths,
+// null without context or with a remote context and without watch_paths, and unknown while they are unknown.
+func (r *DockerfileImageResource) buildContextHash(ctx context.Context, model *DockerfileImageResourceModel) (types.String, error) {
+	if model.Context.IsNull() || model.Context.ValueString() == "" {
+		return withWatchPaths(ctx, "", model.WatchPaths)
+	}
+	if model.Context.IsUnknown() || model.DockerfileContents.IsUnknown() {
+		return types.StringUnknown(), nil
+	}
+	hash, err := contextHash(&composetypes.BuildConfig{
+		Context:          model.Context.ValueString(),
+		DockerfileInline: model.DockerfileContents.ValueString(),
+	})
+	if err != nil {
+		return types.StringUnknown(), err
+	}
+	return withWatchPaths(ctx, hash, model.WatchPaths)
+}
+
+// ModifyPlan recomputes context_hash from the build context, planning a rebuild when the files changed.
+func (r *DockerfileImageResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to build when the resource is destroyed
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	var model DockerfileImageResourceModel
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("context"), &model.Context)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("dockerfile_contents"), &model.DockerfileContents)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("watch_paths"), &model.WatchPaths)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("builder"), &model.Builder)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("on_label_change"), &model.OnLabelChange)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	hash, err := r.buildContextHash(ctx, &model)
+	if !checkContextHashError(ctx, resp, err) {
+		return
+	}
+	planContextHash(ctx, req, resp, hash, rebuiltImageAttributes())
+	planDriftRebuild(ctx, req, resp, rebuiltImageAttributes())
+	planAlwaysRebuild(ctx, req, resp, rebuiltImageAttributes())
+	planTagChangeID(ctx, req, resp)
+	r.compose.planDockerDaemonCheck(ctx, req, resp, &ComposeResourceModel{Builder: model.Builder, OnLabelChange: model.OnLabelChange}, "sha256_digest")
+}
+
+// escapeInterpolation escapes "$" so that compose variable interpolation leaves s unchanged.
+func escapeInterpolation(s string) string {
+	return strings.ReplaceAll(s, "$", "$$")
+}
+
+// toComposeModel converts the model to the equivalent containerregistry_compose model.
+// The returned cleanup function removes the empty build context created when context is omitted.
+func (r *DockerfileImageResource) toComposeModel(ctx context.Context, model *DockerfileImageResourceModel) (*ComposeResourceModel, func(), error) {
+	cleanup := func() {}
+	contextDir := model.Context.ValueString()
+	if contextDir == "" {
+		dir, err := os.MkdirTemp("", "containerregistry-context-")
+		if err != nil {
+			return nil, cleanup, fmt.Errorf("failed to create empty build context: %w", err)
+		}
+		contextDir = dir
+		cleanup = func() {
+			if err := os.RemoveAll(dir); err != nil {
+				tflog.Warn(ctx, "Failed to remove empty build context: ignored", map[string]any{
+					"path":  dir,
+					"error": err.Error(),
+				})
+			}
+		}
+	}
+
+	build := map[string]any{
+		"context":           escapeInterpolation(contextDir),
+		"dockerfile_inline": escapeInterpolation(model.DockerfileContents.ValueString()),
+	}
+	if target := model.Target.ValueString(); target != "" {
+		build["target"] = escapeInterpolation(target)
+	}
+	if !model.BuildArgs.IsNull() && !model.BuildArgs.IsUnknown() {
+		buildArgs := map[string]string{}
+		if diags := model.BuildArgs.ElementsAs(ctx, &buildArgs, false); diags.HasError() {
+			cleanup()
+			return nil, func() {}, errors.New("invalid build_args")
+		}
+		args := make(map[string]string, len(buildArgs))
+		for k, v := range buildArgs {
+			args[k] = escapeInterpolation(v)
+		}
+		build["args"] = args
+	}
+	if !model.AdditionalContexts.IsNull() && !model.AdditionalContexts.IsUnknown() {
+		contexts := map[string]string{}
+		if diags := model.AdditionalContexts.ElementsAs(ctx, &contexts, false); diags.HasError() {
+			cleanup()
+			return nil, func() {}, errors.New("invalid additional_contexts")
+		}
+		for k, v := range contexts {
+			contexts[k] = escapeInterpolation(v)
+		}
+		build["additional_contexts"] = contexts
+	}
+	for key, list := range map[string]types.List{"ssh": model.SSH, "cache_from": model.CacheFrom, "cache_to": model.CacheTo, "entitlements": model.Entitlements} {
+		if list.IsNull() || list.IsUnknown() {
+			continue
+		}
+		var values []string
+		if diags := list.ElementsAs(ctx, &values, false); diags.HasError() {
+			cleanup()
+			return nil, func() {}, fmt.Errorf("invalid %s", key)
+		}
+		for i := range values {
+			values[i] = escapeInterpolation(values[i])
+		}
+		build[key] = values
+	}
+	buildJSON, err := json.Marshal(build)
+	if err != nil {
+		cleanup()
+		return nil, func() {}, fmt.Errorf("failed to encode build specification: %w", err)
+	}
+
+	return &ComposeResourceModel{
+		ID:                 model.ID,
+		ImageURI:           model.ImageURI,
+		Build:              types.StringValue(string(buildJSON)),
+		Builder:            model.Builder,
+		Platform:           model.Platform,
+		FrontendImage:      model.FrontendImage,
+		CacheFromPrevious:  model.CacheFromPrevious,
+		Squash:             model.Squash,
+		AdditionalTags:     model.AdditionalTags,
+		DirectPush:         model.DirectPush,
+		PushByDigest:       model.PushByDigest,
+		OnTagConflict:      model.OnTagConflict,
+		OnLabelChange:      model.OnLabelChange,
+		Offline:            model.Offline,
+		DryRun:             model.DryRun,
+		Timeouts:           model.Timeouts,
+		CreateRepository:   model.CreateRepository,
+		Test:               model.Test,
+		OnPush:             model.OnPush,
+		Option:             model.Option,
+		Labels:             model.Labels,
+		OCILabels:          model.OCILabels,
+		Annotations:        model.Annotations,
+		MediaType:          model.MediaType,
+		WaitForScan:        model.WaitForScan,
+		FailOnSeverity:     model.FailOnSeverity,
+		WaitForReplication: model.WaitForReplication,
+		WaitForAvailable:   model.WaitForAvailable,
+		Triggers:           model.Triggers,
+		Rebuild:            model.Rebuild,
+		DeleteImage:        model.DeleteImage,
+		PruneLocal:         model.PruneLocal,
+		PruneLocalParents:  model.PruneLocalParents,
+		SHA256Digest:       model.SHA256Digest,
+	}, cleanup, nil
+}
+
+// buildAndPush builds and pushes the image with push, setting sha256_digest in model.
+// push is buildAndPushImage of the compose resource, or relabelOrBuild on updates.
+func (r *DockerfileImageResource) buildAndPush(ctx context.Context, model *DockerfileImageResourceModel, push func(*ComposeResourceModel, *buildMetrics) ([]string, error)) error {
+	composeModel, cleanup, err := r.toComposeModel(ctx, model)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	var metrics buildMetrics
+	lastBuildLines, err := push(composeModel, &metrics)
+	if err != nil && !errors.Is(err, errDigestPending) && !imagePushed(composeModel.PushedImageURI) {
+		if len(lastBuildLines) > 0 {
+			return fmt.Errorf("%w\n\nLast build log lines:\n%s", err, strings.Join(lastBuildLines, "\n"))
+		}
+		return err
+	}
+	model.SHA256Digest = composeModel.SHA256Digest
+	model.PushedImageURI = composeModel.PushedImageURI
+	model.ImageRef = composeModel.ImageRef
+	model.AttestationDigests = composeModel.AttestationDigests
+	model.ScanFindings = composeModel.ScanFindings
+	model.BuildDuration, model.PushDuration, model.PushedBytes = metricsValues(&metrics)
+	if err != nil {
+		// The image is pushed without its digest, or a step following the push failed
+		return err
+	}
+
+	if err := r.compose.writeApplySummary(ctx, composeModel, &metrics); err != nil {
+		tflog.Warn(ctx, "Error writing apply summary", map[string]any{
+			"error": err.Error(),
+		})
+	}
+	return nil
+}
+
+// Create builds and pushes the image and sets the initial Terraform state.
+func (r *DockerfileImageResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	// Initialize the HTTP logging subsystem and header masking for this request.
+	ctx = logging.WithHTTPLoggingSubsystem(ctx)
+
+	var plan DockerfileImageResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Info(ctx, "Creating container registry image from Dockerfile", map[string]interface{}{
+		"image_uri": plan.ImageURI.ValueString(),
+	})
+
+	plan.ID = plan.ImageURI
+	ctx, cancel, err := withTimeout(ctx, plan.Timeouts.createTimeout())
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid timeout", err.Error())
+		return
+	}
+	defer cancel()
+
+	plan.ContextHash = resolveContextHash(ctx, plan.ContextHash, func() (types.String, error) {
+		return r.buildContextHash(ctx, &plan)
+	})
+	err = r.buildAndPush(ctx, &plan, func(composeModel *ComposeResourceModel, metrics *buildMetrics) ([]string, error) {
+		return r.compose.buildAndPushImage(ctx, composeModel, "", metrics)
+	})
+	if errors.Is(err, errDigestPending) {
+		resp.Diagnostics.Append(recordPendingDigest(ctx, resp.Private, plan.PushedImageURI.ValueString(), err)...)
+	} else if err != nil {
+		err = timeoutError(ctx, plan.Timeouts.createTimeout(), err)
+		resp.Diagnostics.AddError(
+			"Error building and pushing image",
+			fmt.Sprintf("Could not build and push image %s: %s", plan.ImageURI.ValueString(), err),
+		)
+		// A step following the push failed: the pushed image is recorded, and Terraform taints the resource
+		if !imagePushed(plan.PushedImageURI) {
+			return
+		}
+	}
+	plan.PreviousDigest = types.StringNull()
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+// Read refreshes the Terraform state with the labels and digest in the registry.
+func (r *DockerfileImageResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// Initialize the HTTP logging subsystem and header masking for this request.
+	ctx = logging.WithHTTPLoggingSubsystem(ctx)
+
+	var state DockerfileImageResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// An image only built with dry_run has nothing to refresh from the registry
+	if state.DryRun.ValueBool() {
+		return
+	}
+
+	imageURI := registryImageURI(&ComposeResourceModel{
+		ImageURI:       state.ImageURI,
+		SHA256Digest:   state.SHA256Digest,
+		PushByDigest:   state.PushByDigest,
+		PushedImageURI: state.PushedImageURI,
+	})
+	imageInfo, err := r.compose.getImageInfoFromRegistry(ctx, &ComposeResourceModel{
+		ImageURI: types.StringValue(imageURI),
+	})
+	if err != nil {
+		// Only a definitive answer from the registry means the image is gone: keep the state on network,
+		// authentication and server errors so that a flaky registry does not plan a rebuild
+		if !registryclient.IsNotFound(err) {
+			resp.Diagnostics.AddError(
+				"Error reading image",
+				fmt.Sprintf("Could not read image %s from the registry: %s", state.ImageURI.ValueString(), err),
+			)
+			return
+		}
+		tflog.Warn(ctx, "Image not found in registry", map[string]interface{}{
+			"image_uri": state.ImageURI.ValueString(),
+			"error":     err.Error(),
+		})
+
+		// The image doesn't exist in the registry, mark it as deleted from state
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	// Another image may have been pushed to the tag out of band
+	var drifts []imageDrift
+	if drift, ok := detectDrift(imageURI, state.SHA256Digest, imageInfo.ManifestDigest); ok {
+		drifts = append(drifts, drift)
+	}
+	adopt, diags := applyOnDrift(ctx, state.OnDrift, drifts, resp.Private)
+	resp.Diagnostics.Append(diags...)
+	if !adopt || resp.Diagnostics.HasError() {
+		return
+	}
+
+	if state.OCILabels.ValueBool() {
+		configured := map[string]string{}
+		if !state.Labels.IsNull() && !state.Labels.IsUnknown() {
+			resp.Diagnostics.Append(state.Labels.ElementsAs(ctx, &configured, false)...)
+		}
+		imageInfo.Labels = removeInjectedLabels(imageInfo.Labels, configured)
+	}
+	if len(imageInfo.Labels) > 0 {
+		labelsMap, diags := registryLabelsValue(imageInfo.Labels)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		state.Labels = labelsMap
+	}
+	if imageInfo.ManifestDigest != "" {
+		state.SHA256Digest = types.StringValue(imageInfo.ManifestDigest)
+		state.ImageRef = types.StringValue(previousImageRef(imageURI, imageInfo.ManifestDigest))
+		// The digest of an image pushed without it is now recorded
+		resp.Diagnostics.Append(resolvePendingDigest(ctx, resp.Private, imageInfo.ManifestDigest)...)
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+// Update rebuilds and pushes the image and sets the updated Terraform state on success.
+func (r *DockerfileImageResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	// Initialize the HTTP logging subsystem and header masking for this request.
+	ctx = logging.WithHTTPLoggingSubsystem(ctx)
+
+	var plan, state DockerfileImageResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Info(ctx, "Updating container registry image from Dockerfile", map[string]interface{}{
+		"image_uri": plan.ImageURI.ValueString(),
+	})
+
+	// Only attributes not affecting the image changed: the plan keeps the digest and the other attributes written by the build
+	if !plan.SHA256Digest.IsUnknown() {
+		resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+		return
+	}
+
+	ctx, cancel, err := withTimeout(ctx, plan.Timeouts.updateTimeout())
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid timeout", err.Error())
+		return
+	}
+	defer cancel()
+
+	plan.ContextHash = resolveContextHash(ctx, plan.ContextHash, func() (types.String, error) {
+		return r.buildContextHash(ctx, &plan)
+	})
+	// The compose resource only reads image_uri, sha256_digest, push_by_digest, pushed_image_uri, dry_run, labels
+	// and attestation_digests from the previous state on updates.
+	previous := &ComposeResourceModel{
+		ImageURI:           state.ImageURI,
+		SHA256Digest:       state.SHA256Digest,
+		PushByDigest:       state.PushByDigest,
+		PushedImageURI:     state.PushedImageURI,
+		DryRun:             state.DryRun,
+		Labels:             state.Labels,
+		AttestationDigests: state.AttestationDigests,
+	}
+	err = r.buildAndPush(ctx, &plan, func(composeModel *ComposeResourceModel, metrics *buildMetrics) ([]string, error) {
+		return r.compose.relabelOrBuild(ctx, req, previous, composeModel, metrics)
+	})
+	pending := errors.Is(err, errDigestPending)
+	if pending {
+		resp.Diagnostics.Append(recordPendingDigest(ctx, resp.Private, plan.PushedImageURI.ValueString(), err)...)
+	} else if err != nil {
+		err = timeoutError(ctx, plan.Timeouts.updateTimeout(), err)
+		resp.Diagnostics.AddError(
+			"Error building and pushing image",
+			fmt.Sprintf("Could not build and push image %s: %s", plan.ImageURI.ValueString(), err),
+		)
+		// A step following the push failed: the pushed image is recorded rather than the previous one
+		if !imagePushed(plan.PushedImageURI) {
+			return
+		}
+	}
+	plan.PreviousDigest = previousDigestValue(state.SHA256Digest, state.PreviousDigest, plan.SHA256Digest, !state.DryRun.ValueBool() && !plan.DryRun.ValueBool())
+
+	// The image is pushed again over a drifted tag, and replaces an image pushed without its digest
+	resp.Diagnostics.Append(resp.Private.SetKey(ctx, driftPrivateKey, nil)...)
+	if !pending {
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, pendingDigestPrivateKey, nil)...)
+	}
+
+	// The tag of image_uri changed in place; the previous tag is kept until the digest of the new image is known
+	if !state.DryRun.ValueBool() && !pending && !resp.Diagnostics.HasError() {
+		if tag := previousTag(plan.DeletePreviousTag, registryImageURI(previous), plan.PushedImageURI.ValueString()); tag != "" {
+			if err := r.compose.deletePreviousTag(ctx, tag, plan.SHA256Digest.ValueString()); err != nil {
+				resp.Diagnostics.AddWarning("Error deleting previous tag", err.Error())
+			}
+		}
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+// Delete deletes the image from the registry when delete_image is set.
+func (r *DockerfileImageResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	// Initialize the HTTP logging subsystem and header masking for this request.
+	ctx = logging.WithHTTPLoggingSubsystem(ctx)
+
+	var state DockerfileImageResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The compose resource only reads image_uri, sha256_digest, push_by_digest, pushed_image_uri, dry_run, delete_image
+	// and delete_image_scope from the state on deletion.
+	composeState := &ComposeResourceModel{
+		ID:               state.ID,
+		ImageURI:         state.ImageURI,
+		SHA256Digest:     state.SHA256Digest,
+		PushByDigest:     state.PushByDigest,
+		PushedImageURI:   state.PushedImageURI,
+		DryRun:           state.DryRun,
+		DeleteImage:      state.DeleteImage,
+		DeleteImageScope: state.DeleteImageScope,
+	}
+	ctx, cancel, err := withTimeout(ctx, state.Timeouts.deleteTimeout())
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid timeout", err.Error())
+		return
+	}
+	defer cancel()
+
+	r.compose.deleteImage(ctx, composeState, resp)
+}
@@ -0,0 +1,291 @@
+package compose
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	composetypes "github.com/compose-spec/compose-go/v2/types"
+	tfplugintypes "github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// kanikoExecutorPath is where the kaniko executor is installed in the kaniko images (gcr.io/kaniko-project/executor).
+const kanikoExecutorPath = "/kaniko/executor"
+
+// dockerHubAuthKey is the key of Docker Hub credentials in a Docker config.json, as expected by kaniko.
+const dockerHubAuthKey = "https://index.docker.io/v1/"
+
+// findKanikoExecutor returns the path of the kaniko executor: "executor" in PATH, or the one installed in the kaniko images.
+func findKanikoExecutor() (string, error) {
+	if path, err := exec.LookPath("executor"); err == nil {
+		return path, nil
+	}
+	if _, err := os.Stat(kanikoExecutorPath); err == nil {
+		return kanikoExecutorPath, nil
+	}
+	return "", fmt.Errorf("builder = %q requires the kaniko executor: run terraform in a kaniko image or put executor in PATH", builderKaniko)
+}
+
+// checkKanikoBuild verifies that kaniko can honor the build, as it builds and pushes the image without a Docker daemon
+// and does not implement the BuildKit-specific build fields.
+func checkKanikoBuild(buildSpec *composetypes.BuildConfig, model *ComposeResourceModel) error {
+	if model.Option != nil && (model.Option.Provenance.ValueString() != "" || model.Option.SBOM.ValueString() != "") {
+		return fmt.Errorf("option.provenance and option.sbom cannot be used with builder = %q", builderKaniko)
+	}
+	if model.FrontendImage.ValueString() != "" {
+		return fmt.Errorf("frontend_image cannot be used with builder = %q", builderKaniko)
+	}
+	if model.CacheFromPrevious.ValueBool() {
+		return fmt.Errorf("cache_from_previous cannot be used with builder = %q: use build.cache_from / build.cache_to with a registry cache instead", builderKaniko)
+	}
+	if isRemoteContext(buildSpec.Context) {
+		return fmt.Errorf("build.context must be a local directory or tarball with builder = %q", builderKaniko)
+	}
+	if len(buildSpec.Platforms) > 1 {
+		return fmt.Errorf("build.platforms must list a single platform with builder = %q", builderKaniko)
+	}
+
+	var fields []string
+	if len(buildSpec.SSH) > 0 {
+		fields = append(fields, "ssh")
+	}
+	if len(buildSpec.Secrets) > 0 {
+		fields = append(fields, "secrets")
+	}
+	if len(buildSpec.AdditionalContexts) > 0 {
+		fields = append(fields, "additional_contexts")
+	}
+	if len(buildSpec.ExtraHosts) > 0 {
+		fields = append(fields, "extra_hosts")
+	}
+	if buildSpec.Network != "" {
+		fields = append(fields, "network")
+	}
+	if buildSpec.ShmSize != 0 {
+		fields = append(fields, "shm_size")
+	}
+	if len(buildSpec.Ulimits) > 0 {
+		fields = append(fields, "ulimits")
+	}
+	if buildSpec.Privileged {
+		fields = append(fields, "privileged")
+	}
+	if len(buildSpec.Entitlements) > 0 {
+		fields = append(fields, "entitlements")
+	}
+	if buildSpec.Isolation != "" && buildSpec.Isolation != "default" {
+		fields = append(fields, "isolation")
+	}
+	if len(fields) > 0 {
+		return fmt.Errorf("build.%s is not supported with builder = %q", fields[0], builderKaniko)
+	}
+	return nil
+}
+
+// kanikoCacheRepo returns the repository kaniko stores cached layers in, from the registry references in
+// cache_from / cache_to, and whether cached layers are pushed (cache_to is set).
+// kaniko uses a single cache repository for both, so differing references are rejected.
+func kanikoCacheRepo(buildSpec *composetypes.BuildConfig) (string, bool, error) {
+	var repo string
+	for _, entry := range append(append(composetypes.StringList{}, buildSpec.CacheFrom...), buildSpec.CacheTo...) {
+		attrs := cacheAttrs(entry)
+		if attrs["type"] != "registry" {
+			return "", false, fmt.Errorf("cache type=%s is not supported with builder = %q: only registry caches are", attrs["type"], builderKaniko)
+		}
+		if repo != "" && attrs["ref"] != repo {
+			return "", false, fmt.Errorf("builder = %q uses a single cache repository, but cache_from and cache_to refer to %s and %s", builderKaniko, repo, attrs["ref"])
+		}
+		repo = attrs["ref"]
+	}
+	return repo, len(buildSpec.CacheTo) > 0, nil
+}
+
+// kanikoArgs returns the command line arguments of the kaniko executor for buildSpec.
+func kanikoArgs(buildSpec *composetypes.BuildConfig, model *ComposeResourceModel, labels map[string]string, digestFile string) ([]string, error) {
+	dockerfile := buildSpec.Dockerfile
+	if dockerfile == "" {
+		dockerfile = "Dockerfile"
+	}
+	if !filepath.IsAbs(dockerfile) {
+		dockerfile = filepath.Join(buildSpec.Context, dockerfile)
+	}
+	args := []string{
+		"--context=dir://" + buildSpec.Context,
+		"--dockerfile=" + dockerfile,
+		"--destination=" + model.ImageURI.ValueString(),
+		"--digest-file=" + digestFile,
+	}
+	for _, tag := range buildSpec.Tags {
+		args = append(args, "--destination="+tag)
+	}
+	if buildSpec.Target != "" {
+		args = append(args, "--target="+buildSpec.Target)
+	}
+	if len(buildSpec.Platforms) == 1 {
+		args = append(args, "--custom-platform="+buildSpec.Platforms[0])
+	}
+	if model.Squash.ValueBool() {
+		// kaniko takes a single snapshot of the filesystem at the end of the build instead of one per step
+		args = append(args, "--single-snapshot")
+	}
+
+	keys := make([]string, 0, len(buildSpec.Args))
+	for key := range buildSpec.Args {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		// Without a value, kaniko takes the build argument from its environment as docker build does
+		if value := buildSpec.Args[key]; value != nil {
+			args = append(args, "--build-arg="+key+"="+*value)
+		} else {
+			args = append(args, "--build-arg="+key)
+		}
+	}
+
+	keys = keys[:0]
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(args, "--label="+key+"="+labels[key])
+	}
+
+	repo, push, err := kanikoCacheRepo(buildSpec)
+	if err != nil {
+		return nil, err
+	}
+	noCache := buildSpec.NoCache || (model.Option != nil && model.Option.NoCache.ValueBool())
+	if repo != "" && !noCache {
+		args = append(args, "--cache=true", "--cache-repo="+repo)
+		if !push {
+			args = append(args, "--no-push-cache")
+		}
+	}
+	return args, nil
+}
+
+// writeKanikoDockerConfig writes a Docker config.json holding the provider registry_auth credentials into dir,
+// so that kaniko authenticates to the registries for the base images, the pushed image and the cache.
+func (r *ComposeResource) writeKanikoDockerConfig(dir string) error {
+	auths := map[string]map[string]string{}
+	if r.providerConfig != nil {
+		for host, creds := range r.providerConfig.RegistryAuth {
+			if creds.Username == "" || creds.Password == "" {
+				return fmt.Errorf("registry_auth for %q has empty username or password", host)
+			}
+			key := host
+			if host == "docker.io" || host == "index.docker.io" {
+				key = dockerHubAuthKey
+			}
+			auths[key] = map[string]string{
+				"auth": base64.StdEncoding.EncodeToString([]byte(creds.Username + ":" + creds.Password)),
+			}
+		}
+	}
+	body, err := json.Marshal(map[string]any{"auths": auths})
+	if err != nil {
+		return fmt.Errorf("failed to encode Docker config: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), body, 0600); err != nil {
+		return fmt.Errorf("failed to write Docker config: %w", err)
+	}
+	return nil
+}
+
+// buildWithKaniko builds buildSpec with the kaniko executor, which pushes the image to image_uri itself
+// without a Docker daemon, and records the pushed digest into model.
+func (r *ComposeResource) buildWithKaniko(
+	ctx context.Context,
+	buildSpec *composetypes.BuildConfig,
+	model *ComposeResourceModel,
+	out io.Writer,
+	metrics *buildMetrics,
+) error {
+	if err := checkKanikoBuild(buildSpec, model); err != nil {
+		return err
+	}
+	executor, err := findKanikoExecutor()
+	if err != nil {
+		return err
+	}
+
+	if buildSpec.Context == "" {
+		buildSpec.Context = "."
+	}
+	cleanupContext, err := prepareContextArchive(ctx, buildSpec)
+	if err != nil {
+		return err
+	}
+	defer cleanupContext()
+	if buildSpec.Context, err = filepath.Abs(buildSpec.Context); err != nil {
+		return fmt.Errorf("failed to resolve build context: %w", err)
+	}
+	cleanupDockerfile, err := writeDockerfileInline(buildSpec)
+	if err != nil {
+		return err
+	}
+	defer cleanupDockerfile()
+
+	dir, err := os.MkdirTemp("", "containerregistry-kaniko-")
+	if err != nil {
+		return fmt.Errorf("failed to create directory for kaniko: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := r.writeKanikoDockerConfig(dir); err != nil {
+		return err
+	}
+	digestFile := filepath.Join(dir, "digest")
+
+	args, err := kanikoArgs(buildSpec, model, r.buildLabels(ctx, buildSpec, model), digestFile)
+	if err != nil {
+		return err
+	}
+	if retries := r.pushRetries(); retries > 0 {
+		args = append(args, fmt.Sprintf("--push-retry=%d", retries))
+	}
+
+	tflog.Info(ctx, "Building image with kaniko", map[string]interface{}{
+		"image_uri": model.ImageURI.ValueString(),
+		"executor":  executor,
+	})
+	cmd := exec.CommandContext(ctx, executor, args...)
+	cmd.Env = append(os.Environ(), "DOCKER_CONFIG="+dir)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	buildStart := time.Now()
+	err = cmd.Run()
+	// kaniko pushes while building, so the push is included in the build duration
+	metrics.BuildDuration = time.Since(buildStart)
+	if err != nil {
+		return fmt.Errorf("kaniko build failed: %w", err)
+	}
+
+	digest, err := os.ReadFile(digestFile)
+	if err != nil {
+		return fmt.Errorf("failed to read the image digest written by kaniko: %w", err)
+	}
+	if strings.TrimSpace(string(digest)) == "" {
+		return errors.New("manifest digest is empty")
+	}
+	if err := r.verifyRegistryDigest(ctx, model.ImageURI.ValueString(), strings.TrimSpace(string(digest))); err != nil {
+		return err
+	}
+	model.SHA256Digest = tfplugintypes.StringValue(strings.TrimSpace(string(digest)))
+	tflog.Info(ctx, "Successfully built and pushed image with kaniko", map[string]interface{}{
+		"image_uri": model.ImageURI.ValueString(),
+		"digest":    model.SHA256Digest.ValueString(),
+	})
+	return nil
+}
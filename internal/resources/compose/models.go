@@ -5,9 +5,24 @@ import (
 )
 
 type OptionModel struct {
-	Pull     types.Bool   `tfsdk:"pull"`
-	NoCache  types.Bool   `tfsdk:"no_cache"`
-	Progress types.String `tfsdk:"progress"`
+	Pull       types.Bool   `tfsdk:"pull"`
+	NoCache    types.Bool   `tfsdk:"no_cache"`
+	Progress   types.String `tfsdk:"progress"`
+	Provenance types.String `tfsdk:"provenance"`
+	SBOM       types.String `tfsdk:"sbom"`
+}
+
+// ExportModel represents export of the image to disk
+type ExportModel struct {
+	Type     types.String `tfsdk:"type"`
+	Path     types.String `tfsdk:"path"`
+	SkipPush types.Bool   `tfsdk:"skip_push"`
+}
+
+// LoadIntoModel represents loading of the image into a local Kubernetes cluster
+type LoadIntoModel struct {
+	Type    types.String `tfsdk:"type"`
+	Cluster types.String `tfsdk:"cluster"`
 }
 
 // BuildLogModel represents build log output configuration
@@ -15,16 +30,178 @@ type BuildLogModel struct {
 	Timestamp types.Bool   `tfsdk:"timestamp"`
 	Lines     types.Int64  `tfsdk:"lines"`
 	Log       types.String `tfsdk:"log"`
+	Steps     types.Bool   `tfsdk:"steps"`
+	File      types.String `tfsdk:"file"`
 }
 
 type ComposeResourceModel struct {
-	ID           types.String   `tfsdk:"id"`
-	ImageURI     types.String   `tfsdk:"image_uri"`
-	Build        types.String   `tfsdk:"build"`
-	Labels       types.Map      `tfsdk:"labels"`
-	Triggers     types.Map      `tfsdk:"triggers"`
-	DeleteImage  types.Bool     `tfsdk:"delete_image"`
-	Option       *OptionModel   `tfsdk:"option"`
-	BuildLog     *BuildLogModel `tfsdk:"buildlog"`
-	SHA256Digest types.String   `tfsdk:"sha256_digest"`
+	ID                 types.String           `tfsdk:"id"`
+	ImageURI           types.String           `tfsdk:"image_uri"`
+	Build              types.String           `tfsdk:"build"`
+	Environment        types.Map              `tfsdk:"environment"`
+	EnvFile            types.List             `tfsdk:"env_file"`
+	ComposeFile        types.String           `tfsdk:"compose_file"`
+	Service            types.String           `tfsdk:"service"`
+	SourceImage        types.String           `tfsdk:"source_image"`
+	SourceOCILayout    types.String           `tfsdk:"source_oci_layout"`
+	SourceTarball      types.String           `tfsdk:"source_tarball"`
+	Builder            types.String           `tfsdk:"builder"`
+	Platform           types.String           `tfsdk:"platform"`
+	FrontendImage      types.String           `tfsdk:"frontend_image"`
+	CacheFromPrevious  types.Bool             `tfsdk:"cache_from_previous"`
+	Squash             types.Bool             `tfsdk:"squash"`
+	AdditionalTags     types.List             `tfsdk:"additional_tags"`
+	DirectPush         types.Bool             `tfsdk:"direct_push"`
+	PushByDigest       types.Bool             `tfsdk:"push_by_digest"`
+	OnTagConflict      types.String           `tfsdk:"on_tag_conflict"`
+	OnDrift            types.String           `tfsdk:"on_drift"`
+	OnLabelChange      types.String           `tfsdk:"on_label_change"`
+	Offline            types.Bool             `tfsdk:"offline"`
+	DryRun             types.Bool             `tfsdk:"dry_run"`
+	Labels             types.Map              `tfsdk:"labels"`
+	Annotations        types.Map              `tfsdk:"annotations"`
+	MediaType          types.String           `tfsdk:"media_type"`
+	WaitForScan        types.Bool             `tfsdk:"wait_for_scan"`
+	FailOnSeverity     types.String           `tfsdk:"fail_on_severity"`
+	WaitForReplication types.List             `tfsdk:"wait_for_replication"`
+	WaitForAvailable   types.String           `tfsdk:"wait_for_available"`
+	OCILabels          types.Bool             `tfsdk:"oci_labels"`
+	Triggers           types.Map              `tfsdk:"triggers"`
+	WatchPaths         types.List             `tfsdk:"watch_paths"`
+	Rebuild            types.String           `tfsdk:"rebuild"`
+	DeleteImage        types.Bool             `tfsdk:"delete_image"`
+	DeletePreviousTag  types.Bool             `tfsdk:"delete_previous_tag"`
+	DeleteImageScope   types.String           `tfsdk:"delete_image_scope"`
+	PruneLocal         types.Bool             `tfsdk:"prune_local"`
+	PruneLocalParents  types.Bool             `tfsdk:"prune_local_parents"`
+	Option             *OptionModel           `tfsdk:"option"`
+	Export             *ExportModel           `tfsdk:"export"`
+	LoadInto           *LoadIntoModel         `tfsdk:"load_into"`
+	BuildLog           *BuildLogModel         `tfsdk:"buildlog"`
+	SHA256Digest       types.String           `tfsdk:"sha256_digest"`
+	PreviousDigest     types.String           `tfsdk:"previous_digest"`
+	ContextHash        types.String           `tfsdk:"context_hash"`
+	PushedImageURI     types.String           `tfsdk:"pushed_image_uri"`
+	ImageRef           types.String           `tfsdk:"image_ref"`
+	ScanFindings       types.Map              `tfsdk:"scan_findings"`
+	AttestationDigests types.Map              `tfsdk:"attestation_digests"`
+	BuildDuration      types.Float64          `tfsdk:"build_duration_seconds"`
+	PushDuration       types.Float64          `tfsdk:"push_duration_seconds"`
+	PushedBytes        types.Int64            `tfsdk:"pushed_bytes"`
+	Timeouts           *TimeoutsModel         `tfsdk:"timeouts"`
+	CreateRepository   *CreateRepositoryModel `tfsdk:"create_repository"`
+	Test               *SmokeTestModel        `tfsdk:"test"`
+	OnPush             *OnPushModel           `tfsdk:"on_push"`
+}
+
+// DockerfileImageResourceModel describes the containerregistry_dockerfile_image resource data model.
+type DockerfileImageResourceModel struct {
+	ID                 types.String           `tfsdk:"id"`
+	ImageURI           types.String           `tfsdk:"image_uri"`
+	DockerfileContents types.String           `tfsdk:"dockerfile_contents"`
+	Context            types.String           `tfsdk:"context"`
+	Target             types.String           `tfsdk:"target"`
+	Builder            types.String           `tfsdk:"builder"`
+	Platform           types.String           `tfsdk:"platform"`
+	FrontendImage      types.String           `tfsdk:"frontend_image"`
+	CacheFromPrevious  types.Bool             `tfsdk:"cache_from_previous"`
+	Squash             types.Bool             `tfsdk:"squash"`
+	AdditionalTags     types.List             `tfsdk:"additional_tags"`
+	DirectPush         types.Bool             `tfsdk:"direct_push"`
+	PushByDigest       types.Bool             `tfsdk:"push_by_digest"`
+	OnTagConflict      types.String           `tfsdk:"on_tag_conflict"`
+	OnDrift            types.String           `tfsdk:"on_drift"`
+	OnLabelChange      types.String           `tfsdk:"on_label_change"`
+	Offline            types.Bool             `tfsdk:"offline"`
+	DryRun             types.Bool             `tfsdk:"dry_run"`
+	BuildArgs          types.Map              `tfsdk:"build_args"`
+	AdditionalContexts types.Map              `tfsdk:"additional_contexts"`
+	SSH                types.List             `tfsdk:"ssh"`
+	CacheFrom          types.List             `tfsdk:"cache_from"`
+	CacheTo            types.List             `tfsdk:"cache_to"`
+	Entitlements       types.List             `tfsdk:"entitlements"`
+	Option             *OptionModel           `tfsdk:"option"`
+	Labels             types.Map              `tfsdk:"labels"`
+	Annotations        types.Map              `tfsdk:"annotations"`
+	MediaType          types.String           `tfsdk:"media_type"`
+	WaitForScan        types.Bool             `tfsdk:"wait_for_scan"`
+	FailOnSeverity     types.String           `tfsdk:"fail_on_severity"`
+	WaitForReplication types.List             `tfsdk:"wait_for_replication"`
+	WaitForAvailable   types.String           `tfsdk:"wait_for_available"`
+	OCILabels          types.Bool             `tfsdk:"oci_labels"`
+	Triggers           types.Map              `tfsdk:"triggers"`
+	WatchPaths         types.List             `tfsdk:"watch_paths"`
+	Rebuild            types.String           `tfsdk:"rebuild"`
+	DeleteImage        types.Bool             `tfsdk:"delete_image"`
+	DeletePreviousTag  types.Bool             `tfsdk:"delete_previous_tag"`
+	DeleteImageScope   types.String           `tfsdk:"delete_image_scope"`
+	PruneLocal         types.Bool             `tfsdk:"prune_local"`
+	PruneLocalParents  types.Bool             `tfsdk:"prune_local_parents"`
+	SHA256Digest       types.String           `tfsdk:"sha256_digest"`
+	PreviousDigest     types.String           `tfsdk:"previous_digest"`
+	ContextHash        types.String           `tfsdk:"context_hash"`
+	PushedImageURI     types.String           `tfsdk:"pushed_image_uri"`
+	ImageRef           types.String           `tfsdk:"image_ref"`
+	ScanFindings       types.Map              `tfsdk:"scan_findings"`
+	AttestationDigests types.Map              `tfsdk:"attestation_digests"`
+	BuildDuration      types.Float64          `tfsdk:"build_duration_seconds"`
+	PushDuration       types.Float64          `tfsdk:"push_duration_seconds"`
+	PushedBytes        types.Int64            `tfsdk:"pushed_bytes"`
+	Timeouts           *TimeoutsModel         `tfsdk:"timeouts"`
+	CreateRepository   *CreateRepositoryModel `tfsdk:"create_repository"`
+	Test               *SmokeTestModel        `tfsdk:"test"`
+	OnPush             *OnPushModel           `tfsdk:"on_push"`
+}
+
+// ComposeProjectResourceModel describes the containerregistry_compose_project resource data model.
+type ComposeProjectResourceModel struct {
+	ID                 types.String           `tfsdk:"id"`
+	ComposeFile        types.String           `tfsdk:"compose_file"`
+	Services           types.Map              `tfsdk:"services"`
+	Environment        types.Map              `tfsdk:"environment"`
+	EnvFile            types.List             `tfsdk:"env_file"`
+	Builder            types.String           `tfsdk:"builder"`
+	Platform           types.String           `tfsdk:"platform"`
+	FrontendImage      types.String           `tfsdk:"frontend_image"`
+	CacheFromPrevious  types.Bool             `tfsdk:"cache_from_previous"`
+	Squash             types.Bool             `tfsdk:"squash"`
+	AdditionalTags     types.List             `tfsdk:"additional_tags"`
+	DirectPush         types.Bool             `tfsdk:"direct_push"`
+	PushByDigest       types.Bool             `tfsdk:"push_by_digest"`
+	OnTagConflict      types.String           `tfsdk:"on_tag_conflict"`
+	OnDrift            types.String           `tfsdk:"on_drift"`
+	Offline            types.Bool             `tfsdk:"offline"`
+	DryRun             types.Bool             `tfsdk:"dry_run"`
+	Option             *OptionModel           `tfsdk:"option"`
+	Labels             types.Map              `tfsdk:"labels"`
+	Annotations        types.Map              `tfsdk:"annotations"`
+	MediaType          types.String           `tfsdk:"media_type"`
+	WaitForScan        types.Bool             `tfsdk:"wait_for_scan"`
+	FailOnSeverity     types.String           `tfsdk:"fail_on_severity"`
+	WaitForReplication types.List             `tfsdk:"wait_for_replication"`
+	WaitForAvailable   types.String           `tfsdk:"wait_for_available"`
+	OCILabels          types.Bool             `tfsdk:"oci_labels"`
+	Triggers           types.Map              `tfsdk:"triggers"`
+	WatchPaths         types.List             `tfsdk:"watch_paths"`
+	Rebuild            types.String           `tfsdk:"rebuild"`
+	DeleteImage        types.Bool             `tfsdk:"delete_image"`
+	DeletePreviousTag  types.Bool             `tfsdk:"delete_previous_tag"`
+	DeleteImageScope   types.String           `tfsdk:"delete_image_scope"`
+	PruneLocal         types.Bool             `tfsdk:"prune_local"`
+	PruneLocalParents  types.Bool             `tfsdk:"prune_local_parents"`
+	Images             types.Map              `tfsdk:"images"`
+	BuildDuration      types.Float64          `tfsdk:"build_duration_seconds"`
+	PushDuration       types.Float64          `tfsdk:"push_duration_seconds"`
+	PushedBytes        types.Int64            `tfsdk:"pushed_bytes"`
+	ContextHash        types.String           `tfsdk:"context_hash"`
+	Timeouts           *TimeoutsModel         `tfsdk:"timeouts"`
+	CreateRepository   *CreateRepositoryModel `tfsdk:"create_repository"`
+	Test               *SmokeTestModel        `tfsdk:"test"`
+	OnPush             *OnPushModel           `tfsdk:"on_push"`
+}
+
+// ComposeProjectImageModel represents an image pushed for a service of the containerregistry_compose_project resource.
+type ComposeProjectImageModel struct {
+	ImageURI     types.String `tfsdk:"image_uri"`
+	SHA256Digest types.String `tfsdk:"sha256_digest"`
 }
@@ -0,0 +1,118 @@
+package compose
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tfplugintypes "github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/ocilayout"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// pushImageFromDisk pushes the image in source_oci_layout or source_tarball as image_uri
+// with the Registry HTTP API, without a Docker daemon.
+func (r *ComposeResource) pushImageFromDisk(ctx context.Context, model *ComposeResourceModel, metrics *buildMetrics) error {
+	host, repository, ref, err := registryclient.ParseImageReference(model.ImageURI.ValueString())
+	if err != nil {
+		return err
+	}
+	if model.PushByDigest.ValueBool() {
+		// Pushed without a tag, and looked up by the digest of the image for an unchanged image
+		ref = ""
+	}
+
+	var img *ocilayout.Image
+	if !model.SourceOCILayout.IsNull() {
+		img, err = ocilayout.Open(model.SourceOCILayout.ValueString())
+	} else {
+		var cleanup func()
+		img, cleanup, err = ocilayout.OpenTarball(model.SourceTarball.ValueString())
+		if err == nil {
+			defer cleanup()
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read source image: %w", err)
+	}
+
+	tflog.Info(ctx, "Pushing image from disk to registry", map[string]interface{}{
+		"image_uri": model.ImageURI.ValueString(),
+		"digest":    img.Root.Digest,
+	})
+	if c, err := registryclient.New(r.providerConfig, host); err == nil {
+		lookup := ref
+		if lookup == "" {
+			lookup = img.Root.Digest
+		}
+		if remote, err := c.ResolveDigest(ctx, repository, lookup); err == nil && remote == img.Root.Digest {
+			tflog.Info(ctx, "Skipping push: the registry already has the image", map[string]interface{}{
+				"image_uri": model.ImageURI.ValueString(),
+				"digest":    remote,
+			})
+			metrics.Push.Skipped = true
+			model.SHA256Digest = tfplugintypes.StringValue(remote)
+			return nil
+		}
+	}
+
+	pushStart := time.Now()
+	var digest string
+	var stats *ocilayout.PushStats
+	err = r.retryPush(ctx, model.ImageURI.ValueString(), func() error {
+		// A new client requests a new registry token
+		c, err := registryclient.New(r.providerConfig, host)
+		if err != nil {
+			return err
+		}
+		digest, stats, err = ocilayout.Push(ctx, c, repository, ref, img)
+		return err
+	})
+	metrics.PushDuration = time.Since(pushStart)
+	if err != nil {
+		if !isTagConflict(err) {
+			return fmt.Errorf("failed to push image: %w", err)
+		}
+		return r.resolveTagConflict(ctx, model, metrics, err, img.Root.Digest, func(imageURI string) (string, error) {
+			_, _, tag, err := registryclient.ParseImageReference(imageURI)
+			if err != nil {
+				return "", err
+			}
+			var digest string
+			err = r.retryPush(ctx, imageURI, func() error {
+				c, err := registryclient.New(r.providerConfig, host)
+				if err != nil {
+					return err
+				}
+				digest, _, err = ocilayout.Push(ctx, c, repository, tag, img)
+				return err
+			})
+			if err != nil {
+				return "", err
+			}
+			return digest, r.verifyRegistryDigest(ctx, imageURI, digest)
+		})
+	}
+	if ref != "" {
+		if err := r.verifyRegistryDigest(ctx, model.ImageURI.ValueString(), digest); err != nil {
+			return err
+		}
+	}
+	metrics.Push = pushStats{
+		LayersPushed:   stats.BlobsPushed,
+		LayersExisting: stats.BlobsExisting,
+		PushedBytes:    stats.PushedBytes,
+	}
+
+	model.SHA256Digest = tfplugintypes.StringValue(digest)
+	tflog.Info(ctx, "Successfully pushed image from disk to registry", map[string]interface{}{
+		"image_uri":       model.ImageURI.ValueString(),
+		"digest":          digest,
+		"layers_pushed":   stats.BlobsPushed,
+		"layers_existing": stats.BlobsExisting,
+		"pushed_bytes":    stats.PushedBytes,
+	})
+	return nil
+}
@@ -0,0 +1,39 @@
+package compose
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Podman API sockets: rootless Podman listens in the runtime directory of the user, rootful Podman under /run.
+const (
+	podmanRootlessSocket = "podman/podman.sock"
+	podmanRootfulSocket  = "/run/podman/podman.sock"
+)
+
+// podmanHost returns the address of the Docker-compatible API of Podman, which builds with Buildah.
+// CONTAINER_HOST (as used by the podman remote client) takes precedence; otherwise the socket of
+// rootless Podman is used for non-root users and the socket of rootful Podman for root.
+func podmanHost() (string, error) {
+	if host := os.Getenv("CONTAINER_HOST"); host != "" {
+		if !strings.HasPrefix(host, "unix://") {
+			return "", fmt.Errorf("builder = %q supports only unix:// sockets in CONTAINER_HOST, got %q", builderPodman, host)
+		}
+		return host, nil
+	}
+
+	socket := podmanRootfulSocket
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" && os.Geteuid() != 0 {
+		socket = filepath.Join(runtimeDir, podmanRootlessSocket)
+	}
+	if _, err := os.Stat(socket); err != nil {
+		return "", fmt.Errorf(
+			"builder = %q requires the Podman API socket %s: enable it with `systemctl --user enable --now podman.socket` "+
+				"(rootless) or `systemctl enable --now podman.socket` (rootful), or set CONTAINER_HOST: %w",
+			builderPodman, socket, err,
+		)
+	}
+	return "unix://" + socket, nil
+}
@@ -2,30 +2,36 @@ package compose
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
+	"github.com/distribution/reference"
 	"github.com/google/uuid"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
-	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 
 	"github.com/ikedam/terraform-provider-containerregistry/internal/logging"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/ocilayout"
 	"github.com/ikedam/terraform-provider-containerregistry/internal/providerconfig"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
 )
 
 // Ensure provider defined types fully satisfy framework interfaces
 var _ resource.Resource = &ComposeResource{}
 var _ resource.ResourceWithConfigure = &ComposeResource{}
 var _ resource.ResourceWithImportState = &ComposeResource{}
+var _ resource.ResourceWithModifyPlan = &ComposeResource{}
+var _ resource.ResourceWithValidateConfig = &ComposeResource{}
 
 // NewComposeResource returns a new resource implementing the containerregistry_compose resource type.
 func NewComposeResource() resource.Resource {
@@ -60,50 +66,134 @@ func (r *ComposeResource) Schema(ctx context.Context, req resource.SchemaRequest
 				MarkdownDescription: "URI of the image to build and push",
 				Required:            true,
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
+					imageURIRequiresReplace(),
 				},
 			},
 			"build": schema.StringAttribute{
-				MarkdownDescription: "Docker compose v5 compatible build specification in JSON format",
-				Required:            true,
+				MarkdownDescription: "Docker compose v5 compatible build specification in JSON format. " +
+					"Exactly one of `build`, `compose_file`, `source_image`, `source_oci_layout` or `source_tarball` must be set.",
+				Optional: true,
+			},
+			"compose_file": schema.StringAttribute{
+				MarkdownDescription: "Path to a compose file (e.g. `compose.yaml`) to build the image of `service` from, instead of `build`. " +
+					"The file is loaded as `docker compose` does, with variable interpolation from the environment and the `.env` file next to it, " +
+					"`extends` and `include`. Labels must be set in `labels` rather than in the file. " +
+					"Exactly one of `build`, `compose_file`, `source_image`, `source_oci_layout` or `source_tarball` must be set.",
+				Optional: true,
+			},
+			"service": schema.StringAttribute{
+				MarkdownDescription: "Name of the service in `compose_file` whose build section is used. Required with `compose_file`.",
+				Optional:            true,
+			},
+			"environment": environmentAttribute(),
+			"env_file":    envFileAttribute(),
+			"source_image": schema.StringAttribute{
+				MarkdownDescription: "Existing image in the local Docker daemon to tag as `image_uri` and push instead of building. " +
+					"Exactly one of `build`, `compose_file`, `source_image`, `source_oci_layout` or `source_tarball` must be set.",
+				Optional: true,
+			},
+			"source_oci_layout": schema.StringAttribute{
+				MarkdownDescription: "Path to an OCI image layout directory (e.g. written by ko, bazel or buildah) to push as `image_uri` instead of building. " +
+					"The image is pushed with the Registry HTTP API, so no Docker daemon is needed. " +
+					"Exactly one of `build`, `compose_file`, `source_image`, `source_oci_layout` or `source_tarball` must be set.",
+				Optional: true,
 			},
+			"source_tarball": schema.StringAttribute{
+				MarkdownDescription: "Path to a tarball (optionally gzip-compressed) holding an OCI image layout or a docker-archive (e.g. written by `docker save`) " +
+					"to push as `image_uri` instead of building. The image is pushed with the Registry HTTP API, so no Docker daemon is needed. " +
+					"Exactly one of `build`, `compose_file`, `source_image`, `source_oci_layout` or `source_tarball` must be set.",
+				Optional: true,
+			},
+			"builder":             builderAttribute(),
+			"platform":            platformAttribute(),
+			"frontend_image":      frontendImageAttribute(),
+			"cache_from_previous": cacheFromPreviousAttribute(),
+			"squash":              squashAttribute(),
+			"additional_tags":     additionalTagsAttribute(),
+			"direct_push":         directPushAttribute(),
+			"push_by_digest":      pushByDigestAttribute(),
+			"on_tag_conflict":     onTagConflictAttribute(),
+			"on_drift":            onDriftAttribute(),
+			"on_label_change":     onLabelChangeAttribute(),
+			"offline":             offlineAttribute(),
+			"dry_run":             dryRunAttribute(),
 			"labels": schema.MapAttribute{
 				MarkdownDescription: "Labels for the image",
 				Optional:            true,
 				ElementType:         types.StringType,
 			},
+			"oci_labels":           ociLabelsAttribute(),
+			"annotations":          annotationsAttribute(),
+			"media_type":           mediaTypeAttribute(),
+			"wait_for_scan":        waitForScanAttribute(),
+			"fail_on_severity":     failOnSeverityAttribute(),
+			"wait_for_replication": waitForReplicationAttribute(),
+			"wait_for_available":   waitForAvailableAttribute(),
 			"triggers": schema.MapAttribute{
 				MarkdownDescription: "Map of arbitrary strings that, when changed, will force the image to be rebuilt",
 				Optional:            true,
 				ElementType:         types.StringType,
 			},
+			"watch_paths": watchPathsAttribute(),
+			"rebuild":     rebuildAttribute(),
 			"delete_image": schema.BoolAttribute{
 				MarkdownDescription: "Whether to delete the image when the resource is deleted",
 				Optional:            true,
 				Computed:            true,
 				Default:             booldefault.StaticBool(false),
 			},
-			"option": schema.SingleNestedAttribute{
-				MarkdownDescription: "Build options",
+			"delete_previous_tag": deletePreviousTagAttribute(),
+			"delete_image_scope":  deleteImageScopeAttribute(),
+			"prune_local": schema.BoolAttribute{
+				MarkdownDescription: "Whether to remove the locally built image from the Docker daemon after a successful push",
 				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"prune_local_parents": schema.BoolAttribute{
+				MarkdownDescription: "Whether `prune_local` also removes the untagged parent images of the image, like `docker rmi` does by default. " +
+					"Default is false, keeping the parents that later builds may reuse as cache",
+				Optional: true,
+				Computed: true,
+				Default:  booldefault.StaticBool(false),
+			},
+			"option": optionAttribute(),
+			"export": schema.SingleNestedAttribute{
+				MarkdownDescription: "Write the image to disk after the build, e.g. for air-gapped transfer or archival. " +
+					"Not available with `source_oci_layout` or `source_tarball`.",
+				Optional: true,
 				Attributes: map[string]schema.Attribute{
-					"pull": schema.BoolAttribute{
-						MarkdownDescription: "Always pull the latest image (equivalent to --pull)",
-						Optional:            true,
-						Computed:            true,
-						Default:             booldefault.StaticBool(false),
+					"type": schema.StringAttribute{
+						MarkdownDescription: "Export format: `docker` writes a docker-archive tarball (as `docker save`) to `path`, " +
+							"`oci` writes an OCI image layout into the directory `path`.",
+						Required: true,
 					},
-					"no_cache": schema.BoolAttribute{
-						MarkdownDescription: "Do not use cache when building the image (equivalent to --no-cache)",
-						Optional:            true,
-						Computed:            true,
-						Default:             booldefault.StaticBool(false),
+					"path": schema.StringAttribute{
+						MarkdownDescription: "Tarball file (`docker`) or directory (`oci`) to write the image to.",
+						Required:            true,
+					},
+					"skip_push": schema.BoolAttribute{
+						MarkdownDescription: "Only export the image and do not push it to the registry. " +
+							"The registry is then not consulted at all, and `sha256_digest` is the ID of the local image.",
+						Optional: true,
+						Computed: true,
+						Default:  booldefault.StaticBool(false),
+					},
+				},
+			},
+			"load_into": schema.SingleNestedAttribute{
+				MarkdownDescription: "Load the image into a local Kubernetes cluster after the push, so that it can be deployed without pulling from the registry. " +
+					"The CLI of the cluster tool (`kind`, `k3d` or `minikube`) must be available in PATH. " +
+					"Not available with `source_oci_layout` or `source_tarball`.",
+				Optional: true,
+				Attributes: map[string]schema.Attribute{
+					"type": schema.StringAttribute{
+						MarkdownDescription: "Cluster tool: `kind`, `k3d` or `minikube`.",
+						Required:            true,
 					},
-					"progress": schema.StringAttribute{
-						MarkdownDescription: "Progress output format (equivalent to --progress)",
+					"cluster": schema.StringAttribute{
+						MarkdownDescription: "Name of the cluster (the minikube profile for `minikube`). Omit to use the default cluster of the tool.",
 						Optional:            true,
-						Computed:            true,
-						Default:             stringdefault.StaticString("auto"),
 					},
 				},
 			},
@@ -130,12 +220,42 @@ func (r *ComposeResource) Schema(ctx context.Context, req resource.SchemaRequest
 							" To see build logs during apply, set `TF_LOG_PROVIDER` or `TF_LOG` environment variables (plugin logging is off by default; see https://developer.hashicorp.com/terraform/plugin/log/managing).",
 						Optional: true,
 					},
+					"steps": schema.BoolAttribute{
+						MarkdownDescription: "Log a summary at info level when each build step (Dockerfile instruction) starts and finishes, " +
+							"so that the progress of long builds is visible without streaming the whole output. Default is false.",
+						Optional: true,
+					},
+					"file": schema.StringAttribute{
+						MarkdownDescription: "Path of a file to which the full build output is written. The file is replaced on each build.",
+						Optional:            true,
+					},
 				},
 			},
+			"attestation_digests": schema.MapAttribute{
+				MarkdownDescription: "Digests of the attestation manifests (provenance, SBOM) pushed with the image, keyed by platform. " +
+					"Set when `option.provenance` or `option.sbom` requests attestations.",
+				Computed:    true,
+				ElementType: types.StringType,
+			},
+			"pushed_image_uri":       pushedImageURIAttribute(),
+			"image_ref":              imageRefAttribute(),
+			"scan_findings":          scanFindingsAttribute(),
+			"build_duration_seconds": buildDurationSecondsAttribute(),
+			"push_duration_seconds":  pushDurationSecondsAttribute(),
+			"pushed_bytes":           pushedBytesAttribute(),
+			"context_hash":           contextHashAttribute(),
 			"sha256_digest": schema.StringAttribute{
 				MarkdownDescription: "SHA256 digest of the image in the registry",
 				Computed:            true,
 			},
+			"previous_digest": previousDigestAttribute(),
+		},
+
+		Blocks: map[string]schema.Block{
+			"timeouts":          timeoutsBlock(),
+			"create_repository": createRepositoryBlock(),
+			"test":              smokeTestBlock(),
+			"on_push":           onPushBlock(),
 		},
 	}
 }
@@ -150,6 +270,219 @@ func (r *ComposeResource) Configure(ctx context.Context, req resource.ConfigureR
 	}
 }
 
+// ValidateConfig checks that exactly one image source (build, source_image, source_oci_layout or source_tarball) is set.
+func (r *ComposeResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config ComposeResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(validateTimeouts(config.Timeouts)...)
+	resp.Diagnostics.Append(validateBuildSpec(ctx, &config)...)
+	resp.Diagnostics.Append(validateCreateRepository(config.CreateRepository, config.ImageURI)...)
+	resp.Diagnostics.Append(validateSmokeTest(config.Test, config.Builder, config.DirectPush)...)
+	resp.Diagnostics.Append(validateOnPush(config.OnPush)...)
+	resp.Diagnostics.Append(validateScan(config.WaitForScan, config.FailOnSeverity, config.ImageURI)...)
+	resp.Diagnostics.Append(validateWaitForReplication(ctx, config.WaitForReplication, config.ImageURI)...)
+	resp.Diagnostics.Append(validateWaitForAvailable(config.WaitForAvailable)...)
+	resp.Diagnostics.Append(validateOption(config.Option)...)
+	resp.Diagnostics.Append(validatePlatform(config.Platform)...)
+	resp.Diagnostics.Append(validateFrontendImage(config.FrontendImage)...)
+	resp.Diagnostics.Append(validateAdditionalTags(ctx, config.AdditionalTags)...)
+	resp.Diagnostics.Append(validateDirectPush(config.DirectPush, config.Builder, config.Squash)...)
+	resp.Diagnostics.Append(validatePushByDigest(config.PushByDigest, config.Builder, config.DirectPush)...)
+	resp.Diagnostics.Append(validateOnTagConflict(config.OnTagConflict, config.Builder, config.DirectPush)...)
+	resp.Diagnostics.Append(validateOnDrift(config.OnDrift)...)
+	resp.Diagnostics.Append(validateOnLabelChange(config.OnLabelChange)...)
+	resp.Diagnostics.Append(validateDeleteImageScope(config.DeleteImageScope, config.ImageURI)...)
+	resp.Diagnostics.Append(validateOffline(config.Offline, config.Builder, config.CacheFromPrevious, config.Option)...)
+	resp.Diagnostics.Append(validateDryRun(config.DryRun, config.Builder, config.DirectPush)...)
+	resp.Diagnostics.Append(validateWatchPaths(ctx, config.WatchPaths)...)
+	resp.Diagnostics.Append(validateRebuild(config.Rebuild)...)
+	resp.Diagnostics.Append(validateAnnotations(config.Annotations, config.Builder, config.Squash)...)
+	resp.Diagnostics.Append(validateMediaType(config.MediaType, config.Builder, config.Squash, config.Annotations, config.Option)...)
+
+	sources := []types.String{config.Build, config.ComposeFile, config.SourceImage, config.SourceOCILayout, config.SourceTarball}
+	count := 0
+	for _, source := range sources {
+		if source.IsUnknown() {
+			return
+		}
+		if !source.IsNull() {
+			count++
+		}
+	}
+	if count != 1 {
+		resp.Diagnostics.AddError(
+			"Invalid image source",
+			"Exactly one of build, compose_file, source_image, source_oci_layout or source_tarball must be set.",
+		)
+		return
+	}
+	if config.ComposeFile.IsNull() != config.Service.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("service"),
+			"Invalid compose service",
+			"compose_file and service must be set together.",
+		)
+	}
+	builds := !config.Build.IsNull() || !config.ComposeFile.IsNull()
+	resp.Diagnostics.Append(validateBuilder(config.Builder, config.Export != nil || config.LoadInto != nil || config.PruneLocal.ValueBool())...)
+	if config.Export != nil {
+		if config.Export.SkipPush.ValueBool() && !config.AdditionalTags.IsNull() {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("additional_tags"),
+				"Tags require a push",
+				"additional_tags tags the pushed image in the registry and cannot be used with export.skip_push.",
+			)
+		}
+		if config.Export.SkipPush.ValueBool() && config.OnPush != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("on_push"),
+				"Hooks require a push",
+				"on_push runs after the push and cannot be used with export.skip_push.",
+			)
+		}
+		if config.Export.SkipPush.ValueBool() && config.PushByDigest.ValueBool() {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("push_by_digest"),
+				"Push by digest requires a push",
+				"push_by_digest cannot be used with export.skip_push.",
+			)
+		}
+		if !config.SourceOCILayout.IsNull() || !config.SourceTarball.IsNull() {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("export"),
+				"Export is not available for images on disk",
+				"export requires the image in the local Docker daemon and cannot be used with source_oci_layout or source_tarball.",
+			)
+		}
+		if exportType := config.Export.Type; !exportType.IsUnknown() && exportType.ValueString() != ocilayout.FormatDocker && exportType.ValueString() != ocilayout.FormatOCI {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("export").AtName("type"),
+				"Invalid export type",
+				fmt.Sprintf("export.type must be %q or %q.", ocilayout.FormatDocker, ocilayout.FormatOCI),
+			)
+		}
+	}
+	if config.LoadInto != nil {
+		if !config.SourceOCILayout.IsNull() || !config.SourceTarball.IsNull() {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("load_into"),
+				"Loading into a cluster is not available for images on disk",
+				"load_into requires the image in the local Docker daemon and cannot be used with source_oci_layout or source_tarball.",
+			)
+		}
+		if clusterType := config.LoadInto.Type; !clusterType.IsUnknown() {
+			if _, err := loadCommand(clusterType.ValueString(), "", ""); err != nil {
+				resp.Diagnostics.AddAttributeError(
+					path.Root("load_into").AtName("type"),
+					"Invalid load_into type",
+					err.Error(),
+				)
+			}
+		}
+	}
+	if !builds && !config.Platform.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("platform"),
+			"Platform cannot be applied to an existing image",
+			"platform selects the build target platform and cannot be used with source_image, source_oci_layout or source_tarball.",
+		)
+	}
+	if !builds && !config.FrontendImage.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("frontend_image"),
+			"Frontend cannot be applied to an existing image",
+			"frontend_image selects the Dockerfile frontend at build time and cannot be used with source_image, source_oci_layout or source_tarball.",
+		)
+	}
+	if !builds && config.CacheFromPrevious.ValueBool() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("cache_from_previous"),
+			"Cache cannot be applied to an existing image",
+			"cache_from_previous is used when building and cannot be used with source_image, source_oci_layout or source_tarball.",
+		)
+	}
+	if !builds && config.DirectPush.ValueBool() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("direct_push"),
+			"Direct push requires a build",
+			"direct_push pushes the image from BuildKit while building and cannot be used with source_image, source_oci_layout or source_tarball.",
+		)
+	}
+	if config.DirectPush.ValueBool() && (config.Export != nil || config.LoadInto != nil) {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("direct_push"),
+			"Local image required",
+			"direct_push does not keep the image in the Docker daemon and cannot be used with export or load_into.",
+		)
+	}
+	if !builds && config.DryRun.ValueBool() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("dry_run"),
+			"Dry run requires a build",
+			"dry_run only builds the image and cannot be used with source_image, source_oci_layout or source_tarball, which have nothing to build.",
+		)
+	}
+	if !builds && config.Offline.ValueBool() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("offline"),
+			"Offline mode requires a build",
+			"offline applies to the build and cannot be used with source_image, source_oci_layout or source_tarball.",
+		)
+	}
+	if !builds && config.Squash.ValueBool() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("squash"),
+			"Squash cannot be applied to an existing image",
+			"squash merges the layers of the built image and cannot be used with source_image, source_oci_layout or source_tarball.",
+		)
+	}
+	if !builds && config.OCILabels.ValueBool() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("oci_labels"),
+			"Labels cannot be applied to an existing image",
+			"oci_labels adds labels at build time and cannot be used with source_image, source_oci_layout or source_tarball.",
+		)
+	}
+	if !builds && (!config.Environment.IsNull() || !config.EnvFile.IsNull()) {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("environment"),
+			"Variables cannot be applied to an existing image",
+			"environment and env_file are used for interpolation in build or compose_file and cannot be used with source_image, source_oci_layout or source_tarball.",
+		)
+	}
+	if !builds && !config.Annotations.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("annotations"),
+			"Annotations cannot be applied to an existing image",
+			"annotations are added at build time and cannot be used with source_image, source_oci_layout or source_tarball.",
+		)
+	}
+	if !builds && !config.MediaType.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("media_type"),
+			"Media types cannot be changed for an existing image",
+			"media_type is applied at build time and cannot be used with source_image, source_oci_layout or source_tarball.",
+		)
+	}
+	if !builds && config.Test != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("test"),
+			"Test requires a build",
+			"test checks the freshly built image before the push and cannot be used with source_image, source_oci_layout or source_tarball.",
+		)
+	}
+	if !builds && !config.Labels.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("labels"),
+			"Labels cannot be applied to an existing image",
+			"labels are set at build time and cannot be added to source_image, source_oci_layout or source_tarball. Remove labels or use build instead.",
+		)
+	}
+}
+
 // Create creates the resource and sets the initial Terraform state.
 func (r *ComposeResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
 	// Initialize the HTTP logging subsystem and header masking for this request.
@@ -167,9 +500,23 @@ func (r *ComposeResource) Create(ctx context.Context, req resource.CreateRequest
 		"image_uri": plan.ImageURI.ValueString(),
 	})
 
-	// Build and push the image
-	lastBuildLines, err := r.buildAndPushImage(ctx, &plan)
+	ctx, cancel, err := withTimeout(ctx, plan.Timeouts.createTimeout())
 	if err != nil {
+		resp.Diagnostics.AddError("Invalid timeout", err.Error())
+		return
+	}
+	defer cancel()
+
+	// Build and push the image
+	plan.ContextHash = resolveContextHash(ctx, plan.ContextHash, func() (types.String, error) {
+		return r.buildContextHash(ctx, &plan)
+	})
+	var metrics buildMetrics
+	lastBuildLines, err := r.buildAndPushImage(ctx, &plan, "", &metrics)
+	if errors.Is(err, errDigestPending) {
+		resp.Diagnostics.Append(recordPendingDigest(ctx, resp.Private, plan.PushedImageURI.ValueString(), err)...)
+	} else if err != nil {
+		err = timeoutError(ctx, plan.Timeouts.createTimeout(), err)
 		detail := fmt.Sprintf("Could not build and push image %s: %s", plan.ImageURI.ValueString(), err)
 		if len(lastBuildLines) > 0 {
 			detail += "\n\nLast build log lines:\n" + strings.Join(lastBuildLines, "\n")
@@ -178,11 +525,23 @@ func (r *ComposeResource) Create(ctx context.Context, req resource.CreateRequest
 			"Error building and pushing image",
 			detail,
 		)
-		return
+		// A step following the push failed: the pushed image is recorded, and Terraform taints the resource
+		if !imagePushed(plan.PushedImageURI) {
+			return
+		}
 	}
 
+	plan.BuildDuration, plan.PushDuration, plan.PushedBytes = metricsValues(&metrics)
+
 	// Set the ID to the image URI
 	plan.ID = plan.ImageURI
+	plan.PreviousDigest = types.StringNull()
+
+	if !resp.Diagnostics.HasError() {
+		if err := r.writeApplySummary(ctx, &plan, &metrics); err != nil {
+			resp.Diagnostics.AddWarning("Error writing apply summary", err.Error())
+		}
+	}
 
 	// Save the plan to the state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
@@ -206,39 +565,66 @@ func (r *ComposeResource) Read(ctx context.Context, req resource.ReadRequest, re
 		"id":        state.ID.ValueString(),
 	})
 
+	// An image that is only built with dry_run or exported to disk has nothing to refresh from the registry
+	if skipPush(&state) {
+		return
+	}
+
 	// Try to fetch image information from the container registry using the Registry API
 	// We use the image URI stored in the state file, even when the tag might have changed
-	imageInfo, err := r.getImageInfoFromRegistry(ctx, &state)
+	// Images pushed by digest or with a suffixed tag are not found with the tag of image_uri
+	imageInfo, err := r.getImageInfoFromRegistry(ctx, &ComposeResourceModel{
+		ImageURI: types.StringValue(registryImageURI(&state)),
+	})
 	if err != nil {
-		tflog.Warn(ctx, "Failed to get image info from registry", map[string]interface{}{
+		// Only a definitive answer from the registry means the image is gone: keep the state on network,
+		// authentication and server errors so that a flaky registry does not plan a rebuild
+		if !registryclient.IsNotFound(err) {
+			resp.Diagnostics.AddError(
+				"Error reading image",
+				fmt.Sprintf("Could not read image %s from the registry: %s", state.ImageURI.ValueString(), err),
+			)
+			return
+		}
+		tflog.Warn(ctx, "Image not found in registry", map[string]interface{}{
 			"image_uri": state.ImageURI.ValueString(),
 			"error":     err.Error(),
 		})
 
-		// If the image doesn't exist in the registry, mark it as deleted from state
+		// The image doesn't exist in the registry, mark it as deleted from state
 		resp.State.RemoveResource(ctx)
 		return
 	}
 
-	// If image exists, update label information from the registry
-	if len(imageInfo.Labels) > 0 {
+	// Another image may have been pushed to the tag out of band
+	var drifts []imageDrift
+	if drift, ok := detectDrift(registryImageURI(&state), state.SHA256Digest, imageInfo.ManifestDigest); ok {
+		drifts = append(drifts, drift)
+	}
+	adopt, diags := applyOnDrift(ctx, state.OnDrift, drifts, resp.Private)
+	resp.Diagnostics.Append(diags...)
+	if !adopt || resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Labels injected by oci_labels are not part of the configuration
+	if state.OCILabels.ValueBool() {
+		imageInfo.Labels = removeInjectedLabels(imageInfo.Labels, r.extractLabels(&state))
+	}
+
+	// An existing image pushed with source_image, source_oci_layout or source_tarball keeps the labels it was built with,
+	// which labels cannot configure
+	if !state.SourceImage.IsNull() || !state.SourceOCILayout.IsNull() || !state.SourceTarball.IsNull() {
+		state.Labels = types.MapNull(types.StringType)
+	} else if len(imageInfo.Labels) > 0 {
+		// If image exists, update label information from the registry
 		tflog.Debug(ctx, "Updating labels from registry", map[string]interface{}{
 			"image_uri": state.ImageURI.ValueString(),
 			"labels":    imageInfo.Labels,
 		})
 
-		// Convert the map[string]string to map[string]attr.Value for Terraform.
-		// Exclude com.docker.compose.* labels as they are added by Compose and cause unwanted diffs.
-		labelValues := make(map[string]attr.Value, len(imageInfo.Labels))
-		for k, v := range imageInfo.Labels {
-			if strings.HasPrefix(k, "com.docker.compose.") {
-				continue
-			}
-			labelValues[k] = types.StringValue(v)
-		}
-
 		// Create a new labels map
-		labelsMap, diags := types.MapValue(types.StringType, labelValues)
+		labelsMap, diags := registryLabelsValue(imageInfo.Labels)
 		if diags.HasError() {
 			resp.Diagnostics.Append(diags...)
 			return
@@ -255,6 +641,9 @@ func (r *ComposeResource) Read(ctx context.Context, req resource.ReadRequest, re
 	// Update the SHA256 digest - use manifest digest which is used for docker pull
 	if imageInfo.ManifestDigest != "" {
 		state.SHA256Digest = types.StringValue(imageInfo.ManifestDigest)
+		state.ImageRef = imageRefValue(&state)
+		// The digest of an image pushed without it is now recorded
+		resp.Diagnostics.Append(resolvePendingDigest(ctx, resp.Private, imageInfo.ManifestDigest)...)
 		tflog.Debug(ctx, "Updated image manifest SHA256 digest from registry", map[string]interface{}{
 			"image_uri": state.ImageURI.ValueString(),
 			"digest":    imageInfo.ManifestDigest,
@@ -290,9 +679,33 @@ func (r *ComposeResource) Update(ctx context.Context, req resource.UpdateRequest
 		"image_uri": plan.ImageURI.ValueString(),
 	})
 
-	// Build and push the image
-	lastBuildLines, err := r.buildAndPushImage(ctx, &plan)
+	// Only attributes not affecting the image changed: the plan keeps the digest and the other attributes written by the build
+	if !plan.SHA256Digest.IsUnknown() {
+		tflog.Info(ctx, "Updating the resource without rebuilding the image", map[string]interface{}{
+			"image_uri": plan.ImageURI.ValueString(),
+		})
+		resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+		return
+	}
+
+	ctx, cancel, err := withTimeout(ctx, plan.Timeouts.updateTimeout())
 	if err != nil {
+		resp.Diagnostics.AddError("Invalid timeout", err.Error())
+		return
+	}
+	defer cancel()
+
+	// Build and push the image
+	plan.ContextHash = resolveContextHash(ctx, plan.ContextHash, func() (types.String, error) {
+		return r.buildContextHash(ctx, &plan)
+	})
+	var metrics buildMetrics
+	lastBuildLines, err := r.relabelOrBuild(ctx, req, &state, &plan, &metrics)
+	pending := errors.Is(err, errDigestPending)
+	if pending {
+		resp.Diagnostics.Append(recordPendingDigest(ctx, resp.Private, plan.PushedImageURI.ValueString(), err)...)
+	} else if err != nil {
+		err = timeoutError(ctx, plan.Timeouts.updateTimeout(), err)
 		detail := fmt.Sprintf("Could not build and push image %s: %s", plan.ImageURI.ValueString(), err)
 		if len(lastBuildLines) > 0 {
 			detail += "\n\nLast build log lines:\n" + strings.Join(lastBuildLines, "\n")
@@ -301,7 +714,34 @@ func (r *ComposeResource) Update(ctx context.Context, req resource.UpdateRequest
 			"Error building and pushing image",
 			detail,
 		)
-		return
+		// A step following the push failed: the pushed image is recorded rather than the previous one
+		if !imagePushed(plan.PushedImageURI) {
+			return
+		}
+	}
+
+	plan.PreviousDigest = previousDigestValue(state.SHA256Digest, state.PreviousDigest, plan.SHA256Digest, !skipPush(&state) && !skipPush(&plan))
+	plan.BuildDuration, plan.PushDuration, plan.PushedBytes = metricsValues(&metrics)
+
+	if !resp.Diagnostics.HasError() {
+		if err := r.writeApplySummary(ctx, &plan, &metrics); err != nil {
+			resp.Diagnostics.AddWarning("Error writing apply summary", err.Error())
+		}
+	}
+
+	// The image is pushed again over a drifted tag, and replaces an image pushed without its digest
+	resp.Diagnostics.Append(resp.Private.SetKey(ctx, driftPrivateKey, nil)...)
+	if !pending {
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, pendingDigestPrivateKey, nil)...)
+	}
+
+	// The tag of image_uri changed in place; the previous tag is kept until the digest of the new image is known
+	if !skipPush(&state) && !pending && !resp.Diagnostics.HasError() {
+		if tag := previousTag(plan.DeletePreviousTag, registryImageURI(&state), plan.PushedImageURI.ValueString()); tag != "" {
+			if err := r.deletePreviousTag(ctx, tag, plan.SHA256Digest.ValueString()); err != nil {
+				resp.Diagnostics.AddWarning("Error deleting previous tag", err.Error())
+			}
+		}
 	}
 
 	// Save the updated plan to the state
@@ -320,20 +760,50 @@ func (r *ComposeResource) Delete(ctx context.Context, req resource.DeleteRequest
 		return
 	}
 
+	ctx, cancel, err := withTimeout(ctx, state.Timeouts.deleteTimeout())
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid timeout", err.Error())
+		return
+	}
+	defer cancel()
+
+	r.deleteImage(ctx, &state, resp)
+
+	// No need to update the state as it will be removed by Terraform after this function returns
+}
+
+// deleteImage deletes the image of state from the registry when delete_image is set.
+// Failures are reported as warnings so that the resource is removed from the state anyway.
+func (r *ComposeResource) deleteImage(ctx context.Context, state *ComposeResourceModel, resp *resource.DeleteResponse) {
 	// Log the delete operation
 	tflog.Info(ctx, "Deleting container registry image", map[string]interface{}{
 		"image_uri": state.ImageURI.ValueString(),
 	})
 
-	// Check if we should actually delete the image
-	if state.DeleteImage.ValueBool() {
+	// Check if we should actually delete the image (never pushed with dry_run or when only exported to disk)
+	if state.DeleteImage.ValueBool() && !skipPush(state) {
 		// Delete the image from the registry
 		tflog.Info(ctx, "Deleting the image from registry", map[string]interface{}{
 			"image_uri": state.ImageURI.ValueString(),
 		})
 
-		err := r.deleteImageFromRegistry(ctx, &state)
-		if err != nil {
+		err := r.deleteImageInScope(ctx, state)
+		if errors.Is(err, errImageAlreadyDeleted) {
+			// The image was removed out-of-band; the goal of the deletion is already achieved
+			resp.Diagnostics.AddWarning(
+				"Image already deleted from registry",
+				fmt.Sprintf("Image %s was not found in the registry; treating it as deleted: %s", state.ImageURI.ValueString(), err),
+			)
+		} else if errors.Is(err, errImageDeletionUnsupported) {
+			// Many registries disable manifest deletion; the image is left in place
+			resp.Diagnostics.AddWarning(
+				"Image deletion not supported by registry",
+				fmt.Sprintf("The registry rejected deleting image %s, so it was left in the registry. "+
+					"Enable deletion in the registry or remove the image manually, set delete_image_scope = \"digest\" "+
+					"when the registry only supports deleting by digest, or set delete_image = false to skip deletion: %s",
+					state.ImageURI.ValueString(), err),
+			)
+		} else if err != nil {
 			resp.Diagnostics.AddWarning(
 				"Error deleting image from registry",
 				fmt.Sprintf("Could not delete image %s: %s", state.ImageURI.ValueString(), err),
@@ -345,11 +815,11 @@ func (r *ComposeResource) Delete(ctx context.Context, req resource.DeleteRequest
 			})
 		}
 	}
-
-	// No need to update the state as it will be removed by Terraform after this function returns
 }
 
 // ImportState imports an existing resource into Terraform.
+// The import ID is the image URI. The digest and labels of the image are read from the registry,
+// so that the first plan after the import only shows the differences with the configuration.
 func (r *ComposeResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
 	// Initialize the HTTP logging subsystem and header masking for this request.
 	ctx = logging.WithHTTPLoggingSubsystem(ctx)
@@ -359,37 +829,93 @@ func (r *ComposeResource) ImportState(ctx context.Context, req resource.ImportSt
 		"image_uri": req.ID,
 	})
 
-	// Set the image_uri attribute from the provided ID (which is expected to be the image URI)
-	resp.Diagnostics.Append(resp.State.SetAttribute(
-		ctx,
-		path.Root("image_uri"),
-		req.ID,
-	)...)
+	if _, err := reference.ParseNormalizedNamed(req.ID); err != nil {
+		resp.Diagnostics.AddError(
+			"Invalid import ID",
+			fmt.Sprintf("The import ID must be the URI of the image (e.g. registry.example.com/app:v1.0.0), got %q: %s", req.ID, err),
+		)
+		return
+	}
+
+	// Fail the import of a missing image here, rather than importing a resource that the refresh removes
+	imageInfo, err := r.getImageInfoFromRegistry(ctx, &ComposeResourceModel{
+		ImageURI: types.StringValue(req.ID),
+	})
+	if err != nil {
+		if registryclient.IsNotFound(err) {
+			resp.Diagnostics.AddError(
+				"Image not found",
+				fmt.Sprintf("Image %s was not found in the registry: %s", req.ID, err),
+			)
+			return
+		}
+		resp.Diagnostics.AddError(
+			"Error reading image",
+			fmt.Sprintf("Could not read image %s from the registry: %s", req.ID, err),
+		)
+		return
+	}
 
 	// Generate a new UUID for the resource ID
 	// This is needed because we don't use image URI as the resource ID since the tag might change
-	id := generateUUID()
-	resp.Diagnostics.Append(resp.State.SetAttribute(
-		ctx,
-		path.Root("id"),
-		id,
-	)...)
-
-	// Set default values for optional attributes
-	resp.Diagnostics.Append(resp.State.SetAttribute(
-		ctx,
-		path.Root("delete_image"),
-		false,
-	)...)
-
-	// The remaining attributes like build, labels, and triggers
+	state := ComposeResourceModel{
+		ID:             types.StringValue(generateUUID()),
+		ImageURI:       types.StringValue(req.ID),
+		PushedImageURI: types.StringValue(req.ID),
+	}
+	if imageInfo.ManifestDigest != "" {
+		state.SHA256Digest = types.StringValue(imageInfo.ManifestDigest)
+		state.ImageRef = imageRefValue(&state)
+	}
+	// The source of the image is not known before the configuration is read: labels are imported for a build,
+	// and the first apply clears them for the other sources, whose labels are not refreshed afterwards
+	state.Labels = types.MapNull(types.StringType)
+	if len(imageInfo.Labels) > 0 {
+		labelsMap, diags := registryLabelsValue(imageInfo.Labels)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		state.Labels = labelsMap
+	}
+
+	for name, value := range map[string]attr.Value{
+		"id":               state.ID,
+		"image_uri":        state.ImageURI,
+		"pushed_image_uri": state.PushedImageURI,
+		"sha256_digest":    state.SHA256Digest,
+		"image_ref":        state.ImageRef,
+		"labels":           state.Labels,
+		// Set default values for optional attributes
+		"delete_image":        types.BoolValue(false),
+		"prune_local":         types.BoolValue(false),
+		"prune_local_parents": types.BoolValue(false),
+	} {
+		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root(name), value)...)
+	}
+
+	// The remaining attributes like build and triggers
 	// will need to be set by the user after import
 	tflog.Info(ctx, "Successfully imported image, additional configuration required", map[string]interface{}{
 		"image_uri": req.ID,
-		"id":        id,
+		"id":        state.ID.ValueString(),
+		"digest":    imageInfo.ManifestDigest,
 	})
 }
 
+// registryLabelsValue converts the labels of an image in the registry to a Terraform map.
+// com.docker.compose.* labels are excluded as they are added by Compose and cause unwanted diffs.
+func registryLabelsValue(labels map[string]string) (types.Map, diag.Diagnostics) {
+	labelValues := make(map[string]attr.Value, len(labels))
+	for k, v := range labels {
+		if strings.HasPrefix(k, "com.docker.compose.") {
+			continue
+		}
+		labelValues[k] = types.StringValue(v)
+	}
+	return types.MapValue(types.StringType, labelValues)
+}
+
 // generateUUID generates a new UUID for resource identification
 func generateUUID() string {
 	// Import package in the top of the file: "github.com/google/uuid"
@@ -0,0 +1,114 @@
+package compose
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	tfplugintypes "github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// summaryMu serializes writes to the apply summary file, which is shared by all resources.
+var summaryMu sync.Mutex
+
+// buildMetrics holds measurements of a build and push.
+type buildMetrics struct {
+	BuildDuration time.Duration
+	PushDuration  time.Duration
+	Push          pushStats
+}
+
+// add adds the durations and the pushed bytes of other, to total the builds of several services.
+func (m *buildMetrics) add(other buildMetrics) {
+	m.BuildDuration += other.BuildDuration
+	m.PushDuration += other.PushDuration
+	m.Push.PushedBytes += other.Push.PushedBytes
+}
+
+// buildDurationSecondsAttribute returns the schema of the build_duration_seconds attribute shared by the image resources.
+func buildDurationSecondsAttribute() schema.Float64Attribute {
+	return schema.Float64Attribute{
+		MarkdownDescription: "Duration of the build of the last apply in seconds. 0 when an existing image is pushed.",
+		Computed:            true,
+	}
+}
+
+// pushDurationSecondsAttribute returns the schema of the push_duration_seconds attribute shared by the image resources.
+func pushDurationSecondsAttribute() schema.Float64Attribute {
+	return schema.Float64Attribute{
+		MarkdownDescription: "Duration of the push of the last apply in seconds. 0 when the image is not pushed.",
+		Computed:            true,
+	}
+}
+
+// pushedBytesAttribute returns the schema of the pushed_bytes attribute shared by the image resources.
+func pushedBytesAttribute() schema.Int64Attribute {
+	return schema.Int64Attribute{
+		MarkdownDescription: "Total size in bytes of the layers uploaded by the push of the last apply, " +
+			"excluding the layers the registry already had. 0 when the push is skipped.",
+		Computed: true,
+	}
+}
+
+// metricsValues returns the values of build_duration_seconds, push_duration_seconds and pushed_bytes for metrics.
+func metricsValues(metrics *buildMetrics) (buildDuration, pushDuration tfplugintypes.Float64, pushedBytes tfplugintypes.Int64) {
+	return tfplugintypes.Float64Value(metrics.BuildDuration.Seconds()),
+		tfplugintypes.Float64Value(metrics.PushDuration.Seconds()),
+		tfplugintypes.Int64Value(metrics.Push.PushedBytes)
+}
+
+// applySummary is a single line written to the apply summary file.
+type applySummary struct {
+	Timestamp            string  `json:"timestamp"`
+	ID                   string  `json:"id"`
+	ImageURI             string  `json:"image_uri"`
+	SHA256Digest         string  `json:"sha256_digest"`
+	BuildDurationSeconds float64 `json:"build_duration_seconds"`
+	PushDurationSeconds  float64 `json:"push_duration_seconds"`
+	PushedBytes          int64   `json:"pushed_bytes"`
+	LayersPushed         int     `json:"layers_pushed"`
+	LayersExisting       int     `json:"layers_existing"`
+	PushSkipped          bool    `json:"push_skipped"`
+}
+
+// writeApplySummary appends a JSON line describing the published image to the
+// apply summary file configured in the provider. It does nothing when no file is configured.
+func (r *ComposeResource) writeApplySummary(_ context.Context, model *ComposeResourceModel, metrics *buildMetrics) error {
+	if r.providerConfig == nil || r.providerConfig.ApplySummaryFile == "" {
+		return nil
+	}
+
+	line, err := json.Marshal(applySummary{
+		Timestamp:            time.Now().UTC().Format(time.RFC3339),
+		ID:                   model.ID.ValueString(),
+		ImageURI:             model.ImageURI.ValueString(),
+		SHA256Digest:         model.SHA256Digest.ValueString(),
+		BuildDurationSeconds: metrics.BuildDuration.Seconds(),
+		PushDurationSeconds:  metrics.PushDuration.Seconds(),
+		PushedBytes:          metrics.Push.PushedBytes,
+		LayersPushed:         metrics.Push.LayersPushed,
+		LayersExisting:       metrics.Push.LayersExisting,
+		PushSkipped:          metrics.Push.Skipped,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode apply summary: %w", err)
+	}
+
+	summaryMu.Lock()
+	defer summaryMu.Unlock()
+
+	f, err := os.OpenFile(r.providerConfig.ApplySummaryFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open apply summary file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write apply summary file: %w", err)
+	}
+	return f.Close()
+}
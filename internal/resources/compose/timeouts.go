@@ -0,0 +1,144 @@
+package compose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// TimeoutsModel represents the timeouts block of the image resources
+type TimeoutsModel struct {
+	Create types.String `tfsdk:"create"`
+	Update types.String `tfsdk:"update"`
+	Delete types.String `tfsdk:"delete"`
+	Push   types.String `tfsdk:"push"`
+}
+
+// timeoutsBlock returns the schema of the timeouts block shared by the image resources.
+func timeoutsBlock() schema.SingleNestedBlock {
+	attribute := func(operation string) schema.StringAttribute {
+		return schema.StringAttribute{
+			MarkdownDescription: fmt.Sprintf("Maximum duration of %s, including the build, the push and registry calls, "+
+				"as a Go duration (e.g. `30m`, `1h30m`). Omit for no limit.", operation),
+			Optional: true,
+		}
+	}
+	return schema.SingleNestedBlock{
+		MarkdownDescription: "Timeouts of the operations. When a timeout expires, the running build or push is canceled.",
+		Attributes: map[string]schema.Attribute{
+			"create": attribute("creation"),
+			"update": attribute("update"),
+			"delete": attribute("deletion"),
+			"push": schema.StringAttribute{
+				MarkdownDescription: "Maximum duration of pushing the image to the registry, including retries (`push_retries`) " +
+					"and tagging `additional_tags`, as a Go duration (e.g. `10m`). The build is not included, " +
+					"so that a stalled upload fails without waiting for the create or update timeout. " +
+					"Not applied with `builder = \"kaniko\"` or `direct_push`, which push while building. Omit for no limit.",
+				Optional: true,
+			},
+		},
+	}
+}
+
+// validateTimeouts reports timeouts values that are not valid durations.
+func validateTimeouts(timeouts *TimeoutsModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if timeouts == nil {
+		return diags
+	}
+	for name, value := range map[string]types.String{"create": timeouts.Create, "update": timeouts.Update, "delete": timeouts.Delete, "push": timeouts.Push} {
+		if _, err := parseTimeout(value); err != nil {
+			diags.AddAttributeError(path.Root("timeouts").AtName(name), "Invalid timeout", err.Error())
+		}
+	}
+	return diags
+}
+
+// parseTimeout parses a timeouts value. It returns 0 when the value is not set.
+func parseTimeout(value types.String) (time.Duration, error) {
+	if value.IsNull() || value.IsUnknown() {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value.ValueString())
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: %w", value.ValueString(), err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration must be positive, but got %q", value.ValueString())
+	}
+	return d, nil
+}
+
+// withTimeout returns ctx with the deadline configured by value, or ctx itself when value is not set.
+func withTimeout(ctx context.Context, value types.String) (context.Context, context.CancelFunc, error) {
+	d, err := parseTimeout(value)
+	if err != nil {
+		return ctx, func() {}, err
+	}
+	if d == 0 {
+		return ctx, func() {}, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, d)
+	return ctx, cancel, nil
+}
+
+// timeoutError rewrites err to state the timeout when the deadline of ctx has expired.
+func timeoutError(ctx context.Context, value types.String, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s: %w", value.ValueString(), err)
+	}
+	return err
+}
+
+// withPushTimeout runs push with the deadline of timeouts.push, and rewrites the error to state the push timeout
+// when it expires (rather than the timeout of the whole operation).
+func withPushTimeout(ctx context.Context, timeouts *TimeoutsModel, push func(ctx context.Context) error) error {
+	pushCtx, cancel, err := withTimeout(ctx, timeouts.pushTimeout())
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	err = push(pushCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(pushCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("push timed out after %s (timeouts.push): %w", timeouts.pushTimeout().ValueString(), err)
+	}
+	return err
+}
+
+// createTimeout returns timeouts.create, or null when the timeouts block is omitted.
+func (t *TimeoutsModel) createTimeout() types.String {
+	if t == nil {
+		return types.StringNull()
+	}
+	return t.Create
+}
+
+// updateTimeout returns timeouts.update, or null when the timeouts block is omitted.
+func (t *TimeoutsModel) updateTimeout() types.String {
+	if t == nil {
+		return types.StringNull()
+	}
+	return t.Update
+}
+
+// deleteTimeout returns timeouts.delete, or null when the timeouts block is omitted.
+func (t *TimeoutsModel) deleteTimeout() types.String {
+	if t == nil {
+		return types.StringNull()
+	}
+	return t.Delete
+}
+
+// pushTimeout returns timeouts.push, or null when the timeouts block is omitted.
+func (t *TimeoutsModel) pushTimeout() types.String {
+	if t == nil {
+		return types.StringNull()
+	}
+	return t.Push
+}
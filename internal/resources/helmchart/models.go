@@ -0,0 +1,18 @@
+package helmchart
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// HelmChartResourceModel describes the containerregistry_helm_chart resource data model.
+type HelmChartResourceModel struct {
+	ID           types.String `tfsdk:"id"`
+	ChartPath    types.String `tfsdk:"chart_path"`
+	Repository   types.String `tfsdk:"repository"`
+	Triggers     types.Map    `tfsdk:"triggers"`
+	DeleteChart  types.Bool   `tfsdk:"delete_chart"`
+	Name         types.String `tfsdk:"name"`
+	Version      types.String `tfsdk:"version"`
+	ImageURI     types.String `tfsdk:"image_uri"`
+	SHA256Digest types.String `tfsdk:"sha256_digest"`
+}
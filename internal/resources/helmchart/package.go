@@ -0,0 +1,150 @@
+package helmchart
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/moby/patternmatcher"
+	"github.com/moby/patternmatcher/ignorefile"
+	"gopkg.in/yaml.v3"
+)
+
+// Media types of Helm charts stored in OCI registries.
+const (
+	mediaTypeHelmConfig       = "application/vnd.cncf.helm.config.v1+json"
+	mediaTypeHelmChartContent = "application/vnd.cncf.helm.chart.content.v1.tar+gzip"
+)
+
+// chartPackage is a packaged chart.
+type chartPackage struct {
+	Name    string
+	Version string
+	// Config is the chart metadata (Chart.yaml) as JSON, used as the config blob.
+	Config []byte
+	// Content is the chart archive (.tgz).
+	Content []byte
+}
+
+// packageChart packages the chart directory like `helm package`, honoring .helmignore.
+// File timestamps are not recorded in the archive so that the same sources produce the same digest.
+func packageChart(dir string) (*chartPackage, error) {
+	chartYAML, err := os.ReadFile(filepath.Join(dir, "Chart.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Chart.yaml: %w", err)
+	}
+	var metadata map[string]interface{}
+	if err := yaml.Unmarshal(chartYAML, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse Chart.yaml: %w", err)
+	}
+	name, _ := metadata["name"].(string)
+	version, _ := metadata["version"].(string)
+	if name == "" || version == "" {
+		return nil, errors.New("Chart.yaml must specify name and version")
+	}
+	config, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode chart metadata: %w", err)
+	}
+
+	pm, err := readHelmIgnore(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	// WalkDir visits entries in lexical order, which makes the archive deterministic.
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		ignored, err := pm.MatchesOrParentMatches(rel)
+		if err != nil {
+			return fmt.Errorf("failed to match %s: %w", rel, err)
+		}
+		if ignored {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		// Charts are archived under a directory named after the chart.
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    name + "/" + rel,
+			Mode:    int64(info.Mode().Perm()),
+			Size:    int64(len(content)),
+			ModTime: time.Unix(0, 0),
+			Format:  tar.FormatPAX,
+		}); err != nil {
+			return err
+		}
+		_, err = tw.Write(content)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to package chart %s: %w", dir, err)
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+
+	return &chartPackage{
+		Name:    name,
+		Version: version,
+		Config:  config,
+		Content: buf.Bytes(),
+	}, nil
+}
+
+// readHelmIgnore returns a matcher for the patterns in .helmignore of the chart directory.
+func readHelmIgnore(dir string) (*patternmatcher.PatternMatcher, error) {
+	var patterns []string
+	f, err := os.Open(filepath.Join(dir, ".helmignore"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to open .helmignore: %w", err)
+	}
+	if err == nil {
+		defer f.Close()
+		patterns, err = ignorefile.ReadAll(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read .helmignore: %w", err)
+		}
+	}
+	pm, err := patternmatcher.New(patterns)
+	if err != nil {
+		return nil, fmt.Errorf("invalid .helmignore patterns: %w", err)
+	}
+	return pm, nil
+}
@@ -0,0 +1,267 @@
+package helmchart
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/logging"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/providerconfig"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/resources/registrydelete"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ resource.Resource = &HelmChartResource{}
+var _ resource.ResourceWithConfigure = &HelmChartResource{}
+
+// NewHelmChartResource returns a new resource implementing the containerregistry_helm_chart resource type.
+func NewHelmChartResource() resource.Resource {
+	return &HelmChartResource{}
+}
+
+// HelmChartResource defines the resource implementation.
+type HelmChartResource struct {
+	providerConfig *providerconfig.Config
+}
+
+// Metadata returns the resource type name.
+func (r *HelmChartResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_helm_chart"
+}
+
+// Schema defines the schema for the resource.
+func (r *HelmChartResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Helm chart packaged from a chart directory and pushed to a container registry as an OCI artifact",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Identifier of the chart",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"chart_path": schema.StringAttribute{
+				MarkdownDescription: "Path of the chart directory containing Chart.yaml",
+				Required:            true,
+			},
+			"repository": schema.StringAttribute{
+				MarkdownDescription: "Repository to push the chart to, without the chart name (e.g. `asia-northeast1-docker.pkg.dev/project/charts`). " +
+					"The chart is pushed to `<repository>/<name>:<version>` as `helm push` does.",
+				Required: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"triggers": schema.MapAttribute{
+				MarkdownDescription: "Map of arbitrary strings that, when changed, will force the chart to be packaged and pushed again",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"delete_chart": schema.BoolAttribute{
+				MarkdownDescription: "Whether to delete the chart from the registry when the resource is deleted",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"name": schema.StringAttribute{
+				MarkdownDescription: "Name of the chart in Chart.yaml",
+				Computed:            true,
+			},
+			"version": schema.StringAttribute{
+				MarkdownDescription: "Version of the chart in Chart.yaml",
+				Computed:            true,
+			},
+			"image_uri": schema.StringAttribute{
+				MarkdownDescription: "URI the chart was pushed to",
+				Computed:            true,
+			},
+			"sha256_digest": schema.StringAttribute{
+				MarkdownDescription: "SHA256 digest of the chart manifest in the registry",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+// Configure adds the provider configured client to the resource.
+func (r *HelmChartResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	if cfg, ok := req.ProviderData.(*providerconfig.Config); ok {
+		r.providerConfig = cfg
+	}
+}
+
+// newClient returns a registry client for imageURI along with the repository path and the tag.
+func (r *HelmChartResource) newClient(imageURI string) (*registryclient.Client, string, string, error) {
+	host, repository, tag, err := registryclient.ParseImageReference(imageURI)
+	if err != nil {
+		return nil, "", "", err
+	}
+	client, err := registryclient.New(r.providerConfig, host)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return client, repository, tag, nil
+}
+
+// pushChart packages the chart and pushes it, updating the computed attributes of model.
+func (r *HelmChartResource) pushChart(ctx context.Context, model *HelmChartResourceModel) error {
+	chart, err := packageChart(model.ChartPath.ValueString())
+	if err != nil {
+		return err
+	}
+	// OCI tags cannot contain "+", so Helm replaces it with "_" (e.g. for build metadata in SemVer).
+	imageURI := fmt.Sprintf("%s/%s:%s",
+		strings.TrimSuffix(model.Repository.ValueString(), "/"), chart.Name, strings.ReplaceAll(chart.Version, "+", "_"))
+
+	client, repository, tag, err := r.newClient(imageURI)
+	if err != nil {
+		return err
+	}
+
+	tflog.Info(ctx, "Pushing Helm chart", map[string]interface{}{
+		"image_uri": imageURI,
+		"chart":     chart.Name,
+		"version":   chart.Version,
+	})
+	digest, err := client.PushArtifact(ctx, repository, tag, &registryclient.Artifact{
+		ConfigMediaType: mediaTypeHelmConfig,
+		Config:          chart.Config,
+		Layers: []registryclient.Layer{
+			{
+				MediaType: mediaTypeHelmChartContent,
+				Content:   chart.Content,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	model.Name = types.StringValue(chart.Name)
+	model.Version = types.StringValue(chart.Version)
+	model.ImageURI = types.StringValue(imageURI)
+	model.SHA256Digest = types.StringValue(digest)
+	return nil
+}
+
+// Create pushes the chart and sets the initial Terraform state.
+func (r *HelmChartResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	// Initialize the HTTP logging subsystem and header masking for this request.
+	ctx = logging.WithHTTPLoggingSubsystem(ctx)
+
+	var plan HelmChartResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.pushChart(ctx, &plan); err != nil {
+		resp.Diagnostics.AddError(
+			"Error pushing Helm chart",
+			fmt.Sprintf("Could not push Helm chart %s: %s", plan.ChartPath.ValueString(), err),
+		)
+		return
+	}
+
+	plan.ID = plan.ImageURI
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+// Read refreshes the Terraform state with the digest in the registry.
+func (r *HelmChartResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// Initialize the HTTP logging subsystem and header masking for this request.
+	ctx = logging.WithHTTPLoggingSubsystem(ctx)
+
+	var state HelmChartResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, repository, tag, err := r.newClient(state.ImageURI.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Error configuring registry client", err.Error())
+		return
+	}
+	digest, err := client.ResolveDigest(ctx, repository, tag)
+	if registryclient.IsNotFound(err) {
+		tflog.Warn(ctx, "Helm chart not found in registry", map[string]interface{}{
+			"image_uri": state.ImageURI.ValueString(),
+			"error":     err.Error(),
+		})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error reading Helm chart",
+			fmt.Sprintf("Could not read Helm chart %s: %s", state.ImageURI.ValueString(), err),
+		)
+		return
+	}
+
+	state.SHA256Digest = types.StringValue(digest)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+// Update packages and pushes the chart again and sets the updated Terraform state on success.
+func (r *HelmChartResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	// Initialize the HTTP logging subsystem and header masking for this request.
+	ctx = logging.WithHTTPLoggingSubsystem(ctx)
+
+	var plan HelmChartResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.pushChart(ctx, &plan); err != nil {
+		resp.Diagnostics.AddError(
+			"Error pushing Helm chart",
+			fmt.Sprintf("Could not push Helm chart %s: %s", plan.ChartPath.ValueString(), err),
+		)
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+// Delete deletes the chart from the registry when delete_chart is set.
+func (r *HelmChartResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	// Initialize the HTTP logging subsystem and header masking for this request.
+	ctx = logging.WithHTTPLoggingSubsystem(ctx)
+
+	var state HelmChartResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !state.DeleteChart.ValueBool() || state.SHA256Digest.ValueString() == "" {
+		return
+	}
+
+	tflog.Info(ctx, "Deleting Helm chart from registry", map[string]interface{}{
+		"image_uri": state.ImageURI.ValueString(),
+		"digest":    state.SHA256Digest.ValueString(),
+	})
+	client, repository, _, err := r.newClient(state.ImageURI.ValueString())
+	if err == nil {
+		err = client.DeleteManifest(ctx, repository, state.SHA256Digest.ValueString())
+	}
+	// Continue with resource deletion even if chart deletion fails
+	registrydelete.WarnDeleteError(&resp.Diagnostics, "Helm chart", state.ImageURI.ValueString(), "delete_chart", err)
+}
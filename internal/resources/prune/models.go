@@ -0,0 +1,16 @@
+package prune
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// PruneResourceModel describes the containerregistry_prune resource data model.
+type PruneResourceModel struct {
+	ID          types.String `tfsdk:"id"`
+	Repository  types.String `tfsdk:"repository"`
+	Keep        types.Int64  `tfsdk:"keep"`
+	Regex       types.String `tfsdk:"regex"`
+	DryRun      types.Bool   `tfsdk:"dry_run"`
+	Triggers    types.Map    `tfsdk:"triggers"`
+	DeletedTags types.List   `tfsdk:"deleted_tags"`
+}
@@ -0,0 +1,75 @@
+package prune
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	ecrtypes "github.com/aws/aws-sdk-go-v2/service/ecr/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// garHostPattern matches Google Artifact Registry Docker hosts: <location>-docker.pkg.dev
+var garHostPattern = regexp.MustCompile(`^([a-z0-9-]+)-docker\.pkg\.dev$`)
+
+// imageDeleter deletes the manifest digest and its tags from the repository.
+type imageDeleter func(ctx context.Context, digest string, tags []string) error
+
+// newImageDeleter returns the imageDeleter for repository on host.
+// ECR does not delete manifests through the Registry API, so images are deleted with BatchDeleteImage.
+// Artifact Registry refuses to delete a manifest that still has tags, so the tags are deleted first.
+// Other registries delete the manifest, with all its tags, through the Registry API.
+func newImageDeleter(client *registryclient.Client, host, repository string) imageDeleter {
+	if registry, ok := registryclient.ParseECRHost(host); ok {
+		return func(ctx context.Context, digest string, tags []string) error {
+			return deleteECRImage(ctx, registry, repository, digest)
+		}
+	}
+	deleteManifest := func(ctx context.Context, digest string, tags []string) error {
+		return client.DeleteManifest(ctx, repository, digest)
+	}
+	if garHostPattern.MatchString(host) {
+		return func(ctx context.Context, digest string, tags []string) error {
+			for _, tag := range tags {
+				if err := client.DeleteManifest(ctx, repository, tag); err != nil && !registryclient.IsNotFound(err) {
+					return fmt.Errorf("failed to delete tag %s: %w", tag, err)
+				}
+			}
+			return deleteManifest(ctx, digest, tags)
+		}
+	}
+	return deleteManifest
+}
+
+// deleteECRImage deletes the image digest, with all its tags, from the ECR repository.
+// An image already deleted is not an error.
+func deleteECRImage(ctx context.Context, registry registryclient.ECRRegistry, repository, digest string) error {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(registry.Region))
+	if err != nil {
+		return fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	tflog.Debug(ctx, "Deleting image with ECR BatchDeleteImage", map[string]interface{}{
+		"repository": repository,
+		"digest":     digest,
+	})
+	out, err := ecr.NewFromConfig(cfg).BatchDeleteImage(ctx, &ecr.BatchDeleteImageInput{
+		RegistryId:     aws.String(registry.Account),
+		RepositoryName: aws.String(repository),
+		ImageIds:       []ecrtypes.ImageIdentifier{{ImageDigest: aws.String(digest)}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete image %s: %w", digest, err)
+	}
+	for _, failure := range out.Failures {
+		if failure.FailureCode == ecrtypes.ImageFailureCodeImageNotFound {
+			continue
+		}
+		return fmt.Errorf("failed to delete image %s: %s: %s", digest, failure.FailureCode, aws.ToString(failure.FailureReason))
+	}
+	return nil
+}
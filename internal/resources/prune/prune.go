@@ -0,0 +1,112 @@
+package prune
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// taggedImage is a tag that is a candidate for deletion.
+type taggedImage struct {
+	Tag     string
+	Digest  string
+	Created time.Time
+}
+
+// pruneTags deletes the manifests of the tags matching re, except for the keep most recently created ones.
+// Manifests that are also referenced by a tag to keep (or a tag not matching re) are never deleted,
+// as deleting a manifest removes all of its tags. Manifests are deleted with deleteImage.
+// It returns the deleted (or, for dry runs, deletable) tags.
+func pruneTags(ctx context.Context, client *registryclient.Client, deleteImage imageDeleter, repository string, keep int, re *regexp.Regexp, dryRun bool) ([]string, error) {
+	tags, err := client.ListTags(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []taggedImage
+	protected := map[string]bool{}
+	tagsByDigest := map[string][]string{}
+	for _, tag := range tags {
+		digest, err := client.ResolveDigest(ctx, repository, tag)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve tag %s: %w", tag, err)
+		}
+		tagsByDigest[digest] = append(tagsByDigest[digest], tag)
+		if re != nil && !re.MatchString(tag) {
+			protected[digest] = true
+			continue
+		}
+		config, err := client.GetImageConfig(ctx, repository, digest, "")
+		if err != nil {
+			// Artifacts such as Helm charts, signatures and attestations have no image config to order them by.
+			tflog.Warn(ctx, "Tag has no readable image config; keeping it", map[string]interface{}{
+				"tag":   tag,
+				"error": err.Error(),
+			})
+			protected[digest] = true
+			continue
+		}
+		created, err := time.Parse(time.RFC3339Nano, config.Created)
+		if err != nil {
+			// Without a creation time, the tag cannot be ordered safely.
+			tflog.Warn(ctx, "Image has no creation time; keeping it", map[string]interface{}{
+				"tag": tag,
+			})
+			protected[digest] = true
+			continue
+		}
+		candidates = append(candidates, taggedImage{Tag: tag, Digest: digest, Created: created})
+	}
+
+	// Newest first; tags are compared to keep the order stable for images created at the same time.
+	sort.Slice(candidates, func(i, j int) bool {
+		if !candidates[i].Created.Equal(candidates[j].Created) {
+			return candidates[i].Created.After(candidates[j].Created)
+		}
+		return candidates[i].Tag > candidates[j].Tag
+	})
+	if len(candidates) <= keep {
+		return []string{}, nil
+	}
+	for _, c := range candidates[:keep] {
+		protected[c.Digest] = true
+	}
+
+	deleted := []string{}
+	deletedDigests := map[string]bool{}
+	for _, c := range candidates[keep:] {
+		if protected[c.Digest] {
+			tflog.Info(ctx, "Keeping tag sharing its manifest with a kept tag", map[string]interface{}{
+				"tag":    c.Tag,
+				"digest": c.Digest,
+			})
+			continue
+		}
+		if dryRun || deletedDigests[c.Digest] {
+			deleted = append(deleted, c.Tag)
+			continue
+		}
+		tflog.Info(ctx, "Deleting tag", map[string]interface{}{
+			"repository": repository,
+			"tag":        c.Tag,
+			"digest":     c.Digest,
+		})
+		if err := deleteImage(ctx, c.Digest, tagsByDigest[c.Digest]); err != nil {
+			if registryclient.IsUnsupported(err) {
+				return deleted, fmt.Errorf("the registry does not support deleting images: %w", err)
+			}
+			if !registryclient.IsNotFound(err) {
+				return deleted, fmt.Errorf("failed to delete tag %s: %w", c.Tag, err)
+			}
+		}
+		deletedDigests[c.Digest] = true
+		deleted = append(deleted, c.Tag)
+	}
+	return deleted, nil
+}
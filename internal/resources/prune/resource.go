@@ -0,0 +1,193 @@
+package prune
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/logging"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/providerconfig"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ resource.Resource = &PruneResource{}
+var _ resource.ResourceWithConfigure = &PruneResource{}
+
+// NewPruneResource returns a new resource implementing the containerregistry_prune resource type.
+func NewPruneResource() resource.Resource {
+	return &PruneResource{}
+}
+
+// PruneResource defines the resource implementation.
+type PruneResource struct {
+	providerConfig *providerconfig.Config
+}
+
+// Metadata returns the resource type name.
+func (r *PruneResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_prune"
+}
+
+// Schema defines the schema for the resource.
+func (r *PruneResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Deletes old tags of a repository, keeping the most recently created ones. " +
+			"Pruning runs when the resource is created or updated; change `triggers` to run it again.",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Repository the tags are pruned in",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"repository": schema.StringAttribute{
+				MarkdownDescription: "Repository to prune (e.g. `asia-northeast1-docker.pkg.dev/project/repo/app`)",
+				Required:            true,
+			},
+			"keep": schema.Int64Attribute{
+				MarkdownDescription: "Number of the most recently created tags matching `regex` to keep",
+				Required:            true,
+			},
+			"regex": schema.StringAttribute{
+				MarkdownDescription: "Only prune tags matching this regular expression (RE2 syntax). " +
+					"Tags not matching are always kept. Omit to prune all tags.",
+				Optional: true,
+			},
+			"dry_run": schema.BoolAttribute{
+				MarkdownDescription: "Only report the tags that would be deleted in `deleted_tags`",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"triggers": schema.MapAttribute{
+				MarkdownDescription: "Map of arbitrary strings that, when changed, will run pruning again " +
+					"(e.g. the digest of a newly pushed image)",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"deleted_tags": schema.ListAttribute{
+				MarkdownDescription: "Tags deleted in the last run",
+				Computed:            true,
+				ElementType:         types.StringType,
+			},
+		},
+	}
+}
+
+// Configure adds the provider configured client to the resource.
+func (r *PruneResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	if cfg, ok := req.ProviderData.(*providerconfig.Config); ok {
+		r.providerConfig = cfg
+	}
+}
+
+// prune runs pruning and sets the computed attributes of model.
+func (r *PruneResource) prune(ctx context.Context, model *PruneResourceModel) error {
+	if model.Keep.ValueInt64() < 0 {
+		return fmt.Errorf("keep must not be negative")
+	}
+	var re *regexp.Regexp
+	if !model.Regex.IsNull() {
+		var err error
+		re, err = regexp.Compile(model.Regex.ValueString())
+		if err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+	}
+	host, repository, err := registryclient.ParseRepository(model.Repository.ValueString())
+	if err != nil {
+		return err
+	}
+	client, err := registryclient.New(r.providerConfig, host)
+	if err != nil {
+		return err
+	}
+
+	tflog.Info(ctx, "Pruning tags", map[string]interface{}{
+		"registry":   host,
+		"repository": repository,
+		"keep":       model.Keep.ValueInt64(),
+		"dry_run":    model.DryRun.ValueBool(),
+	})
+	deleted, err := pruneTags(ctx, client, newImageDeleter(client, host, repository), repository, int(model.Keep.ValueInt64()), re, model.DryRun.ValueBool())
+	if err != nil {
+		if len(deleted) > 0 {
+			return fmt.Errorf("%w (deleted before the failure: %v)", err, deleted)
+		}
+		return err
+	}
+
+	deletedTags, diags := types.ListValueFrom(ctx, types.StringType, deleted)
+	if diags.HasError() {
+		return fmt.Errorf("failed to convert deleted tags")
+	}
+	model.ID = types.StringValue(host + "/" + repository)
+	model.DeletedTags = deletedTags
+	return nil
+}
+
+// Create prunes the tags and sets the initial Terraform state.
+func (r *PruneResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	// Initialize the HTTP logging subsystem and header masking for this request.
+	ctx = logging.WithHTTPLoggingSubsystem(ctx)
+
+	var plan PruneResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.prune(ctx, &plan); err != nil {
+		resp.Diagnostics.AddError(
+			"Error pruning tags",
+			fmt.Sprintf("Could not prune tags of %s: %s", plan.Repository.ValueString(), err),
+		)
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+// Read keeps the state as is; pruning has no remote object to refresh.
+func (r *PruneResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+}
+
+// Update prunes the tags again and sets the updated Terraform state on success.
+func (r *PruneResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	// Initialize the HTTP logging subsystem and header masking for this request.
+	ctx = logging.WithHTTPLoggingSubsystem(ctx)
+
+	var plan PruneResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.prune(ctx, &plan); err != nil {
+		resp.Diagnostics.AddError(
+			"Error pruning tags",
+			fmt.Sprintf("Could not prune tags of %s: %s", plan.Repository.ValueString(), err),
+		)
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+// Delete removes the resource from the Terraform state. Deleted tags are not restored.
+func (r *PruneResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+}
@@ -0,0 +1,39 @@
+// Package registrydelete reports the deletion of manifests pushed by the registry-only resources.
+package registrydelete
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// WarnDeleteError reports err from deleting the manifest of a resource as a warning, so that the resource is removed
+// from the state even when the manifest is left in the registry. kind names what was pushed (e.g. "Helm chart"),
+// name identifies it in the messages, and attribute is the attribute to set false to skip the deletion.
+// A nil err reports nothing.
+func WarnDeleteError(diags *diag.Diagnostics, kind, name, attribute string, err error) {
+	title := strings.ToUpper(kind[:1]) + kind[1:]
+	switch {
+	case err == nil:
+	case registryclient.IsNotFound(err):
+		diags.AddWarning(
+			title+" already deleted from registry",
+			fmt.Sprintf("%s %s was not found in the registry; treating it as deleted: %s", title, name, err),
+		)
+	case registryclient.IsUnsupported(err):
+		diags.AddWarning(
+			title+" deletion not supported by registry",
+			fmt.Sprintf("The registry rejected deleting %s %s, so it was left in the registry. "+
+				"Enable deletion in the registry or remove the %s manually, or set %s = false to skip deletion: %s",
+				kind, name, kind, attribute, err),
+		)
+	default:
+		diags.AddWarning(
+			"Error deleting "+kind+" from registry",
+			fmt.Sprintf("Could not delete %s %s: %s", kind, name, err),
+		)
+	}
+}
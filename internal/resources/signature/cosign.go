@@ -0,0 +1,154 @@
+package signature
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/providerconfig"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// defaultCosignPath is the cosign command used when cosign_path is not set.
+const defaultCosignPath = "cosign"
+
+// dockerHubAuthKey is the key of Docker Hub credentials in a Docker config.json.
+const dockerHubAuthKey = "https://index.docker.io/v1/"
+
+// runCosign runs cosign with args and returns its standard output.
+// Registry credentials from the provider registry_auth are passed for host, if configured,
+// through a temporary Docker config rather than the command line, where other users of the host could read them.
+func (r *SignatureResource) runCosign(ctx context.Context, model *SignatureResourceModel, host string, args []string) (string, error) {
+	cosignPath := model.CosignPath.ValueString()
+	if cosignPath == "" {
+		cosignPath = defaultCosignPath
+	}
+
+	cmd := exec.CommandContext(ctx, cosignPath, args...)
+	cmd.Env = os.Environ()
+	if r.providerConfig != nil {
+		if creds, ok := r.providerConfig.RegistryAuth[host]; ok {
+			dir, err := writeCosignDockerConfig(host, creds)
+			if err != nil {
+				return "", err
+			}
+			defer os.RemoveAll(dir)
+			cmd.Env = append(cmd.Env, "DOCKER_CONFIG="+dir)
+		}
+	}
+	if !model.KeyPassword.IsNull() {
+		cmd.Env = append(cmd.Env, "COSIGN_PASSWORD="+model.KeyPassword.ValueString())
+	}
+	if !model.IdentityToken.IsNull() && model.IdentityToken.ValueString() != "" {
+		cmd.Env = append(cmd.Env, "SIGSTORE_ID_TOKEN="+model.IdentityToken.ValueString())
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	tflog.Debug(ctx, "Running cosign", map[string]interface{}{
+		"command": cosignPath,
+		"action":  args[0],
+	})
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("cosign %s failed: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// signImage signs the image with cosign and returns the signature reference.
+func (r *SignatureResource) signImage(ctx context.Context, model *SignatureResourceModel) (string, error) {
+	host, _, ref, err := registryclient.ParseImageReference(model.ImageURI.ValueString())
+	if err != nil {
+		return "", err
+	}
+	if !strings.Contains(ref, ":") {
+		return "", fmt.Errorf("image URI %s must specify a digest (e.g. repo@sha256:...)", model.ImageURI.ValueString())
+	}
+
+	args := []string{"sign", "--yes"}
+	if !model.Key.IsNull() && model.Key.ValueString() != "" {
+		args = append(args, "--key", model.Key.ValueString())
+	}
+	if !model.Annotations.IsNull() && !model.Annotations.IsUnknown() {
+		annotations := map[string]string{}
+		if diags := model.Annotations.ElementsAs(ctx, &annotations, false); diags.HasError() {
+			return "", fmt.Errorf("invalid annotations")
+		}
+		keys := make([]string, 0, len(annotations))
+		for k := range annotations {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			args = append(args, "-a", k+"="+annotations[k])
+		}
+	}
+	args = append(args, model.ImageURI.ValueString())
+
+	tflog.Info(ctx, "Signing image with cosign", map[string]interface{}{
+		"image_uri": model.ImageURI.ValueString(),
+		"keyless":   model.Key.IsNull() || model.Key.ValueString() == "",
+	})
+	if _, err := r.runCosign(ctx, model, host, args); err != nil {
+		return "", err
+	}
+	return r.signatureRef(ctx, model)
+}
+
+// signatureRef returns the reference where cosign stores the signatures of the image.
+func (r *SignatureResource) signatureRef(ctx context.Context, model *SignatureResourceModel) (string, error) {
+	host, _, _, err := registryclient.ParseImageReference(model.ImageURI.ValueString())
+	if err != nil {
+		return "", err
+	}
+	return r.runCosign(ctx, model, host, []string{"triangulate", "--type", "signature", model.ImageURI.ValueString()})
+}
+
+// signatureDigest returns the manifest digest of the signature reference.
+func (r *SignatureResource) signatureDigest(ctx context.Context, signatureRef string) (string, error) {
+	host, repository, ref, err := registryclient.ParseImageReference(signatureRef)
+	if err != nil {
+		return "", err
+	}
+	client, err := registryclient.New(r.providerConfig, host)
+	if err != nil {
+		return "", err
+	}
+	return client.ResolveDigest(ctx, repository, ref)
+}
+
+// writeCosignDockerConfig writes a Docker config.json holding creds for host into a new temporary directory,
+// for cosign to authenticate to the registry with DOCKER_CONFIG. The caller removes the directory.
+func writeCosignDockerConfig(host string, creds providerconfig.RegistryAuthCredentials) (string, error) {
+	key := host
+	if host == "docker.io" || host == "index.docker.io" {
+		key = dockerHubAuthKey
+	}
+	body, err := json.Marshal(map[string]any{
+		"auths": map[string]map[string]string{
+			key: {"auth": base64.StdEncoding.EncodeToString([]byte(creds.Username + ":" + creds.Password))},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode Docker config: %w", err)
+	}
+	dir, err := os.MkdirTemp("", "containerregistry-cosign-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create directory for cosign: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), body, 0600); err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to write Docker config: %w", err)
+	}
+	return dir, nil
+}
@@ -0,0 +1,18 @@
+package signature
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// SignatureResourceModel describes the containerregistry_signature resource data model.
+type SignatureResourceModel struct {
+	ID              types.String `tfsdk:"id"`
+	ImageURI        types.String `tfsdk:"image_uri"`
+	Key             types.String `tfsdk:"key"`
+	KeyPassword     types.String `tfsdk:"key_password"`
+	IdentityToken   types.String `tfsdk:"identity_token"`
+	Annotations     types.Map    `tfsdk:"annotations"`
+	CosignPath      types.String `tfsdk:"cosign_path"`
+	SignatureRef    types.String `tfsdk:"signature_ref"`
+	SignatureDigest types.String `tfsdk:"signature_digest"`
+}
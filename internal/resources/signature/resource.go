@@ -0,0 +1,201 @@
+package signature
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/ikedam/terraform-provider-containerregistry/internal/logging"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/providerconfig"
+	"github.com/ikedam/terraform-provider-containerregistry/internal/registryclient"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ resource.Resource = &SignatureResource{}
+var _ resource.ResourceWithConfigure = &SignatureResource{}
+
+// NewSignatureResource returns a new resource implementing the containerregistry_signature resource type.
+func NewSignatureResource() resource.Resource {
+	return &SignatureResource{}
+}
+
+// SignatureResource defines the resource implementation.
+type SignatureResource struct {
+	providerConfig *providerconfig.Config
+}
+
+// Metadata returns the resource type name.
+func (r *SignatureResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_signature"
+}
+
+// Schema defines the schema for the resource.
+func (r *SignatureResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Cosign signature of an image in a container registry. Requires the `cosign` command.",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Identifier of the signature",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"image_uri": schema.StringAttribute{
+				MarkdownDescription: "Image to sign, pinned by digest (e.g. `ghcr.io/owner/app@sha256:...`)",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"key": schema.StringAttribute{
+				MarkdownDescription: "Path of the private key file or KMS key URI (e.g. `awskms:///alias/cosign`, `gcpkms://projects/...`). " +
+					"Omit for keyless signing with an OIDC identity.",
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"key_password": schema.StringAttribute{
+				MarkdownDescription: "Password of the private key file, passed as `COSIGN_PASSWORD`",
+				Optional:            true,
+				Sensitive:           true,
+			},
+			"identity_token": schema.StringAttribute{
+				MarkdownDescription: "OIDC identity token for keyless signing, passed as `SIGSTORE_ID_TOKEN`. " +
+					"When omitted, cosign looks for an ambient token (e.g. GitHub Actions).",
+				Optional:  true,
+				Sensitive: true,
+			},
+			"annotations": schema.MapAttribute{
+				MarkdownDescription: "Annotations to add to the signature",
+				Optional:            true,
+				ElementType:         types.StringType,
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.RequiresReplace(),
+				},
+			},
+			"cosign_path": schema.StringAttribute{
+				MarkdownDescription: "Path of the cosign command. Defaults to `cosign` in `PATH`.",
+				Optional:            true,
+			},
+			"signature_ref": schema.StringAttribute{
+				MarkdownDescription: "Reference where the signatures of the image are stored",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"signature_digest": schema.StringAttribute{
+				MarkdownDescription: "Digest of the signature manifest. It changes when the image is signed again.",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+// Configure adds the provider configured client to the resource.
+func (r *SignatureResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	if cfg, ok := req.ProviderData.(*providerconfig.Config); ok {
+		r.providerConfig = cfg
+	}
+}
+
+// Create signs the image and sets the initial Terraform state.
+func (r *SignatureResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	// Initialize the HTTP logging subsystem and header masking for this request.
+	ctx = logging.WithHTTPLoggingSubsystem(ctx)
+
+	var plan SignatureResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	signatureRef, err := r.signImage(ctx, &plan)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error signing image",
+			fmt.Sprintf("Could not sign image %s: %s", plan.ImageURI.ValueString(), err),
+		)
+		return
+	}
+	digest, err := r.signatureDigest(ctx, signatureRef)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error reading signature",
+			fmt.Sprintf("Image %s was signed, but the signature %s could not be read: %s", plan.ImageURI.ValueString(), signatureRef, err),
+		)
+		return
+	}
+
+	plan.ID = plan.ImageURI
+	plan.SignatureRef = types.StringValue(signatureRef)
+	plan.SignatureDigest = types.StringValue(digest)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+// Read refreshes the Terraform state with the signature in the registry.
+func (r *SignatureResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// Initialize the HTTP logging subsystem and header masking for this request.
+	ctx = logging.WithHTTPLoggingSubsystem(ctx)
+
+	var state SignatureResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if state.SignatureRef.ValueString() == "" {
+		return
+	}
+
+	digest, err := r.signatureDigest(ctx, state.SignatureRef.ValueString())
+	if registryclient.IsNotFound(err) {
+		tflog.Warn(ctx, "Signature not found in registry", map[string]interface{}{
+			"signature_ref": state.SignatureRef.ValueString(),
+			"error":         err.Error(),
+		})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error reading signature",
+			fmt.Sprintf("Could not read signature %s: %s", state.SignatureRef.ValueString(), err),
+		)
+		return
+	}
+
+	state.SignatureDigest = types.StringValue(digest)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+// Update stores the attributes that do not require signing again (key_password, identity_token, cosign_path).
+func (r *SignatureResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan SignatureResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+// Delete removes the resource from the Terraform state.
+// The signature is left in the registry as it may be shared with other signatures of the same image.
+func (r *SignatureResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	tflog.Info(ctx, "Removing signature from state; the signature is left in the registry")
+}
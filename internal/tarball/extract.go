@@ -0,0 +1,132 @@
+// Package tarball extracts tar archives (optionally gzip-compressed) to disk.
+package tarball
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Extract extracts the directories, regular files and symbolic links of the tarball at path into dir,
+// keeping their permission bits. The tarball is decompressed when it starts with the gzip magic number.
+// Entries escaping dir, either by name or through a symbolic link extracted earlier, are rejected.
+func Extract(path, dir string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open tarball: %w", err)
+	}
+	defer f.Close()
+
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", dir, err)
+	}
+
+	br := bufio.NewReader(f)
+	var r io.Reader = br
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return fmt.Errorf("failed to read gzip tarball: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tarball: %w", err)
+		}
+		target, err := SecurePath(root, hdr.Name)
+		if err != nil {
+			return err
+		}
+		if target == root {
+			continue
+		}
+		if err := checkParent(root, target, hdr.Name); err != nil {
+			return err
+		}
+		mode := hdr.FileInfo().Mode().Perm()
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return fmt.Errorf("failed to create directory: %w", err)
+			}
+			if err := os.Chmod(target, mode|0700); err != nil {
+				return fmt.Errorf("failed to set mode of %s: %w", hdr.Name, err)
+			}
+		case tar.TypeReg:
+			// Replace rather than write through a symbolic link extracted earlier at the same path
+			if fi, err := os.Lstat(target); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+				_ = os.Remove(target)
+			}
+			if err := WriteFile(target, tr, mode|0600); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return fmt.Errorf("failed to create directory: %w", err)
+			}
+			_ = os.Remove(target)
+			if err := os.Symlink(hdr.Linkname, target); err != nil {
+				return fmt.Errorf("failed to create symbolic link %s: %w", hdr.Name, err)
+			}
+		}
+	}
+}
+
+// checkParent rejects target (extracted from the entry name) when its parent directory, as far as it exists, resolves outside root
+// because of a symbolic link extracted earlier.
+func checkParent(root, target, name string) error {
+	parent := filepath.Dir(target)
+	for {
+		resolved, err := filepath.EvalSymlinks(parent)
+		if err == nil {
+			if resolved != root && !strings.HasPrefix(resolved, root+string(filepath.Separator)) {
+				return fmt.Errorf("invalid path %q in tarball: escapes through a symbolic link", name)
+			}
+			return nil
+		}
+		if !errors.Is(err, os.ErrNotExist) || parent == root {
+			return fmt.Errorf("failed to resolve %s: %w", parent, err)
+		}
+		parent = filepath.Dir(parent)
+	}
+}
+
+// WriteFile writes the content of r to path with mode, creating parent directories.
+func WriteFile(path string, r io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		_ = out.Close()
+		return fmt.Errorf("failed to extract file: %w", err)
+	}
+	return out.Close()
+}
+
+// SecurePath joins name to dir, rejecting names that escape dir.
+func SecurePath(dir, name string) (string, error) {
+	cleaned := filepath.Clean(filepath.FromSlash(name))
+	if filepath.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid path %q in tarball", name)
+	}
+	return filepath.Join(dir, cleaned), nil
+}